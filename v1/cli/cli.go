@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/bjatkin/silabex/derive"
+	"github.com/bjatkin/silabex/font"
+)
+
+// command is a single subcommand of the silabex tool
+type command struct {
+	name  string
+	usage string
+	run   func(env *env, args []string) error
+}
+
+// env is where a command reads its input and writes its output
+type env struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+// commands lists every subcommand in the order they are shown in the usage
+var commands = []command{
+	{"render", "render text or chords as an svg or png page", runRender},
+	{"derive", "expand the derivation rules and print every rule", runDerive},
+	{"validate", "check the template, metrics and rules for problems", runValidate},
+	{"lint", "check the labels of the template's groups", runLint},
+	{"export", "write the font as a ttf or the private use area table", runExport},
+	{"coverage", "report which strokes the template draws", runCoverage},
+	{"skeleton", "write an empty template for a new font", runSkeleton},
+	{"serve", "preview the font in a browser, pages reload when the template or rules change", runServe},
+}
+
+// ErrUsage is returned when the arguments do not name a command or have invalid flags
+var ErrUsage = errors.New("invalid usage")
+
+// Run runs the subcommand named by the first argument with the rest of the arguments as its flags
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	e := &env{stdin: stdin, stdout: stdout, stderr: stderr}
+	if len(args) == 0 {
+		usage(stderr)
+		return ErrUsage
+	}
+
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(e, args[1:])
+		}
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "-help" {
+		usage(stdout)
+		return nil
+	}
+
+	usage(stderr)
+	return fmt.Errorf("%w: unknown command %q", ErrUsage, args[0])
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: silabex <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", c.name, c.usage)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "run silabex <command> -h to see the flags of a command")
+}
+
+// flagSet creates the flags for a command, parse errors are returned rather than exiting
+func (e *env) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("silabex "+name, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	return fs
+}
+
+// parse parses the flags of a command, asking for help is not an error
+func parse(fs *flag.FlagSet, args []string) (bool, error) {
+	err := fs.Parse(args)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("%w: %w", ErrUsage, err)
+	default:
+		return true, nil
+	}
+}
+
+// files are the template and rules the commands are run against
+type files struct {
+	font    string
+	rules   string
+	dialect string
+}
+
+// registerFont adds the -font flag
+func (f *files) registerFont(fs *flag.FlagSet) {
+	fs.StringVar(&f.font, "font", "reference/font2.svg", "the font template svg")
+}
+
+// registerRules adds the -rules and -dialect flags, an empty -rules flag uses no rules
+func (f *files) registerRules(fs *flag.FlagSet) {
+	fs.StringVar(&f.rules, "rules", "reference/derive.dat", "the derivation rules file")
+	fs.StringVar(&f.dialect, "dialect", "keys", "the dialect of the rules file, keys (see reference/derive.dat) or capture (see reference/derive_2.dat)")
+}
+
+// loadRules reads and expands the rules file in its dialect
+func (f *files) loadRules() ([]derive.Rule, error) {
+	switch f.dialect {
+	case "keys":
+		return derive.LoadRules(f.rules)
+	case "capture":
+		return derive.LoadCaptureRules(f.rules)
+	default:
+		return nil, fmt.Errorf("%w: unknown rules dialect %q", ErrUsage, f.dialect)
+	}
+}
+
+// loadFont loads the template and builds the strokes it does not draw with the rules. Commands without
+// the rules flags, or with an empty -rules flag, only draw the strokes in the template
+func (f *files) loadFont() (*font.Font, error) {
+	fnt, err := font.NewFont(f.font)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.rules == "" {
+		return fnt, nil
+	}
+
+	rules, err := f.loadRules()
+	if err != nil {
+		return nil, err
+	}
+	fnt.Derive(rules)
+
+	return fnt, nil
+}
+
+// write writes data to the file at path, or to stdout if the path is empty or -
+func (e *env) write(path string, data []byte) error {
+	if path == "" || path == "-" {
+		_, err := e.stdout.Write(data)
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// listFlag is a flag that can be given more than once
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
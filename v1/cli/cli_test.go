@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	badRules := filepath.Join(dir, "bad.dat")
+	if err := os.WriteFile(badRules, []byte("@O | E.d\n@ | |\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	captureRules := filepath.Join(dir, "capture.dat")
+	if err := os.WriteFile(captureRules, []byte("v2 | 1.u\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile("../reference/font2.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	brokenFont := filepath.Join(dir, "broken.svg")
+	broken := strings.Replace(string(raw), `inkscape:label="0123"`, `inkscape:label="vowel 0123"`, 1)
+	if err := os.WriteFile(brokenFont, []byte(broken), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	font := "-font=../reference/font2.svg"
+	rules := "-rules=../reference/derive.dat"
+	tests := []struct {
+		name    string
+		args    []string
+		stdin   string
+		wantErr error
+		// want is a part of stdout, or of the output file when file is set
+		want string
+		file string
+	}{
+		{"no command", nil, "", ErrUsage, "", ""},
+		{"unknown command", []string{"draw"}, "", ErrUsage, "", ""},
+		{"help", []string{"help"}, "", nil, "usage: silabex <command> [flags]", ""},
+		{"bad flag", []string{"render", "-bogus"}, "", ErrUsage, "", ""},
+		{"command help", []string{"render", "-h"}, "", nil, "", ""},
+		{"render text without a dictionary", []string{"render", font, rules, "hello"}, "", ErrUsage, "", ""},
+		{"render chords", []string{"render", font, rules, "-chords", "KOE/KWOE", "TEFT"}, "", nil, "<svg width=\"800\"", ""},
+		{"render chords from stdin", []string{"render", font, rules, "-chords"}, "KOE\n", nil, "<svg", ""},
+		{"render bad chord", []string{"render", font, rules, "-chords", "KOE/XYZ"}, "", errAny, "", ""},
+		{"render text", []string{"render", font, rules, "-dict=../dict/testdata/main.json", "hello", "moon."}, "", nil, "<svg", ""},
+		{"render without rules", []string{"render", font, "-rules=", "-chords", "KOE"}, "", nil, "<svg", ""},
+		{"render png", []string{"render", font, rules, "-chords", "-o", filepath.Join(dir, "page.png"), "KOE"}, "", nil, "\x89PNG", "page.png"},
+		{"render unknown format", []string{"render", font, rules, "-chords", "-format=gif", "KOE"}, "", ErrUsage, "", ""},
+		{"derive", []string{"derive", "-rules=../reference/derive.dat"}, "", nil, "V.02 | V.0 V.2\n", ""},
+		{"derive capture", []string{"derive", "-rules", captureRules, "-dialect=capture"}, "", nil, "V.2 | V.1.u\n", ""},
+		{"derive reference capture", []string{"derive", "-rules=../reference/derive_2.dat", "-dialect=capture"}, "", nil, "I.2:hang | I.2:stand.u\n", ""},
+		{"derive unknown dialect", []string{"derive", "-rules=../reference/derive.dat", "-dialect=other"}, "", ErrUsage, "", ""},
+		{"validate", []string{"validate", font, "-rules=../reference/derive.dat"}, "", nil, "", ""},
+		{"validate without rules", []string{"validate", font, "-rules=", "-audit=false"}, "", nil, "", ""},
+		{"validate bad rules", []string{"validate", font, "-rules", badRules, "-audit=false"}, "", errAny, "rules: ", ""},
+		{"validate missing font", []string{"validate", "-font=missing.svg", "-rules=../reference/derive.dat"}, "", errAny, "font: ", ""},
+		{"lint", []string{"lint", font}, "", nil, "", ""},
+		{"lint missing font", []string{"lint", "-font=missing.svg"}, "", errAny, "", ""},
+		{"lint broken labels", []string{"lint", "-font", brokenFont}, "", errAny, "vowels/0123: missing, add it to #layer11\n", ""},
+		{"validate broken labels", []string{"validate", "-font", brokenFont, "-rules=../reference/derive.dat", "-audit=false"}, "", errAny, "vowels/0123: missing", ""},
+		{"skeleton", []string{"skeleton"}, "", nil, `<g inkscape:groupmode="layer" id="initial-stand-23" inkscape:label="23" style="display:none">`, ""},
+		{"skeleton file", []string{"skeleton", "-placeholders", "-metrics=../reference/font2.svg", "-o", filepath.Join(dir, "skeleton.svg")}, "", nil, `<rect id="vowels-0123-0"`, "skeleton.svg"},
+		{"skeleton exists", []string{"skeleton", "-o", brokenFont}, "", errAny, "", ""},
+		{"skeleton force", []string{"skeleton", "-force", "-o", filepath.Join(dir, "skeleton.svg")}, "", nil, `inkscape:label="vowels"`, "skeleton.svg"},
+		{"skeleton missing metrics", []string{"skeleton", "-metrics=missing.json"}, "", errAny, "", ""},
+		{"export pua", []string{"export", "-format=pua", "-o=-"}, "", nil, "\n", ""},
+		{"export ttf", []string{"export", font, rules, "-o", filepath.Join(dir, "font.ttf")}, "", nil, "\x00\x01\x00\x00", "font.ttf"},
+		{"export unknown format", []string{"export", font, rules, "-format=otf"}, "", ErrUsage, "", ""},
+		{"serve unknown dialect", []string{"serve", font, "-dialect=other"}, "", ErrUsage, "", ""},
+		{"serve missing font", []string{"serve", "-font=missing.svg", "-rules=../reference/derive.dat", "-addr=localhost:0"}, "", errAny, "", ""},
+		{"coverage", []string{"coverage", font, "-rules=../reference/derive.dat"}, "", nil, " 0  |  x  |  x  |  x  |  x  |  x  |  x  | 2\n", ""},
+		{"coverage json", []string{"coverage", font, "-rules=../reference/derive.dat", "-format=json"}, "", nil, "\"status\": \"explicit\"", ""},
+		{"coverage html file", []string{"coverage", font, "-rules=../reference/derive.dat", "-o", filepath.Join(dir, "coverage.html")}, "", nil, "<table>", "coverage.html"},
+		{"coverage bad rules", []string{"coverage", font, "-rules", badRules}, "", errAny, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			err := Run(tt.args, strings.NewReader(tt.stdin), stdout, stderr)
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Fatalf("Run() error = %v, want nil", err)
+			case tt.wantErr == errAny && err == nil, tt.wantErr != nil && tt.wantErr != errAny && !errors.Is(err, tt.wantErr):
+				t.Fatalf("Run() error = %v, want %v", err, tt.wantErr)
+			}
+
+			got := stdout.String()
+			if tt.file != "" {
+				raw, err := os.ReadFile(filepath.Join(dir, tt.file))
+				if err != nil {
+					t.Fatalf("ReadFile() error = %v", err)
+				}
+				got = string(raw)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("Run() output = %.200q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// errAny matches any error
+var errAny = errors.New("any error")
@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bjatkin/silabex/font"
+)
+
+// runCoverage reports which strokes the template draws, which the rules derive and which are missing
+func runCoverage(e *env, args []string) error {
+	fs := e.flagSet("coverage")
+	f := files{}
+	f.registerFont(fs)
+	f.registerRules(fs)
+	out := fs.String("o", "-", "the output file, - writes to stdout")
+	format := fs.String("format", "", "md, json or html, by default the format is picked from the output file's extension")
+	if ok, err := parse(fs, args); !ok {
+		return err
+	}
+
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*out)), ".")
+		if *format != "json" && *format != "html" {
+			*format = "md"
+		}
+	}
+
+	rules, err := f.loadRules()
+	if err != nil {
+		return err
+	}
+
+	fnt, err := font.NewFont(f.font)
+	if err != nil {
+		return err
+	}
+
+	coverage := fnt.Coverage(rules)
+
+	var data []byte
+	switch *format {
+	case "md":
+		data = []byte(coverage.Markdown())
+	case "json":
+		data, err = coverage.JSON()
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	case "html":
+		page, err := coverage.HTML()
+		if err != nil {
+			return err
+		}
+		data = []byte(page)
+	default:
+		return fmt.Errorf("%w: unknown format %q", ErrUsage, *format)
+	}
+
+	if err := e.write(*out, data); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(e.stderr, coverage.Summary())
+	return nil
+}
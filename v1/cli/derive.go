@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// runDerive expands the derivation rules and prints every concrete rule in the order they are run
+func runDerive(e *env, args []string) error {
+	fs := e.flagSet("derive")
+	f := files{}
+	f.registerRules(fs)
+	out := fs.String("o", "-", "the output file, - writes to stdout")
+	if ok, err := parse(fs, args); !ok {
+		return err
+	}
+
+	rules, err := f.loadRules()
+	if err != nil {
+		return err
+	}
+
+	lines := []string{}
+	for _, rule := range rules {
+		lines = append(lines, rule.String())
+	}
+
+	if err := e.write(*out, []byte(strings.Join(lines, "\n")+"\n")); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(e.stderr, "%d rules\n", len(rules))
+	return nil
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/bjatkin/silabex/opentype"
+	"github.com/bjatkin/silabex/pua"
+)
+
+// runExport writes the font as a truetype font, or writes the table of private use area code points
+func runExport(e *env, args []string) error {
+	fs := e.flagSet("export")
+	f := files{}
+	f.registerFont(fs)
+	f.registerRules(fs)
+	format := fs.String("format", "ttf", "ttf for a truetype font or pua for the private use area table")
+	out := fs.String("o", "", "the output file, by default reference/silabex.ttf or reference/silabex-pua.tsv. - writes to stdout")
+	info := opentype.Info{}
+	fs.StringVar(&info.FamilyName, "family", "", "the family name of the ttf")
+	fs.StringVar(&info.Version, "version", "", "the version of the ttf")
+	if ok, err := parse(fs, args); !ok {
+		return err
+	}
+
+	var data []byte
+	switch *format {
+	case "ttf":
+		fnt, err := f.loadFont()
+		if err != nil {
+			return err
+		}
+
+		data, err = opentype.Export(fnt, info)
+		if err != nil {
+			return err
+		}
+
+		if *out == "" {
+			*out = "reference/silabex.ttf"
+		}
+	case "pua":
+		buf := &bytes.Buffer{}
+		if err := pua.WriteTable(buf); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+
+		if *out == "" {
+			*out = "reference/silabex-pua.tsv"
+		}
+	default:
+		return fmt.Errorf("%w: unknown format %q", ErrUsage, *format)
+	}
+
+	return e.write(*out, data)
+}
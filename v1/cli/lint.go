@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/bjatkin/silabex/font"
+)
+
+// runLint checks the labels of the template, every problem is printed and an error is returned
+// if any were found so the command can be used in CI
+func runLint(e *env, args []string) error {
+	fs := e.flagSet("lint")
+	f := files{}
+	f.registerFont(fs)
+	if ok, err := parse(fs, args); !ok {
+		return err
+	}
+
+	problems, err := font.Lint(f.font)
+	if err != nil {
+		return err
+	}
+	for _, p := range problems {
+		fmt.Fprintln(e.stdout, p)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("found %d problems", len(problems))
+	}
+
+	return nil
+}
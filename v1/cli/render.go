@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/bjatkin/silabex/dict"
+	"github.com/bjatkin/silabex/layout"
+	"github.com/bjatkin/silabex/raster"
+	"github.com/bjatkin/silabex/steno"
+)
+
+// runRender sets text or chords on a page. The input is the arguments joined with spaces, or stdin
+// when there are no arguments. Text is looked up in the dictionaries, chords are written as given
+// and only use the dictionaries to join multi stroke words
+func runRender(e *env, args []string) error {
+	fs := e.flagSet("render")
+	f := files{}
+	f.registerFont(fs)
+	f.registerRules(fs)
+	dicts := listFlag{}
+	fs.Var(&dicts, "dict", "a plover json or rtf dictionary, can be given more than once from lowest to highest priority")
+	chords := fs.Bool("chords", false, "read the input as steno strokes seperated by spaces or slashes (e.g. KOE/KWOE)")
+	out := fs.String("o", "-", "the output file, - writes to stdout")
+	format := fs.String("format", "", "svg or png, by default the format is picked from the output file's extension")
+	opts := layout.DefaultOptions()
+	fs.Float64Var(&opts.GlyphSize, "size", opts.GlyphSize, "the width and height of a syllable in pixels")
+	fs.Float64Var(&opts.MaxWidth, "width", opts.MaxWidth, "the width of the page in pixels, 0 never wraps lines")
+	if ok, err := parse(fs, args); !ok {
+		return err
+	}
+
+	if *format == "" {
+		*format = "svg"
+		if strings.EqualFold(filepath.Ext(*out), ".png") {
+			*format = "png"
+		}
+	}
+	if *format != "svg" && *format != "png" {
+		return fmt.Errorf("%w: unknown format %q", ErrUsage, *format)
+	}
+	if !*chords && len(dicts) == 0 {
+		return fmt.Errorf("%w: text can only be rendered with a dictionary, use -dict or -chords", ErrUsage)
+	}
+
+	input := strings.Join(fs.Args(), " ")
+	if len(fs.Args()) == 0 {
+		raw, err := io.ReadAll(e.stdin)
+		if err != nil {
+			return err
+		}
+		input = string(raw)
+	}
+
+	fnt, err := f.loadFont()
+	if err != nil {
+		return err
+	}
+
+	loaded := []*dict.Dictionary{}
+	for _, path := range dicts {
+		d, err := dict.LoadFile(path)
+		if err != nil {
+			return err
+		}
+		loaded = append(loaded, d)
+	}
+	l := layout.New(fnt, dict.NewStore(loaded...), opts)
+
+	var page *layout.Page
+	if *chords {
+		strokes, err := steno.ParseKeysList(input)
+		if err != nil {
+			return err
+		}
+		if len(strokes) == 0 {
+			return errors.New("there are no strokes to render")
+		}
+		page = l.LayoutStrokes(strokes)
+	} else {
+		page = l.Layout(input)
+		if len(page.Missing) > 0 {
+			fmt.Fprintf(e.stderr, "missing from the dictionary: %s\n", strings.Join(page.Missing, ", "))
+		}
+	}
+
+	data, err := renderPage(page, *format)
+	if err != nil {
+		return err
+	}
+
+	return e.write(*out, data)
+}
+
+// renderPage writes the page as an svg document or a png image
+func renderPage(page *layout.Page, format string) ([]byte, error) {
+	if format == "svg" {
+		svg, err := page.SVG()
+		if err != nil {
+			return nil, err
+		}
+		return []byte(svg + "\n"), nil
+	}
+
+	outline, err := page.Outline()
+	if err != nil {
+		return nil, err
+	}
+
+	img := raster.Fill(outline, int(math.Ceil(page.Width)), int(math.Ceil(page.Height)))
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, img); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
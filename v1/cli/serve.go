@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/bjatkin/silabex/derive"
+	"github.com/bjatkin/silabex/dict"
+	"github.com/bjatkin/silabex/layout"
+	"github.com/bjatkin/silabex/preview"
+)
+
+// runServe starts the preview server, open pages reload when the template or rules are saved
+func runServe(e *env, args []string) error {
+	fs := e.flagSet("serve")
+	f := files{}
+	f.registerFont(fs)
+	f.registerRules(fs)
+	dicts := listFlag{}
+	fs.Var(&dicts, "dict", "a plover json or rtf dictionary, can be given more than once from lowest to highest priority")
+	addr := fs.String("addr", "localhost:8080", "the address to serve the preview on")
+	poll := fs.Duration("poll", preview.DefaultPoll, "how often the template and rules are checked for changes")
+	if ok, err := parse(fs, args); !ok {
+		return err
+	}
+
+	// the dialect is checked before the server starts so a typo is not reported on every page
+	if _, err := f.loadRules(); errors.Is(err, ErrUsage) {
+		return err
+	}
+
+	loaded := []*dict.Dictionary{}
+	for _, path := range dicts {
+		d, err := dict.LoadFile(path)
+		if err != nil {
+			return err
+		}
+		loaded = append(loaded, d)
+	}
+
+	s, err := preview.New(preview.Config{
+		Font:  f.font,
+		Rules: f.rules,
+		LoadRules: func(string) ([]derive.Rule, error) {
+			return f.loadRules()
+		},
+		Dicts:   loaded,
+		Options: layout.DefaultOptions(),
+		Poll:    *poll,
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Watch(ctx)
+
+	fmt.Fprintf(e.stderr, "previewing %s at http://%s\n", f.font, *addr)
+	return http.ListenAndServe(*addr, s.Handler())
+}
@@ -0,0 +1,45 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/bjatkin/silabex/font"
+	"github.com/bjatkin/silabex/metrics"
+)
+
+// runSkeleton writes an empty template for a new font with the groups, guides and Layout layer
+// from the metrics so a designer can open it and start drawing
+func runSkeleton(e *env, args []string) error {
+	fs := e.flagSet("skeleton")
+	out := fs.String("o", "-", "the output file, - writes to stdout")
+	from := fs.String("metrics", "", "a json metrics file or a template to read the metrics from, the reference metrics are used by default")
+	placeholders := fs.Bool("placeholders", false, "fill each stroke group with a block for every slot in its name")
+	force := fs.Bool("force", false, "overwrite the output file if it already exists")
+	if ok, err := parse(fs, args); !ok {
+		return err
+	}
+
+	m := metrics.Default()
+	if *from != "" {
+		var err error
+		m, err = metrics.LoadFile(*from)
+		if err != nil {
+			return err
+		}
+	}
+
+	// a skeleton written over a drawn template would lose every stroke
+	if *out != "" && *out != "-" && !*force {
+		if _, err := os.Stat(*out); err == nil {
+			return fmt.Errorf("%s already exists, use -force to overwrite it", *out)
+		}
+	}
+
+	template, err := font.Skeleton(m, *placeholders)
+	if err != nil {
+		return err
+	}
+
+	return e.write(*out, []byte(template))
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/bjatkin/silabex/derive"
+	"github.com/bjatkin/silabex/font"
+)
+
+// runValidate loads the template and the rules, lints the template's labels and audits every
+// character of the font. Every problem is printed and an error is returned if any were found
+func runValidate(e *env, args []string) error {
+	fs := e.flagSet("validate")
+	f := files{}
+	f.registerFont(fs)
+	f.registerRules(fs)
+	audit := fs.Bool("audit", true, "check every character for colliding strokes and strokes outside the em box")
+	if ok, err := parse(fs, args); !ok {
+		return err
+	}
+
+	// an empty -rules flag checks the template without any rules
+	problems := 0
+	var rules []derive.Rule
+	if f.rules != "" {
+		var err error
+		rules, err = f.loadRules()
+		if err != nil {
+			fmt.Fprintf(e.stdout, "rules: %v\n", err)
+			problems++
+		}
+	}
+
+	// the metrics are checked when the template is loaded
+	fnt, err := font.NewFont(f.font)
+	if err != nil {
+		fmt.Fprintf(e.stdout, "font: %v\n", err)
+		return fmt.Errorf("found %d problems", problems+1)
+	}
+
+	// the audit checks the strokes the rules build as well as the strokes in the template
+	fnt.Derive(rules)
+
+	labels, err := font.Lint(f.font)
+	if err != nil {
+		return err
+	}
+	for _, p := range labels {
+		fmt.Fprintln(e.stdout, p)
+	}
+	problems += len(labels)
+
+	if *audit {
+		issues, err := fnt.Audit()
+		if err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			fmt.Fprintln(e.stdout, issue)
+		}
+		problems += len(issues)
+	}
+
+	if problems > 0 {
+		return fmt.Errorf("found %d problems", problems)
+	}
+
+	return nil
+}
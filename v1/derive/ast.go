@@ -0,0 +1,46 @@
+package derive
+
+// File is a parsed derivation file
+type File struct {
+	Name  string
+	Rules []*RuleStmt
+}
+
+// RuleStmt is a single `target | expr expr ...` line in a derivation file
+type RuleStmt struct {
+	Line   int
+	Bank   Bank
+	Target []Part
+	Tags   []string
+	Exprs  []*Expr
+}
+
+type PartKind int
+
+const (
+	// LiteralPart is a plain name that is used as is
+	LiteralPart PartKind = iota
+	// CombinePart is a [] list, a rule is generated for every combination of the values
+	CombinePart
+	// AlternatePart is a () list, a rule is generated for each of the values
+	AlternatePart
+)
+
+// Part is a single piece of a rule target, parts are concatenated to build the target name
+type Part struct {
+	Kind   PartKind
+	Values []string
+}
+
+// Expr is a single stroke reference on the right hand side of a rule
+type Expr struct {
+	// Bank is only set if HasBank is true, otherwise the bank of the rule is used
+	Bank    Bank
+	HasBank bool
+	// Name is either a steno name or the index of a captured target part
+	Name    string
+	Tags    []string
+	Convert bool
+	// Transforms is the raw suffix after the '.'
+	Transforms string
+}
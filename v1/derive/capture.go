@@ -0,0 +1,487 @@
+package derive
+
+import (
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/bjatkin/silabex/stroke"
+)
+
+// The capture dialect (see reference/derive_2.dat) names strokes by their template slots instead of
+// their steno keys. Every name starts with a cluster letter, v for vowels, c for initial consonants,
+// s for solos and f for finals. Parts of the target in [] or () can be referenced on the right hand
+// side with <n> and a single value from a [] combination can be picked out with <n>#i where i starts
+// at 1. Terms that use a capture the target does not have or pick a value past the end of a combination
+// are dropped from the rule, rules with no terms left are dropped, and terms are joined using +
+//
+//	v[0, 1, 2, 3]                 | <0>#1 + <0>#2 + <0>#3 + <0>#4
+//	(c, s)[2, 3, 4, 5, 6, 7]:hang | <0><1>:stand.u
+
+var captureClusters = map[string]stroke.Cluster{
+	"v": stroke.Vowel,
+	"c": stroke.Initial,
+	"s": stroke.Solo,
+	"f": stroke.Final,
+}
+
+// clusterName matches a name that starts with a cluster letter and is optionally followed by slots
+var clusterName = regexp.MustCompile(`^([vcsf])([0-9]*)$`)
+
+// LoadCaptureRules reads, parses and expands the capture dialect derivation file at path
+func LoadCaptureRules(path string) ([]Rule, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseCaptureRules(path, raw)
+}
+
+// ParseCaptureRules parses and expands the contents of a capture dialect derivation file
+func ParseCaptureRules(fileName string, src []byte) ([]Rule, error) {
+	rules := []Rule{}
+	errors := []*lineError{}
+
+	for i, line := range strings.Split(string(src), "\n") {
+		tokens, err := lexCaptureLine(i+1, line)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		if len(tokens) == 0 {
+			continue
+		}
+
+		p := &parser{lineNumber: i + 1, tokens: tokens}
+		stmt, err := p.parseCaptureRule()
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		expanded, err := stmt.expand()
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		rules = append(rules, expanded...)
+	}
+
+	if len(errors) > 0 {
+		return nil, newBuilderError(fileName, errors)
+	}
+
+	return rules, nil
+}
+
+// lexCaptureLine splits a single line of a capture dialect file into tokens, // comments are dropped
+func lexCaptureLine(lineNumber int, line string) ([]token, *lineError) {
+	tokens := []token{}
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			return tokens, nil
+		case unicode.IsSpace(r):
+			continue
+		case unicode.IsDigit(r):
+			start := i
+			for i+1 < len(runes) && unicode.IsDigit(runes[i+1]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokNumber, value: string(runes[start : i+1]), col: start + 1})
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i+1 < len(runes) && (unicode.IsLetter(runes[i+1]) || unicode.IsDigit(runes[i+1]) || runes[i+1] == '_') {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, value: string(runes[start : i+1]), col: start + 1})
+		default:
+			kind, ok := map[rune]tokenKind{
+				'|': tokPipe,
+				':': tokColon,
+				'.': tokDot,
+				',': tokComma,
+				'[': tokLBracket,
+				']': tokRBracket,
+				'(': tokLParen,
+				')': tokRParen,
+				'<': tokLAngle,
+				'>': tokRAngle,
+				'#': tokHash,
+				'+': tokPlus,
+			}[r]
+			if !ok {
+				return nil, newLineError(lineNumber, "unexpected character '%s' at column %d", string(r), i+1)
+			}
+			tokens = append(tokens, token{kind: kind, value: string(r), col: i + 1})
+		}
+	}
+
+	return tokens, nil
+}
+
+// captureStmt is a single line of a capture dialect file
+type captureStmt struct {
+	line   int
+	target []Part
+	tags   []string
+	terms  []*captureTerm
+}
+
+// captureTerm is a single + seperated term on the right hand side of a capture rule
+type captureTerm struct {
+	pieces     []capturePiece
+	tags       []string
+	transforms string
+}
+
+// capturePiece is either literal text or a reference to a captured target part
+type capturePiece struct {
+	text    string
+	capture int
+	// index is the 1 based value to pick from the capture, 0 uses the whole capture
+	index int
+}
+
+func (p *parser) parseCaptureRule() (*captureStmt, *lineError) {
+	stmt := &captureStmt{line: p.lineNumber}
+
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokColon || tok.kind == tokPipe {
+			break
+		}
+
+		part, err := p.parseCapturePart()
+		if err != nil {
+			return nil, err
+		}
+		stmt.target = append(stmt.target, part)
+	}
+
+	if len(stmt.target) == 0 {
+		return nil, newLineError(p.lineNumber, "missing a name for the rule target")
+	}
+
+	tags, err := p.parseTags()
+	if err != nil {
+		return nil, err
+	}
+	stmt.tags = tags
+
+	if _, err := p.expect(tokPipe); err != nil {
+		return nil, err
+	}
+
+	for {
+		term, err := p.parseCaptureTerm()
+		if err != nil {
+			return nil, err
+		}
+		stmt.terms = append(stmt.terms, term)
+
+		if _, ok := p.accept(tokPlus); !ok {
+			break
+		}
+	}
+
+	if tok, ok := p.peek(); ok {
+		return nil, newLineError(p.lineNumber, "unexpected %s at column %d, terms must be joined with '+'", tok, tok.col)
+	}
+
+	return stmt, nil
+}
+
+func (p *parser) parseCapturePart() (Part, *lineError) {
+	if tok, ok := p.accept(tokIdent); ok {
+		return Part{Kind: LiteralPart, Values: []string{tok.value}}, nil
+	}
+
+	if tok, ok := p.accept(tokNumber); ok {
+		return Part{Kind: LiteralPart, Values: []string{tok.value}}, nil
+	}
+
+	if _, ok := p.accept(tokLBracket); ok {
+		values, err := p.parseCaptureList(tokRBracket)
+		return Part{Kind: CombinePart, Values: values}, err
+	}
+
+	if _, ok := p.accept(tokLParen); ok {
+		values, err := p.parseCaptureList(tokRParen)
+		return Part{Kind: AlternatePart, Values: values}, err
+	}
+
+	tok, _ := p.peek()
+	return Part{}, newLineError(p.lineNumber, "unexpected %s at column %d in rule target", tok, tok.col)
+}
+
+// parseCaptureList parses a comma seperated list of names and numbers up to the closing token
+func (p *parser) parseCaptureList(end tokenKind) ([]string, *lineError) {
+	values := []string{}
+	for {
+		tok, ok := p.accept(tokIdent)
+		if !ok {
+			var err *lineError
+			tok, err = p.expect(tokNumber)
+			if err != nil {
+				return nil, err
+			}
+		}
+		values = append(values, tok.value)
+
+		if _, ok := p.accept(end); ok {
+			return values, nil
+		}
+
+		if _, err := p.expect(tokComma); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// parseCaptureTerm parses `piece...[:tag...][.transforms]`
+func (p *parser) parseCaptureTerm() (*captureTerm, *lineError) {
+	term := &captureTerm{}
+
+	for {
+		if tok, ok := p.accept(tokIdent); ok {
+			term.pieces = append(term.pieces, capturePiece{text: tok.value, capture: -1})
+			continue
+		}
+
+		if tok, ok := p.accept(tokNumber); ok {
+			term.pieces = append(term.pieces, capturePiece{text: tok.value, capture: -1})
+			continue
+		}
+
+		if _, ok := p.accept(tokLAngle); ok {
+			piece, err := p.parseCaptureRef()
+			if err != nil {
+				return nil, err
+			}
+			term.pieces = append(term.pieces, piece)
+			continue
+		}
+
+		break
+	}
+
+	if len(term.pieces) == 0 {
+		tok, ok := p.peek()
+		if !ok {
+			return nil, newLineError(p.lineNumber, "expected a stroke name but found the end of the line")
+		}
+		return nil, newLineError(p.lineNumber, "expected a stroke name but found %s at column %d", tok, tok.col)
+	}
+
+	tags, err := p.parseTags()
+	if err != nil {
+		return nil, err
+	}
+	term.tags = tags
+
+	if _, ok := p.accept(tokDot); ok {
+		tok, err := p.expect(tokIdent)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := parseTransforms(tok.value); err != nil {
+			return nil, newLineError(p.lineNumber, "%s at column %d", err, tok.col)
+		}
+		term.transforms = tok.value
+	}
+
+	return term, nil
+}
+
+// parseCaptureRef parses `n>[#i]`, the opening < has already been consumed
+func (p *parser) parseCaptureRef() (capturePiece, *lineError) {
+	tok, err := p.expect(tokNumber)
+	if err != nil {
+		return capturePiece{}, err
+	}
+	capture, _ := strconv.Atoi(tok.value)
+
+	if _, err := p.expect(tokRAngle); err != nil {
+		return capturePiece{}, err
+	}
+
+	piece := capturePiece{capture: capture}
+	if _, ok := p.accept(tokHash); ok {
+		tok, err := p.expect(tokNumber)
+		if err != nil {
+			return capturePiece{}, err
+		}
+
+		piece.index, _ = strconv.Atoi(tok.value)
+		if piece.index == 0 {
+			return capturePiece{}, newLineError(p.lineNumber, "capture indexes start at 1 but found #0 at column %d", tok.col)
+		}
+	}
+
+	return piece, nil
+}
+
+func (s *captureStmt) expand() ([]Rule, *lineError) {
+	_, segments := splitTags(s.tags)
+
+	rules := []Rule{}
+	for _, captures := range expandParts(s.target) {
+		cluster, name, ok := splitClusterName(captures.values)
+		if !ok {
+			return nil, newLineError(s.line, "rule target '%s' must start with one of the clusters v, c, s or f", strings.Join(captures.values, ""))
+		}
+
+		targetSegments := segments
+		if cluster == stroke.Vowel {
+			targetSegments = []stroke.Segment{stroke.Border}
+		}
+
+		for _, segment := range targetSegments {
+			target := Ref{
+				Cluster: cluster,
+				Name:    name,
+				Segment: segmentFor(bankOf(cluster), name, segment),
+			}
+
+			terms := []Term{}
+			for _, t := range s.terms {
+				term, ok, err := t.term(s.line, target, captures.captured)
+				if err != nil {
+					return nil, err
+				}
+
+				if ok {
+					terms = append(terms, term)
+				}
+			}
+			if len(terms) == 0 {
+				continue
+			}
+
+			rules = append(rules, Rule{
+				Line:   s.line,
+				Target: target,
+				Terms:  terms,
+			})
+		}
+	}
+
+	return rules, nil
+}
+
+// term resolves the captures used by the term. false is returned if the term uses a capture the
+// target does not have or picks a value past the end of a combination and should be left out of the rule
+func (t *captureTerm) term(line int, target Ref, captured [][]string) (Term, bool, *lineError) {
+	values := []string{}
+	for _, piece := range t.pieces {
+		if piece.capture < 0 {
+			values = append(values, piece.text)
+			continue
+		}
+
+		if piece.capture >= len(captured) {
+			return Term{}, false, nil
+		}
+
+		capture := captured[piece.capture]
+		if piece.index == 0 {
+			values = append(values, strings.Join(capture, ""))
+			continue
+		}
+
+		if piece.index > len(capture) {
+			return Term{}, false, nil
+		}
+		values = append(values, capture[piece.index-1])
+	}
+
+	cluster, name, ok := splitClusterName(values)
+	if !ok {
+		// terms without a cluster use the cluster of the target
+		cluster, name = target.Cluster, canonicalSlots(strings.Join(values, ""))
+	}
+
+	segment := target.Segment
+	for _, tag := range t.tags {
+		if s, ok := tagSegments[tag]; ok {
+			segment = s
+		}
+	}
+
+	if cluster == stroke.Vowel {
+		segment = stroke.Border
+	}
+
+	transforms, err := parseTransforms(t.transforms)
+	if err != nil {
+		return Term{}, false, newLineError(line, "%s", err)
+	}
+
+	return Term{
+		Ref: Ref{
+			Cluster: cluster,
+			Name:    name,
+			Segment: segmentFor(bankOf(cluster), name, segment),
+		},
+		Transforms: transforms,
+	}, true, nil
+}
+
+// splitClusterName splits the leading cluster letter off of a name, the cluster letter can either be
+// its own value (e.g. "c", "23") or the start of the first value (e.g. "v2")
+func splitClusterName(values []string) (stroke.Cluster, string, bool) {
+	if len(values) == 0 {
+		return 0, "", false
+	}
+
+	match := clusterName.FindStringSubmatch(values[0])
+	if match == nil {
+		return 0, "", false
+	}
+
+	name := match[2] + strings.Join(values[1:], "")
+	return captureClusters[match[1]], canonicalSlots(name), true
+}
+
+// canonicalSlots sorts slot names into template order (e.g. "32" becomes "23"), named
+// fragments like frag_35 are left unchanged
+func canonicalSlots(name string) string {
+	for _, r := range name {
+		if !unicode.IsDigit(r) {
+			return name
+		}
+	}
+
+	slots := []rune{}
+	for _, r := range name {
+		if !slices.Contains(slots, r) {
+			slots = append(slots, r)
+		}
+	}
+	slices.Sort(slots)
+
+	return string(slots)
+}
+
+// bankOf returns the steno bank that strokes in cluster are written in
+func bankOf(cluster stroke.Cluster) Bank {
+	switch cluster {
+	case stroke.Vowel:
+		return VowelBank
+	case stroke.Final:
+		return FinalBank
+	default:
+		return InitialBank
+	}
+}
@@ -0,0 +1,97 @@
+package derive
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCaptureRules(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			"simple vowel",
+			"v2 | 1.u // comment",
+			[]string{"V.2 | V.1.u"},
+		},
+		{
+			"indexed captures",
+			"v[0, 1] | <0>#1 + <0>#2",
+			[]string{
+				"V.0 | V.0",
+				"V.1 | V.1",
+				"V.01 | V.0 V.1",
+			},
+		},
+		{
+			"cluster prefix with fragment",
+			"(c, s)35:tall | <0>3 + <0>frag_35",
+			[]string{
+				"I.35:tall | I.3:tall I.frag_35:tall",
+				"S.35:tall | S.3:tall S.frag_35:tall",
+			},
+		},
+		{
+			"head and foot segments",
+			"(c)8:stand | <0>0:hang.d",
+			[]string{"I.8:foot | I.0:head.d"},
+		},
+		{
+			"missing capture",
+			"(c)35:tall | <1>3 + <0>frag_35\n(c)2:tall | <1>3.x",
+			[]string{"I.35:tall | I.frag_35:tall"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ParseCaptureRules("test.dat", []byte(tt.src))
+			if err != nil {
+				t.Fatalf("ParseCaptureRules() error = %v", err)
+			}
+
+			got := []string{}
+			for _, rule := range rules {
+				got = append(got, rule.String())
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseCaptureRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseCaptureRules_errors(t *testing.T) {
+	src := "// header\n" +
+		"q2 | 1\n" +
+		"v2 | <0#1>\n" +
+		"v3 | 0.r\n"
+
+	want := "test.dat:2 rule target 'q2' must start with one of the clusters v, c, s or f\n" +
+		"test.dat:3 expected '>' but found '#' at column 8"
+
+	_, err := ParseCaptureRules("test.dat", []byte(src))
+	if err == nil || err.Error() != want {
+		t.Errorf("ParseCaptureRules() error = \n%v\nwant\n%v", err, want)
+	}
+}
+
+func TestLoadCaptureRules(t *testing.T) {
+	rules, err := LoadCaptureRules("../reference/derive_2.dat")
+	if err != nil {
+		t.Fatalf("LoadCaptureRules() error = %v", err)
+	}
+
+	// the consonant rules on lines 13 and 14 use <1> but their targets only have one capture, so
+	// they have no terms left and are dropped
+	for _, rule := range rules {
+		if rule.Line == 13 || rule.Line == 14 {
+			t.Errorf("LoadCaptureRules() = %q, want no rules from line %d", rule, rule.Line)
+		}
+	}
+	if len(rules) == 0 {
+		t.Errorf("LoadCaptureRules() = no rules, want the vowel and hang rules")
+	}
+}
@@ -108,6 +108,27 @@ func NewCharBuilder() *CharBuilder {
 	}
 }
 
+// NewRuleCharBuilder creates a CharBuilder that derives strokes from parsed derivation rules
+// instead of the built in builders
+func NewRuleCharBuilder(rules []Rule) *CharBuilder {
+	return &CharBuilder{
+		builders: []builder{
+			newRuleBuilder(rules),
+		},
+	}
+}
+
+// Build runs all the builders in order and returns the base strokes along with every derived stroke.
+// Each builder can use the strokes derived by the builders that ran before it
+func (c *CharBuilder) Build(base stroke.StrokeSlice) stroke.StrokeSlice {
+	ret := append(stroke.StrokeSlice{}, base...)
+	for _, b := range c.builders {
+		ret = append(ret, b(ret)...)
+	}
+
+	return ret
+}
+
 type filter func(*stroke.Stroke) bool
 
 func filterInCluster(cluster ...stroke.Cluster) filter {
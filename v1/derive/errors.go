@@ -0,0 +1,48 @@
+package derive
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+type builderError struct {
+	fileName  string
+	errors    []*lineError
+	maxErrors int
+}
+
+func newBuilderError(fileName string, errors []*lineError) *builderError {
+	return &builderError{
+		fileName:  fileName,
+		errors:    errors,
+		maxErrors: 10,
+	}
+}
+
+func (b *builderError) Error() string {
+	dir, _ := os.Getwd()
+	fileName := strings.Replace(b.fileName, dir, ".", 1)
+	errors := []string{}
+	for i, e := range b.errors {
+		if i >= b.maxErrors {
+			errors = append(errors, fmt.Sprintf("max errors reached, there were %d more errors", len(b.errors)-i))
+			break
+		}
+		errors = append(errors, fmt.Sprintf("%s:%d %s", fileName, e.lineNumber, e.message))
+	}
+
+	return strings.Join(errors, "\n")
+}
+
+type lineError struct {
+	lineNumber int
+	message    string
+}
+
+func newLineError(lineNumber int, format string, args ...any) *lineError {
+	return &lineError{
+		lineNumber: lineNumber,
+		message:    fmt.Sprintf(format, args...),
+	}
+}
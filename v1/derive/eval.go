@@ -0,0 +1,117 @@
+package derive
+
+import (
+	"github.com/bjatkin/silabex/stroke"
+)
+
+// Derivation is a stroke that was built by a rule
+type Derivation struct {
+	Rule   Rule
+	Stroke *stroke.Stroke
+}
+
+// Eval runs the rules in order against the base strokes and returns every stroke that was derived.
+// A rule is skipped if its target already exists, either in the base strokes or because an earlier
+// rule built it, or if any of the strokes it references can not be found
+func Eval(rules []Rule, base stroke.StrokeSlice) []Derivation {
+	index := map[Ref]*stroke.Stroke{}
+	for _, s := range base {
+		ref := Ref{Cluster: s.Cluster(), Name: s.Name(), Segment: s.Segment()}
+		if _, ok := index[ref]; !ok {
+			index[ref] = s
+		}
+	}
+
+	derived := []Derivation{}
+	for _, rule := range rules {
+		if _, ok := index[rule.Target]; ok {
+			continue
+		}
+
+		built := build(rule, index)
+		if built == nil {
+			continue
+		}
+
+		index[rule.Target] = built
+		derived = append(derived, Derivation{
+			Rule:   rule,
+			Stroke: built,
+		})
+	}
+
+	return derived
+}
+
+// Plan returns the rule that derives each stroke when the rules are run against the base strokes,
+// without building any of them. The same rules are picked as Eval would pick
+func Plan(rules []Rule, base []Ref) map[Ref]Rule {
+	have := map[Ref]bool{}
+	for _, ref := range base {
+		have[ref] = true
+	}
+
+	ret := map[Ref]Rule{}
+	for _, rule := range rules {
+		if have[rule.Target] {
+			continue
+		}
+
+		found := true
+		for _, term := range rule.Terms {
+			found = found && have[term.Ref]
+		}
+		if !found || len(rule.Terms) == 0 {
+			continue
+		}
+
+		have[rule.Target] = true
+		ret[rule.Target] = rule
+	}
+
+	return ret
+}
+
+// build joins all the terms of the rule into a single stroke, nil is returned if
+// any of the terms are missing from the index
+func build(rule Rule, index map[Ref]*stroke.Stroke) *stroke.Stroke {
+	var ret *stroke.Stroke
+	for _, term := range rule.Terms {
+		found, ok := index[term.Ref]
+		if !ok {
+			return nil
+		}
+
+		s := found.Copy()
+		for _, transform := range term.Transforms {
+			transform.apply(s)
+		}
+
+		if ret == nil {
+			ret = s
+			continue
+		}
+
+		ret = stroke.Join(rule.Target.Name, ret, s)
+	}
+
+	if ret == nil {
+		return nil
+	}
+
+	return ret.SetName(rule.Target.Name).
+		SetCluster(rule.Target.Cluster).
+		SetSegment(rule.Target.Segment)
+}
+
+// newRuleBuilder creates a builder that derives strokes using the provided rules
+func newRuleBuilder(rules []Rule) builder {
+	return func(s stroke.StrokeSlice) stroke.StrokeSlice {
+		ret := stroke.StrokeSlice{}
+		for _, d := range Eval(rules, s) {
+			ret = append(ret, d.Stroke)
+		}
+
+		return ret
+	}
+}
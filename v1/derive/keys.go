@@ -0,0 +1,167 @@
+package derive
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/bjatkin/silabex/stroke"
+)
+
+// Bank is the section of the steno keyboard a stroke name is written in
+type Bank int
+
+const (
+	VowelBank Bank = iota
+	InitialBank
+	FinalBank
+)
+
+func (b Bank) String() string {
+	switch b {
+	case VowelBank:
+		return "@"
+	case InitialBank:
+		return "^"
+	case FinalBank:
+		return "$"
+	default:
+		return "?"
+	}
+}
+
+// keys maps each steno key in the bank to the template slots it is drawn in.
+// The slots follow the layout in reference/ref.md, S and * span both head and foot slots
+// and the final bank is the initial bank mirrored along the x axis, which is why the
+// % conversion can keep the slot numbers unchanged
+func (b Bank) keys() map[rune]string {
+	switch b {
+	case VowelBank:
+		return map[rune]string{'A': "0", 'O': "1", 'E': "2", 'U': "3"}
+	case InitialBank:
+		return map[rune]string{
+			'S': "01", 'T': "2", 'K': "3", 'P': "4",
+			'W': "5", 'H': "6", 'R': "7", '*': "89",
+		}
+	case FinalBank:
+		return map[rune]string{
+			'R': "0", 'F': "1", 'B': "2", 'P': "3", 'G': "4",
+			'L': "5", 'S': "6", 'T': "7", 'Z': "8", 'D': "9",
+		}
+	default:
+		return map[rune]string{}
+	}
+}
+
+// convert returns the bank that % converts b into
+func (b Bank) convert() (Bank, bool) {
+	switch b {
+	case InitialBank:
+		return FinalBank, true
+	case FinalBank:
+		return InitialBank, true
+	default:
+		return b, false
+	}
+}
+
+// cluster returns the stroke cluster that strokes from b are drawn in. target is the cluster of
+// the stroke being derived so initial consonants used by a solo stroke are also taken from solos
+func (b Bank) cluster(target stroke.Cluster) stroke.Cluster {
+	switch b {
+	case VowelBank:
+		return stroke.Vowel
+	case FinalBank:
+		return stroke.Final
+	default:
+		if target == stroke.Solo {
+			return stroke.Solo
+		}
+		return stroke.Initial
+	}
+}
+
+// slots converts a steno name written in the bank (e.g. "TK") into the template slot name (e.g. "23")
+func (b Bank) slots(name string) (string, error) {
+	keys := b.keys()
+
+	slots := []rune{}
+	for _, r := range name {
+		slot, ok := keys[r]
+		if !ok {
+			return "", fmt.Errorf("unknown key '%s' in %s name '%s'", string(r), b, name)
+		}
+
+		for _, s := range slot {
+			if !slices.Contains(slots, s) {
+				slots = append(slots, s)
+			}
+		}
+	}
+
+	slices.Sort(slots)
+	return string(slots), nil
+}
+
+// Name converts a template slot name (e.g. "23") back into steno keys (e.g. "TK") for the bank
+func (b Bank) Name(slots string) string {
+	type key struct {
+		name  rune
+		slots string
+	}
+
+	keys := []key{}
+	for name, s := range b.keys() {
+		keys = append(keys, key{name: name, slots: s})
+	}
+	slices.SortFunc(keys, func(a, b key) int {
+		return strings.Compare(a.slots, b.slots)
+	})
+
+	name := ""
+	for _, k := range keys {
+		if containsAll(slots, k.slots) {
+			name += string(k.name)
+		}
+	}
+
+	return name
+}
+
+// segmentFor places head and foot only slots into their own segments, the head and foot strokes
+// are not drawn in the tall, stand, core and hang segments
+func segmentFor(bank Bank, slots string, segment stroke.Segment) stroke.Segment {
+	if bank == VowelBank || slots == "" {
+		return segment
+	}
+
+	if containsOnly(slots, "01") {
+		return stroke.Head
+	}
+
+	if containsOnly(slots, "89") {
+		return stroke.Foot
+	}
+
+	return segment
+}
+
+func containsAll(s, chars string) bool {
+	for _, r := range chars {
+		if !strings.ContainsRune(s, r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsOnly(s, chars string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune(chars, r) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,140 @@
+package derive
+
+import (
+	"fmt"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokIdent tokenKind = iota
+	tokVowel
+	tokInitial
+	tokFinal
+	tokPipe
+	tokColon
+	tokDot
+	tokPercent
+	tokComma
+	tokLBracket
+	tokRBracket
+	tokLParen
+	tokRParen
+	tokNumber
+	tokLAngle
+	tokRAngle
+	tokHash
+	tokPlus
+)
+
+func (k tokenKind) String() string {
+	switch k {
+	case tokIdent:
+		return "name"
+	case tokVowel:
+		return "'@'"
+	case tokInitial:
+		return "'^'"
+	case tokFinal:
+		return "'$'"
+	case tokPipe:
+		return "'|'"
+	case tokColon:
+		return "':'"
+	case tokDot:
+		return "'.'"
+	case tokPercent:
+		return "'%'"
+	case tokComma:
+		return "','"
+	case tokLBracket:
+		return "'['"
+	case tokRBracket:
+		return "']'"
+	case tokLParen:
+		return "'('"
+	case tokRParen:
+		return "')'"
+	case tokNumber:
+		return "number"
+	case tokLAngle:
+		return "'<'"
+	case tokRAngle:
+		return "'>'"
+	case tokHash:
+		return "'#'"
+	case tokPlus:
+		return "'+'"
+	default:
+		return "unknown token"
+	}
+}
+
+type token struct {
+	kind  tokenKind
+	value string
+	col   int
+}
+
+var punctuation = map[rune]tokenKind{
+	'@': tokVowel,
+	'^': tokInitial,
+	'$': tokFinal,
+	'|': tokPipe,
+	':': tokColon,
+	'.': tokDot,
+	'%': tokPercent,
+	',': tokComma,
+	'[': tokLBracket,
+	']': tokRBracket,
+	'(': tokLParen,
+	')': tokRParen,
+}
+
+// isNameRune reports whether r can be part of an identifier, the * key is allowed so
+// clusters like S* can be written without quoting
+func isNameRune(r rune) bool {
+	return r == '*' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// lexLine splits a single line of a derivation file into tokens, comments are dropped
+func lexLine(lineNumber int, line string) ([]token, *lineError) {
+	tokens := []token{}
+
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == '#':
+			return tokens, nil
+		case unicode.IsSpace(r):
+			continue
+		case isNameRune(r):
+			start := i
+			for i+1 < len(runes) && isNameRune(runes[i+1]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokIdent, value: string(runes[start : i+1]), col: start + 1})
+		default:
+			kind, ok := punctuation[r]
+			if !ok {
+				return nil, newLineError(lineNumber, "unexpected character '%s' at column %d", string(r), i+1)
+			}
+			tokens = append(tokens, token{kind: kind, value: string(r), col: i + 1})
+		}
+	}
+
+	return tokens, nil
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokIdent:
+		return fmt.Sprintf("name '%s'", t.value)
+	case tokNumber:
+		return fmt.Sprintf("number '%s'", t.value)
+	}
+
+	return t.kind.String()
+}
@@ -0,0 +1,268 @@
+package derive
+
+import (
+	"os"
+	"strings"
+)
+
+// ParseRules parses the contents of a derivation file (see reference/derive.dat)
+func ParseRules(fileName string, src []byte) (*File, error) {
+	file := &File{Name: fileName}
+	errors := []*lineError{}
+
+	for i, line := range strings.Split(string(src), "\n") {
+		tokens, err := lexLine(i+1, line)
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		if len(tokens) == 0 {
+			continue
+		}
+
+		p := &parser{lineNumber: i + 1, tokens: tokens}
+		rule, err := p.parseRule()
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		file.Rules = append(file.Rules, rule)
+	}
+
+	if len(errors) > 0 {
+		return nil, newBuilderError(fileName, errors)
+	}
+
+	return file, nil
+}
+
+// LoadRules reads, parses and expands the derivation file at path
+func LoadRules(path string) ([]Rule, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := ParseRules(path, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return file.Expand()
+}
+
+type parser struct {
+	lineNumber int
+	tokens     []token
+	pos        int
+}
+
+func (p *parser) peek() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+
+	return p.tokens[p.pos], true
+}
+
+func (p *parser) accept(kind tokenKind) (token, bool) {
+	tok, ok := p.peek()
+	if !ok || tok.kind != kind {
+		return token{}, false
+	}
+
+	p.pos++
+	return tok, true
+}
+
+func (p *parser) expect(kind tokenKind) (token, *lineError) {
+	tok, ok := p.peek()
+	if !ok {
+		return token{}, newLineError(p.lineNumber, "expected %s but found the end of the line", kind)
+	}
+
+	if tok.kind != kind {
+		return token{}, newLineError(p.lineNumber, "expected %s but found %s at column %d", kind, tok, tok.col)
+	}
+
+	p.pos++
+	return tok, nil
+}
+
+// parseRule parses `bank target[:tag...] | expr...`
+func (p *parser) parseRule() (*RuleStmt, *lineError) {
+	rule := &RuleStmt{Line: p.lineNumber}
+
+	bank, ok := p.parseBank()
+	if !ok {
+		tok, _ := p.peek()
+		return nil, newLineError(p.lineNumber, "rules must start with @, ^ or $ but found %s", tok)
+	}
+	rule.Bank = bank
+
+	for {
+		tok, ok := p.peek()
+		if !ok || tok.kind == tokColon || tok.kind == tokPipe {
+			break
+		}
+
+		part, err := p.parsePart()
+		if err != nil {
+			return nil, err
+		}
+		rule.Target = append(rule.Target, part)
+	}
+
+	if len(rule.Target) == 0 {
+		return nil, newLineError(p.lineNumber, "missing a name for the rule target")
+	}
+
+	tags, err := p.parseTags()
+	if err != nil {
+		return nil, err
+	}
+	rule.Tags = tags
+
+	if _, err := p.expect(tokPipe); err != nil {
+		return nil, err
+	}
+
+	for {
+		if _, ok := p.peek(); !ok {
+			break
+		}
+
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		rule.Exprs = append(rule.Exprs, expr)
+	}
+
+	if len(rule.Exprs) == 0 {
+		return nil, newLineError(p.lineNumber, "rule has no derivation after the | seperator")
+	}
+
+	return rule, nil
+}
+
+func (p *parser) parseBank() (Bank, bool) {
+	if _, ok := p.accept(tokVowel); ok {
+		return VowelBank, true
+	}
+	if _, ok := p.accept(tokInitial); ok {
+		return InitialBank, true
+	}
+	if _, ok := p.accept(tokFinal); ok {
+		return FinalBank, true
+	}
+
+	return 0, false
+}
+
+func (p *parser) parsePart() (Part, *lineError) {
+	if tok, ok := p.accept(tokIdent); ok {
+		return Part{Kind: LiteralPart, Values: []string{tok.value}}, nil
+	}
+
+	if _, ok := p.accept(tokLBracket); ok {
+		values, err := p.parseList(tokRBracket)
+		return Part{Kind: CombinePart, Values: values}, err
+	}
+
+	if _, ok := p.accept(tokLParen); ok {
+		values, err := p.parseList(tokRParen)
+		return Part{Kind: AlternatePart, Values: values}, err
+	}
+
+	tok, _ := p.peek()
+	return Part{}, newLineError(p.lineNumber, "unexpected %s at column %d in rule target", tok, tok.col)
+}
+
+// parseList parses a comma seperated list of names up to the closing token
+func (p *parser) parseList(end tokenKind) ([]string, *lineError) {
+	values := []string{}
+	for {
+		tok, err := p.expect(tokIdent)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, tok.value)
+
+		if _, ok := p.accept(end); ok {
+			return values, nil
+		}
+
+		if _, err := p.expect(tokComma); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseTags() ([]string, *lineError) {
+	tags := []string{}
+	for {
+		if _, ok := p.accept(tokColon); !ok {
+			return tags, nil
+		}
+
+		tok, err := p.expect(tokIdent)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, ok := tagSegments[tok.value]; !ok {
+			if _, ok := tagClusters[tok.value]; !ok && tok.value != "all" {
+				return nil, newLineError(p.lineNumber, "unknown tag ':%s' at column %d", tok.value, tok.col)
+			}
+		}
+		tags = append(tags, tok.value)
+	}
+}
+
+// parseExpr parses `[bank]name[:tag...][%][.transforms]`
+func (p *parser) parseExpr() (*Expr, *lineError) {
+	expr := &Expr{}
+	expr.Bank, expr.HasBank = p.parseBank()
+
+	tok, err := p.expect(tokIdent)
+	if err != nil {
+		return nil, err
+	}
+	expr.Name = tok.value
+
+	for {
+		if _, ok := p.accept(tokPercent); ok {
+			expr.Convert = true
+			continue
+		}
+
+		tok, ok := p.peek()
+		if ok && tok.kind == tokColon {
+			tags, err := p.parseTags()
+			if err != nil {
+				return nil, err
+			}
+			expr.Tags = append(expr.Tags, tags...)
+			continue
+		}
+
+		break
+	}
+
+	if _, ok := p.accept(tokDot); ok {
+		tok, err := p.expect(tokIdent)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := parseTransforms(tok.value); err != nil {
+			return nil, newLineError(p.lineNumber, "%s for '%s'", err, expr.Name)
+		}
+		expr.Transforms = tok.value
+	}
+
+	return expr, nil
+}
@@ -0,0 +1,180 @@
+package derive
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bjatkin/silabex/stroke"
+)
+
+func TestFile_Expand(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string
+	}{
+		{
+			"vowel transform",
+			"@O | E.d",
+			[]string{"V.1 | V.2.d"},
+		},
+		{
+			"combination with capture",
+			"^S[T,K]:tall:initial | S:hang 0:stand",
+			[]string{
+				"I.012:tall | I.01:head I.2:stand",
+				"I.013:tall | I.01:head I.3:stand",
+				"I.0123:tall | I.01:head I.23:stand",
+			},
+		},
+		{
+			"alternation with conversion",
+			"$(B, P):tall | 0%.r # comment",
+			[]string{
+				"F.2:tall | I.2:tall.r",
+				"F.3:tall | I.3:tall.r",
+			},
+		},
+		{
+			"explicit bank",
+			"$B:core | ^T.xr",
+			[]string{"F.2:core | I.2:core.xr"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			file, err := ParseRules("test.dat", []byte(tt.src))
+			if err != nil {
+				t.Fatalf("ParseRules() error = %v", err)
+			}
+
+			rules, err := file.Expand()
+			if err != nil {
+				t.Fatalf("Expand() error = %v", err)
+			}
+
+			got := []string{}
+			for _, rule := range rules {
+				got = append(got, rule.String())
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRules_errors(t *testing.T) {
+	// only the first 10 errors are listed
+	tooMany := ""
+	for i := 1; i <= 10; i++ {
+		tooMany += fmt.Sprintf("test.dat:%d rules must start with @, ^ or $ but found name 'K'\n", i)
+	}
+	tooMany += "max errors reached, there were 2 more errors"
+
+	tests := []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			"missing bank",
+			"K | T.x",
+			"test.dat:1 rules must start with @, ^ or $ but found name 'K'",
+		},
+		{
+			"missing seperator",
+			"\n^K",
+			"test.dat:2 expected '|' but found the end of the line",
+		},
+		{
+			"unknown transform",
+			"^K | T.q",
+			"test.dat:1 unknown transform 'q' for 'T'",
+		},
+		{
+			"unclosed list",
+			"^S[K, T | T",
+			"test.dat:1 expected ',' but found '|' at column 9",
+		},
+		{
+			"too many errors",
+			strings.Repeat("K | T.x\n", 12),
+			tooMany,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRules("test.dat", []byte(tt.src))
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("ParseRules() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestEval(t *testing.T) {
+	rules, err := LoadRules("../reference/derive.dat")
+	if err != nil {
+		t.Fatal("failed to load rules", err)
+	}
+
+	base := stroke.NewSlice(
+		stroke.New("0", stroke.Vowel, stroke.Border),
+		stroke.New("2", stroke.Vowel, stroke.Border),
+		stroke.New("2", stroke.Initial, stroke.Tall),
+		// explicit strokes must not be replaced by derived ones and
+		// V.012 can not be derived because V.01 is missing
+		stroke.New("3", stroke.Initial, stroke.Tall),
+	)
+
+	got := map[string]bool{}
+	for _, d := range Eval(rules, base) {
+		got[d.Rule.Target.String()] = true
+	}
+
+	for _, want := range []string{"V.1", "V.3", "V.02", "V.0123", "I.7:tall", "F.2:tall"} {
+		if !got[want] {
+			t.Errorf("Eval() did not derive %s", want)
+		}
+	}
+
+	for _, notWant := range []string{"I.3:tall", "S.3:tall", "V.012"} {
+		if got[notWant] {
+			t.Errorf("Eval() derived %s", notWant)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	rules, err := LoadRules("../reference/derive.dat")
+	if err != nil {
+		t.Fatal("failed to load rules", err)
+	}
+
+	base := []Ref{
+		{Cluster: stroke.Vowel, Name: "0"},
+		{Cluster: stroke.Vowel, Name: "2"},
+		{Cluster: stroke.Initial, Name: "2", Segment: stroke.Tall},
+		{Cluster: stroke.Initial, Name: "3", Segment: stroke.Tall},
+	}
+	strokes := stroke.StrokeSlice{}
+	for _, ref := range base {
+		strokes = append(strokes, stroke.New(ref.Name, ref.Cluster, ref.Segment))
+	}
+
+	// every stroke Eval derives is planned with the same rule
+	plan := Plan(rules, base)
+	derived := Eval(rules, strokes)
+	if len(plan) != len(derived) {
+		t.Errorf("Plan() has %d strokes, want %d", len(plan), len(derived))
+	}
+	for _, d := range derived {
+		if got, ok := plan[d.Rule.Target]; !ok || got.Line != d.Rule.Line {
+			t.Errorf("Plan() %s = %v, want %v", d.Rule.Target, got, d.Rule)
+		}
+	}
+}
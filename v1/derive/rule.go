@@ -0,0 +1,362 @@
+package derive
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bjatkin/silabex/stroke"
+)
+
+// Transform is a single movement applied to a stroke before it is joined into a derived stroke
+type Transform int
+
+const (
+	Up Transform = iota
+	Down
+	Left
+	Right
+	FlipX
+	FlipY
+)
+
+var transformNames = map[rune]Transform{
+	'u': Up,
+	'd': Down,
+	'l': Left,
+	'r': Right,
+	'x': FlipX,
+	'y': FlipY,
+}
+
+func parseTransforms(suffix string) ([]Transform, error) {
+	transforms := []Transform{}
+	for _, r := range suffix {
+		t, ok := transformNames[r]
+		if !ok {
+			return nil, fmt.Errorf("unknown transform '%s'", string(r))
+		}
+		transforms = append(transforms, t)
+	}
+
+	return transforms, nil
+}
+
+func (t Transform) String() string {
+	for r, transform := range transformNames {
+		if transform == t {
+			return string(r)
+		}
+	}
+
+	return "?"
+}
+
+func (t Transform) apply(s *stroke.Stroke) {
+	switch t {
+	case Up:
+		s.Up()
+	case Down:
+		s.Down()
+	case Left:
+		s.Left()
+	case Right:
+		s.Right()
+	case FlipX:
+		s.FlipX()
+	case FlipY:
+		s.FlipY()
+	}
+}
+
+var tagSegments = map[string]stroke.Segment{
+	"head":  stroke.Head,
+	"hang":  stroke.Hang,
+	"core":  stroke.Core,
+	"stand": stroke.Stand,
+	"foot":  stroke.Foot,
+	"tall":  stroke.Tall,
+}
+
+var tagClusters = map[string]stroke.Cluster{
+	"initial": stroke.Initial,
+	"solo":    stroke.Solo,
+}
+
+// allSegments are the segments generated by the :all tag
+var allSegments = []stroke.Segment{stroke.Hang, stroke.Stand, stroke.Core, stroke.Tall}
+
+// Ref identifies a single stroke in the font. Name is the template slot name (e.g. "023")
+type Ref struct {
+	Cluster stroke.Cluster
+	Name    string
+	Segment stroke.Segment
+}
+
+func (r Ref) String() string {
+	cluster := map[stroke.Cluster]string{
+		stroke.Solo:    "S",
+		stroke.Initial: "I",
+		stroke.Vowel:   "V",
+		stroke.Final:   "F",
+	}[r.Cluster]
+
+	if r.Cluster == stroke.Vowel {
+		return fmt.Sprintf("%s.%s", cluster, r.Name)
+	}
+
+	segment := "border"
+	for name, s := range tagSegments {
+		if s == r.Segment {
+			segment = name
+		}
+	}
+
+	return fmt.Sprintf("%s.%s:%s", cluster, r.Name, segment)
+}
+
+// Term is a stroke that is used to build a derived stroke
+type Term struct {
+	Ref        Ref
+	Transforms []Transform
+}
+
+func (t Term) String() string {
+	if len(t.Transforms) == 0 {
+		return t.Ref.String()
+	}
+
+	transforms := ""
+	for _, transform := range t.Transforms {
+		transforms += transform.String()
+	}
+
+	return t.Ref.String() + "." + transforms
+}
+
+// Rule is a fully expanded derivation, the target stroke is built by joining all the terms
+type Rule struct {
+	Line   int
+	Target Ref
+	Terms  []Term
+}
+
+func (r Rule) String() string {
+	terms := []string{}
+	for _, term := range r.Terms {
+		terms = append(terms, term.String())
+	}
+
+	return fmt.Sprintf("%s | %s", r.Target, strings.Join(terms, " "))
+}
+
+// Expand generates every concrete rule described by the file. Rules are returned in file order
+// so the first rule that can build a stroke is the one that is used
+func (f *File) Expand() ([]Rule, error) {
+	rules := []Rule{}
+	errors := []*lineError{}
+
+	for _, stmt := range f.Rules {
+		expanded, err := stmt.expand()
+		if err != nil {
+			errors = append(errors, err)
+			continue
+		}
+
+		rules = append(rules, expanded...)
+	}
+
+	if len(errors) > 0 {
+		return nil, newBuilderError(f.Name, errors)
+	}
+
+	return rules, nil
+}
+
+func (r *RuleStmt) expand() ([]Rule, *lineError) {
+	clusters, segments := r.targets()
+
+	rules := []Rule{}
+	for _, captures := range expandParts(r.Target) {
+		name := strings.Join(captures.values, "")
+		slots, err := r.Bank.slots(name)
+		if err != nil {
+			return nil, newLineError(r.Line, "%s", err)
+		}
+
+		for _, cluster := range clusters {
+			for _, segment := range segments {
+				target := Ref{
+					Cluster: cluster,
+					Name:    slots,
+					Segment: segmentFor(r.Bank, slots, segment),
+				}
+
+				terms := []Term{}
+				for _, expr := range r.Exprs {
+					term, err := expr.term(r, target, captures.captured)
+					if err != nil {
+						return nil, err
+					}
+					terms = append(terms, term)
+				}
+
+				rules = append(rules, Rule{
+					Line:   r.Line,
+					Target: target,
+					Terms:  terms,
+				})
+			}
+		}
+	}
+
+	return rules, nil
+}
+
+// targets returns the clusters and segments the rule generates strokes for
+func (r *RuleStmt) targets() ([]stroke.Cluster, []stroke.Segment) {
+	switch r.Bank {
+	case VowelBank:
+		return []stroke.Cluster{stroke.Vowel}, []stroke.Segment{stroke.Border}
+	case FinalBank:
+		_, segments := splitTags(r.Tags)
+		return []stroke.Cluster{stroke.Final}, segments
+	default:
+		return splitTags(r.Tags)
+	}
+}
+
+// splitTags converts rule tags into the initial clusters and segments they select,
+// missing tags default to :all
+func splitTags(tags []string) ([]stroke.Cluster, []stroke.Segment) {
+	clusters := []stroke.Cluster{}
+	segments := []stroke.Segment{}
+	for _, tag := range tags {
+		if segment, ok := tagSegments[tag]; ok {
+			segments = append(segments, segment)
+		}
+		if cluster, ok := tagClusters[tag]; ok {
+			clusters = append(clusters, cluster)
+		}
+	}
+
+	if len(clusters) == 0 {
+		clusters = []stroke.Cluster{stroke.Initial, stroke.Solo}
+	}
+	if len(segments) == 0 {
+		segments = allSegments
+	}
+
+	return clusters, segments
+}
+
+func (e *Expr) term(rule *RuleStmt, target Ref, captured [][]string) (Term, *lineError) {
+	bank := rule.Bank
+	if e.HasBank {
+		bank = e.Bank
+	}
+
+	name, nameBank := e.Name, bank
+	if index, err := strconv.Atoi(e.Name); err == nil {
+		if index < 0 || index >= len(captured) {
+			return Term{}, newLineError(rule.Line, "capture %d does not exist, the target only has %d captures", index, len(captured))
+		}
+
+		// captured names are always written in the rule's bank
+		name, nameBank = strings.Join(captured[index], ""), rule.Bank
+	}
+
+	slots, err := nameBank.slots(name)
+	if err != nil {
+		return Term{}, newLineError(rule.Line, "%s", err)
+	}
+
+	if e.Convert {
+		converted, ok := bank.convert()
+		if !ok {
+			return Term{}, newLineError(rule.Line, "%% can only convert between initial and final consonants")
+		}
+		bank = converted
+	}
+
+	segment := target.Segment
+	for _, tag := range e.Tags {
+		if s, ok := tagSegments[tag]; ok {
+			segment = s
+		}
+	}
+
+	if bank == VowelBank {
+		segment = stroke.Border
+	}
+
+	transforms, err := parseTransforms(e.Transforms)
+	if err != nil {
+		return Term{}, newLineError(rule.Line, "%s", err)
+	}
+
+	return Term{
+		Ref: Ref{
+			Cluster: bank.cluster(target.Cluster),
+			Name:    slots,
+			Segment: segmentFor(bank, slots, segment),
+		},
+		Transforms: transforms,
+	}, nil
+}
+
+// captureSet is a single name generated from a rule target. captured holds the values picked for each
+// of the [] and () parts, combinations keep each of their picked values so they can be indexed
+type captureSet struct {
+	values   []string
+	captured [][]string
+}
+
+// expandParts generates every name described by the target parts. Literal parts are kept as is,
+// [] parts are replaced by each of their combinations and () parts by each of their values
+func expandParts(parts []Part) []captureSet {
+	sets := []captureSet{{}}
+	for _, part := range parts {
+		options := [][]string{}
+		switch part.Kind {
+		case CombinePart:
+			options = subsets(part.Values)
+		default:
+			for _, value := range part.Values {
+				options = append(options, []string{value})
+			}
+		}
+
+		next := []captureSet{}
+		for _, set := range sets {
+			for _, option := range options {
+				values := append(append([]string{}, set.values...), option...)
+				captured := set.captured
+				if part.Kind != LiteralPart {
+					captured = append(append([][]string{}, set.captured...), option)
+				}
+
+				next = append(next, captureSet{values: values, captured: captured})
+			}
+		}
+		sets = next
+	}
+
+	return sets
+}
+
+// subsets returns every non empty subset of values, the subsets are ordered the same way as combinations
+func subsets(values []string) [][]string {
+	ret := [][]string{}
+	for mask := 1; mask < 1<<len(values); mask++ {
+		subset := []string{}
+		for i, value := range values {
+			if mask&(1<<i) != 0 {
+				subset = append(subset, value)
+			}
+		}
+		ret = append(ret, subset)
+	}
+
+	return ret
+}
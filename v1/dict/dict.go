@@ -0,0 +1,184 @@
+package dict
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bjatkin/silabex/steno"
+)
+
+// Entry is a single outline and its translation from a dictionary
+type Entry struct {
+	// Outline is the outline as it was written in the dictionary
+	Outline string
+	// Strokes are the parsed strokes of the outline, they are nil if any stroke could not be parsed
+	Strokes     []steno.Keys
+	Translation string
+	// Parts is the parsed translation, it is nil if the translation could not be parsed
+	Parts []Part
+	// Errs lists every problem found in the entry
+	Errs []error
+}
+
+// Valid reports whether the entry was parsed without errors
+func (e Entry) Valid() bool {
+	return len(e.Errs) == 0
+}
+
+// Dictionary is a list of entries in the order they were loaded
+type Dictionary struct {
+	Entries []Entry
+}
+
+// LoadFile loads a dictionary file, .rtf and .cre files are read as RTF/CRE and anything else as plover json
+func LoadFile(path string) (*Dictionary, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	load := Load
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".rtf", ".cre":
+		load = LoadRTF
+	}
+
+	ret, err := load(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return ret, nil
+}
+
+// Load reads a plover json dictionary, an object that maps outlines (e.g. "HEL/HROE") to translations.
+// Only malformed json is an error, problems with an entry are kept in the entry so the rest of the
+// dictionary can still be used
+func Load(r io.Reader) (*Dictionary, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, err
+	}
+
+	ret := &Dictionary{}
+	seen := map[string]int{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		outline := tok.(string)
+
+		translation := ""
+		if err := dec.Decode(&translation); err != nil {
+			return nil, fmt.Errorf("outline %s: %w", outline, err)
+		}
+
+		entry := NewEntry(outline, translation)
+		if i, ok := seen[entry.key()]; ok {
+			entry.Errs = append(entry.Errs, fmt.Errorf("outline %s is also defined by entry %d", outline, i+1))
+		}
+		seen[entry.key()] = len(ret.Entries)
+
+		ret.Entries = append(ret.Entries, entry)
+	}
+
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// expectDelim reads the next json token and checks that it is the delimiter
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if tok != delim {
+		return fmt.Errorf("expected '%s' but got %v", delim, tok)
+	}
+
+	return nil
+}
+
+// NewEntry parses an outline and its translation. Every bad stroke is reported, not just the first one
+func NewEntry(outline, translation string) Entry {
+	ret := Entry{
+		Outline:     outline,
+		Translation: translation,
+	}
+
+	strokes := []steno.Keys{}
+	for i, stroke := range strings.Split(outline, "/") {
+		k, err := steno.ParseKeys(stroke)
+		if err != nil {
+			ret.Errs = append(ret.Errs, fmt.Errorf("stroke %d: %w", i+1, err))
+			continue
+		}
+		strokes = append(strokes, k)
+	}
+	if len(ret.Errs) == 0 {
+		ret.Strokes = strokes
+	}
+
+	parts, err := ParseTranslation(translation)
+	if err != nil {
+		ret.Errs = append(ret.Errs, fmt.Errorf("translation: %w", err))
+	} else {
+		ret.Parts = parts
+	}
+
+	return ret
+}
+
+// key is the canonical form of the outline, entries with the same key are the same outline
+func (e Entry) key() string {
+	if e.Strokes == nil {
+		return e.Outline
+	}
+
+	strokes := []string{}
+	for _, k := range e.Strokes {
+		strokes = append(strokes, k.String())
+	}
+
+	return strings.Join(strokes, "/")
+}
+
+// Errors returns the problems with every entry, each error names the outline it came from
+func (d *Dictionary) Errors() error {
+	errs := []error{}
+	for _, e := range d.Entries {
+		for _, err := range e.Errs {
+			errs = append(errs, fmt.Errorf("outline %s: %w", e.Outline, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Number returns the digits a number stroke writes when it has no entry, false is returned if the
+// stroke does not have the number bar or has keys that are not digits
+func Number(k steno.Keys) (string, bool) {
+	if k&steno.NumberBar == 0 {
+		return "", false
+	}
+
+	stroke := strings.ReplaceAll(k.String(), "-", "")
+	for _, r := range stroke {
+		if r < '0' || r > '9' {
+			return "", false
+		}
+	}
+
+	return stroke, true
+}
@@ -0,0 +1,137 @@
+package dict
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bjatkin/silabex/steno"
+)
+
+func TestLoadFile(t *testing.T) {
+	d, err := LoadFile("testdata/main.json")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	if len(d.Entries) != 15 {
+		t.Fatalf("LoadFile() got %d entries, want 15", len(d.Entries))
+	}
+
+	tests := []struct {
+		outline string
+		strokes string
+		errs    int
+	}{
+		{"HEL/HROE", "HEL/HROE", 0},
+		{"TAOEU/TPH*EU", "TAOEU/TPH*EU", 0},
+		{"#S-T", "1-9", 0},
+		{"TAK-T", "", 1},
+		{"HEL/XYZ/HROE", "", 1},
+		{"PWROBG", "PWROBG", 1},
+		{"1-9", "1-9", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.outline, func(t *testing.T) {
+			var entry *Entry
+			for i := range d.Entries {
+				if d.Entries[i].Outline == tt.outline {
+					entry = &d.Entries[i]
+				}
+			}
+			if entry == nil {
+				t.Fatalf("LoadFile() is missing outline %s", tt.outline)
+			}
+
+			strokes := []string{}
+			for _, k := range entry.Strokes {
+				strokes = append(strokes, k.String())
+			}
+			if got := strings.Join(strokes, "/"); got != tt.strokes {
+				t.Errorf("Entry.Strokes = %v, want %v", got, tt.strokes)
+			}
+			if len(entry.Errs) != tt.errs {
+				t.Errorf("Entry.Errs = %v, want %d errors", entry.Errs, tt.errs)
+			}
+		})
+	}
+
+	if err := d.Errors(); err == nil || !strings.Contains(err.Error(), "outline TAK-T") {
+		t.Errorf("Dictionary.Errors() = %v, want the TAK-T error", err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    int
+		wantErr bool
+	}{
+		{"empty", `{}`, 0, false},
+		{"entries", `{"KAT": "cat", "-T": "{^t}"}`, 2, false},
+		{"not an object", `["KAT"]`, 0, true},
+		{"not a string", `{"KAT": 1}`, 0, true},
+		{"truncated", `{"KAT": "cat"`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(strings.NewReader(tt.json))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && len(got.Entries) != tt.want {
+				t.Errorf("Load() got %d entries, want %d", len(got.Entries), tt.want)
+			}
+		})
+	}
+}
+
+func TestNewEntry(t *testing.T) {
+	got := NewEntry("HEL/XYZ/HR-O/HROE", "{")
+	if got.Strokes != nil || got.Parts != nil {
+		t.Errorf("NewEntry() = %v, %v, want no strokes or parts", got.Strokes, got.Parts)
+	}
+	if len(got.Errs) != 3 {
+		t.Errorf("NewEntry() errs = %v, want 3 errors", got.Errs)
+	}
+
+	got = NewEntry("KAT", "cat")
+	want := Entry{
+		Outline:     "KAT",
+		Strokes:     []steno.Keys{steno.LeftK | steno.VowelA | steno.RightT},
+		Translation: "cat",
+		Parts:       []Part{{Op: Text, Text: "cat"}},
+	}
+	if !reflect.DeepEqual(got, want) || !got.Valid() {
+		t.Errorf("NewEntry() = %v, want %v", got, want)
+	}
+}
+
+func TestNumber(t *testing.T) {
+	tests := []struct {
+		stroke string
+		want   string
+		wantOk bool
+	}{
+		{"1-9", "19", true},
+		{"#TAO", "250", true},
+		{"#S-D", "", false},
+		{"#K", "", false},
+		{"S-T", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.stroke, func(t *testing.T) {
+			k, err := steno.ParseKeys(tt.stroke)
+			if err != nil {
+				t.Fatalf("ParseKeys() error = %v", err)
+			}
+
+			got, ok := Number(k)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("Number() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
@@ -0,0 +1,410 @@
+package dict
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// LoadRTF reads an RTF/CRE steno dictionary. Every {\*\cxs OUTLINE} group starts an entry and the rtf
+// up to the next entry is its translation. The translation is converted into plover syntax so the
+// entries match the ones from a json dictionary
+func LoadRTF(r io.Reader) (*Dictionary, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := string(data)
+	if !strings.HasPrefix(strings.TrimSpace(doc), `{\rtf1`) {
+		return nil, errors.New(`rtf dictionary must start with {\rtf1`)
+	}
+
+	const entryStart = `{\*\cxs `
+	ret := &Dictionary{}
+	seen := map[string]int{}
+	for i := strings.Index(doc, entryStart); i >= 0; {
+		i += len(entryStart)
+		end := strings.IndexByte(doc[i:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed outline at offset %d", i)
+		}
+		outline := strings.TrimSpace(doc[i : i+end])
+		i += end + 1
+
+		next := strings.Index(doc[i:], entryStart)
+		body := doc[i:]
+		if next >= 0 {
+			body = doc[i : i+next]
+		} else {
+			// the last entry ends with the group that closes the document
+			body = strings.TrimRight(body, " \r\n")
+			body = strings.TrimSuffix(body, "}")
+		}
+
+		translation, rtfErr := rtfTranslation(body)
+		entry := NewEntry(outline, translation)
+		if rtfErr != nil {
+			entry.Errs = append(entry.Errs, fmt.Errorf("rtf: %w", rtfErr))
+		}
+		if j, ok := seen[entry.key()]; ok {
+			entry.Errs = append(entry.Errs, fmt.Errorf("outline %s is also defined by entry %d", outline, j+1))
+		}
+		seen[entry.key()] = len(ret.Entries)
+		ret.Entries = append(ret.Entries, entry)
+
+		if next < 0 {
+			break
+		}
+		i += next
+	}
+
+	return ret, nil
+}
+
+// rtfPiece is a piece of a translation read from rtf. A piece is either text, a plover meta command
+// or a \cxds that deletes the space next to it
+type rtfPiece struct {
+	text   string
+	meta   string
+	delete bool
+}
+
+// rtfReader converts the rtf of a single translation into pieces
+type rtfReader struct {
+	src    string
+	pos    int
+	pieces []rtfPiece
+	// skip counts the characters still to skip after a \u control word
+	skip int
+	// high is the first half of a surrogate pair written with \u
+	high rune
+	errs []error
+}
+
+// rtfTranslation converts the rtf of a translation into plover syntax. Formatting is dropped,
+// unknown steno control words are reported but the rest of the translation is still converted
+func rtfTranslation(body string) (string, error) {
+	r := &rtfReader{src: body}
+	r.group(false)
+
+	b := strings.Builder{}
+	for i := 0; i < len(r.pieces); i++ {
+		p := r.pieces[i]
+		switch {
+		case p.meta != "":
+			b.WriteString("{" + p.meta + "}")
+		case p.delete:
+			// a deleted space joins the text next to it into a single attach command
+			meta := "^"
+			if i+1 < len(r.pieces) && r.pieces[i+1].text != "" {
+				meta += escapeText(r.pieces[i+1].text)
+				i++
+				if i+1 < len(r.pieces) && r.pieces[i+1].delete {
+					meta += "^"
+					i++
+				}
+			}
+			b.WriteString("{" + meta + "}")
+		case i+1 < len(r.pieces) && r.pieces[i+1].delete && (i+2 == len(r.pieces) || r.pieces[i+2].text == ""):
+			b.WriteString("{" + escapeText(p.text) + "^}")
+			i++
+		default:
+			b.WriteString(escapeText(p.text))
+		}
+	}
+
+	return b.String(), errors.Join(r.errs...)
+}
+
+// addText adds text to the last piece if it is also text
+func (r *rtfReader) addText(text string) {
+	if r.skip > 0 {
+		r.skip--
+		return
+	}
+
+	if n := len(r.pieces); n > 0 && r.pieces[n-1].text != "" {
+		r.pieces[n-1].text += text
+		return
+	}
+	r.pieces = append(r.pieces, rtfPiece{text: text})
+}
+
+// group reads until the end of the current group. Groups inside a conflict only keep the first choice
+func (r *rtfReader) group(conflict bool) {
+	choices := 0
+	for r.pos < len(r.src) {
+		c := r.src[r.pos]
+		switch c {
+		case '}':
+			r.pos++
+			return
+		case '{':
+			r.pos++
+			r.subgroup(conflict, &choices)
+		case '\\':
+			r.control()
+		case '\r', '\n':
+			r.pos++
+		default:
+			_, size := utf8.DecodeRuneInString(r.src[r.pos:])
+			// the brackets and bars around conflict choices are not part of the translation
+			if !conflict {
+				r.addText(r.src[r.pos : r.pos+size])
+			}
+			r.pos += size
+		}
+	}
+}
+
+// subgroup reads a group that was just opened
+func (r *rtfReader) subgroup(conflict bool, choices *int) {
+	if strings.HasPrefix(r.src[r.pos:], `\*`) {
+		r.pos += 2
+		word, _, _ := r.word()
+		switch word {
+		case "cxplovermeta":
+			r.pieces = append(r.pieces, rtfPiece{meta: r.raw()})
+		case "cxplovermacro":
+			r.errs = append(r.errs, fmt.Errorf("plover macro %s is not supported", r.raw()))
+		default:
+			r.raw()
+		}
+		return
+	}
+
+	if !strings.HasPrefix(r.src[r.pos:], `\`) {
+		r.group(conflict)
+		return
+	}
+
+	start := r.pos
+	word, _, _ := r.word()
+	switch word {
+	case "cxp":
+		punct := strings.TrimSpace(r.raw())
+		if len(punct) == 1 && strings.Contains(".,?!:;", punct) {
+			r.pieces = append(r.pieces, rtfPiece{meta: punct})
+		} else {
+			r.pieces = append(r.pieces, rtfPiece{meta: "^" + punct + "^"})
+		}
+	case "cxfing":
+		r.pieces = append(r.pieces, rtfPiece{meta: "&" + strings.TrimSpace(r.raw())})
+	case "cxconf":
+		r.group(true)
+	case "cxc":
+		if !conflict || *choices == 0 {
+			r.group(false)
+		} else {
+			r.raw()
+		}
+		*choices++
+	default:
+		// formatting groups like {\b bold} keep their text
+		r.pos = start
+		r.group(conflict)
+	}
+}
+
+// raw returns the text of the current group and moves past its closing brace
+func (r *rtfReader) raw() string {
+	depth := 0
+	b := strings.Builder{}
+	for ; r.pos < len(r.src); r.pos++ {
+		c := r.src[r.pos]
+		switch {
+		case c == '\\' && r.pos+1 < len(r.src) && strings.IndexByte(`{}\`, r.src[r.pos+1]) >= 0:
+			r.pos++
+			b.WriteByte(r.src[r.pos])
+			continue
+		case c == '{':
+			depth++
+		case c == '}' && depth == 0:
+			r.pos++
+			return b.String()
+		case c == '}':
+			depth--
+		}
+		b.WriteByte(c)
+	}
+
+	return b.String()
+}
+
+// word reads a control word and its numeric parameter, the backslash must be at the current position.
+// A control symbol (a backslash and a single character) is returned with no parameter
+func (r *rtfReader) word() (string, int, bool) {
+	r.pos++
+	start := r.pos
+	for r.pos < len(r.src) && isLetter(r.src[r.pos]) {
+		r.pos++
+	}
+	if r.pos == start {
+		if r.pos < len(r.src) {
+			r.pos++
+		}
+		return r.src[start:r.pos], 0, false
+	}
+	word := r.src[start:r.pos]
+
+	numStart := r.pos
+	if r.pos < len(r.src) && r.src[r.pos] == '-' {
+		r.pos++
+	}
+	for r.pos < len(r.src) && r.src[r.pos] >= '0' && r.src[r.pos] <= '9' {
+		r.pos++
+	}
+	param, err := strconv.Atoi(r.src[numStart:r.pos])
+	hasParam := err == nil
+
+	// a single space ends the control word and is not part of the text
+	if r.pos < len(r.src) && r.src[r.pos] == ' ' {
+		r.pos++
+	}
+
+	return word, param, hasParam
+}
+
+// control reads a control word or symbol outside of a destination group
+func (r *rtfReader) control() {
+	if r.pos+3 < len(r.src) && r.src[r.pos+1] == '\'' {
+		b, err := strconv.ParseUint(r.src[r.pos+2:r.pos+4], 16, 8)
+		r.pos += 4
+		if err == nil {
+			r.addText(string(rune(b)))
+		}
+		return
+	}
+
+	word, param, hasParam := r.word()
+	switch word {
+	case "{", "}", `\`:
+		r.addText(word)
+	case "~":
+		r.addText(" ")
+	case "_":
+		r.addText("-")
+	case "par", "line":
+		r.addText("\n")
+	case "tab":
+		r.addText("\t")
+	case "u":
+		if !hasParam {
+			return
+		}
+		if param < 0 {
+			param += 0x10000
+		}
+
+		switch c := rune(param); {
+		case utf16.IsSurrogate(c) && r.high == 0:
+			r.high = c
+		case utf16.IsSurrogate(c):
+			r.addText(string(utf16.DecodeRune(r.high, c)))
+			r.high = 0
+		default:
+			r.addText(string(c))
+		}
+		r.skip = 1
+	case "cxds":
+		r.pieces = append(r.pieces, rtfPiece{delete: true})
+	case "cxfc":
+		r.pieces = append(r.pieces, rtfPiece{meta: "-|"})
+	case "cxfl", "cxfls":
+		r.pieces = append(r.pieces, rtfPiece{meta: ">"})
+	default:
+		if strings.HasPrefix(word, "cx") {
+			r.errs = append(r.errs, fmt.Errorf(`unsupported control word \%s`, word))
+		}
+	}
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// WriteRTF writes the dictionary as an RTF/CRE dictionary. Translations that could not be parsed are
+// written as plain text
+func WriteRTF(w io.Writer, d *Dictionary) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(`{\rtf1\ansi{\*\cxrev100}\cxdict{\*\cxsystem silabex}{\stylesheet{\s0 Normal;}}` + "\r\n")
+	for _, e := range d.Entries {
+		bw.WriteString(`{\*\cxs ` + e.Outline + "}")
+		if e.Parts == nil {
+			bw.WriteString(rtfText(e.Translation))
+		}
+		for _, p := range e.Parts {
+			bw.WriteString(rtfPart(p))
+		}
+		bw.WriteString("\r\n")
+	}
+	bw.WriteString("}\r\n")
+
+	return bw.Flush()
+}
+
+// rtfPart writes a single part of a translation as rtf
+func rtfPart(p Part) string {
+	switch p.Op {
+	case Text:
+		return rtfText(p.Text)
+	case Attach:
+		ret := ""
+		if p.Before {
+			ret += `\cxds `
+		}
+		ret += rtfText(p.Text)
+		if p.After && (p.Text != "" || !p.Before) {
+			ret += `\cxds `
+		}
+		return ret
+	case Punct:
+		return `{\cxp ` + p.Text + ` }`
+	case Glue:
+		return `{\cxfing ` + rtfText(p.Text) + `}`
+	case CapNext:
+		return `\cxfc `
+	case LowerNext:
+		return `\cxfls `
+	default:
+		return `{\*\cxplovermeta ` + rtfText(strings.TrimSuffix(strings.TrimPrefix(p.String(), "{"), "}")) + `}`
+	}
+}
+
+// rtfText escapes text for rtf, characters outside of ascii are written as unicode control words
+func rtfText(text string) string {
+	b := strings.Builder{}
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '{' || r == '}':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '\n':
+			b.WriteString(`\par `)
+		case r == '\t':
+			b.WriteString(`\tab `)
+		case r > 0x7F:
+			halves := []rune{r}
+			if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+				halves = []rune{r1, r2}
+			}
+			for _, h := range halves {
+				v := int(h)
+				if v > 0x7FFF {
+					v -= 0x10000
+				}
+				fmt.Fprintf(&b, `\u%d?`, v)
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
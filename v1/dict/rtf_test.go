@@ -0,0 +1,114 @@
+package dict
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadFile_rtf(t *testing.T) {
+	d, err := LoadFile("testdata/main.rtf")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	want := []struct {
+		outline     string
+		translation string
+		errs        int
+	}{
+		{"HEL/HROE", "hello", 0},
+		{"TP-PL", "{.}", 0},
+		{"KW-BG", "{,}", 0},
+		{"-G", "{^ing}", 0},
+		{"RE", "{re^}", 0},
+		{"H-F", "{^-^}", 0},
+		{"KPA", "{-|}", 0},
+		{"A*", "{&a}", 0},
+		{"KAFR", "café", 0},
+		{"PWOLD", "bold text", 0},
+		{"TKPWAEUT", "gate", 0},
+		{"R-R", "{#Return}", 0},
+		{"TPHAOU/HRAOEUPB", "new\nline", 0},
+		{"TAK-T", "bad stroke", 1},
+		{"PWRAEUS", `\{brace\}`, 1},
+	}
+	if len(d.Entries) != len(want) {
+		t.Fatalf("LoadFile() got %d entries, want %d", len(d.Entries), len(want))
+	}
+
+	for i, w := range want {
+		t.Run(w.outline, func(t *testing.T) {
+			got := d.Entries[i]
+			if got.Outline != w.outline || got.Translation != w.translation {
+				t.Errorf("entry = %q: %q, want %q: %q", got.Outline, got.Translation, w.outline, w.translation)
+			}
+			if len(got.Errs) != w.errs {
+				t.Errorf("entry errs = %v, want %d errors", got.Errs, w.errs)
+			}
+		})
+	}
+}
+
+func TestLoadRTF(t *testing.T) {
+	tests := []struct {
+		name    string
+		rtf     string
+		want    int
+		wantErr bool
+	}{
+		{"no entries", `{\rtf1\ansi\cxdict}`, 0, false},
+		{"one entry", `{\rtf1\ansi\cxdict{\*\cxs KAT}cat}`, 1, false},
+		{"not rtf", `{"KAT": "cat"}`, 0, true},
+		{"unclosed outline", `{\rtf1\ansi\cxdict{\*\cxs KAT`, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadRTF(strings.NewReader(tt.rtf))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadRTF() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err == nil && len(got.Entries) != tt.want {
+				t.Errorf("LoadRTF() got %d entries, want %d", len(got.Entries), tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteRTF(t *testing.T) {
+	d, err := LoadFile("testdata/main.json")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+	d.Entries = append(d.Entries,
+		NewEntry("KAFR", "café 😀"),
+		NewEntry("R-R", "{#Return}{^}{>}"),
+		NewEntry("TPHAOU/HRAOEUPB", "new\nline"),
+		NewEntry("A*", "{&a}"),
+	)
+
+	buf := bytes.Buffer{}
+	if err := WriteRTF(&buf, d); err != nil {
+		t.Fatalf("WriteRTF() error = %v", err)
+	}
+
+	got, err := LoadRTF(&buf)
+	if err != nil {
+		t.Fatalf("LoadRTF() error = %v", err)
+	}
+	if len(got.Entries) != len(d.Entries) {
+		t.Fatalf("LoadRTF() got %d entries, want %d", len(got.Entries), len(d.Entries))
+	}
+
+	for i, want := range d.Entries {
+		e := got.Entries[i]
+		if e.Outline != want.Outline {
+			t.Errorf("entry %d outline = %v, want %v", i, e.Outline, want.Outline)
+		}
+		if want.Parts != nil && !reflect.DeepEqual(e.Parts, want.Parts) {
+			t.Errorf("entry %s parts = %v, want %v", want.Outline, e.Parts, want.Parts)
+		}
+	}
+}
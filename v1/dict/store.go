@@ -0,0 +1,215 @@
+package dict
+
+import (
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bjatkin/silabex/lru"
+	"github.com/bjatkin/silabex/steno"
+)
+
+// DefaultGlyphCacheSize is the number of rendered glyphs a store keeps by default
+const DefaultGlyphCacheSize = 1024
+
+// Renderer draws the glyph for a single stroke
+type Renderer func(steno.Keys) (string, error)
+
+// Store indexes dictionary entries so they can be found by their strokes or by their translation.
+// Lookups are safe to use from multiple goroutines, but Add and SetRenderer must not be called while
+// the store is being read
+type Store struct {
+	root *trieNode
+	// words maps each translation to the entries that write it
+	words map[string][]*Entry
+	// sorted is every translation in order, it is rebuilt by the first Prefix after entries are added
+	// so mu guards it
+	mu     sync.Mutex
+	sorted []string
+
+	render Renderer
+	glyphs *lru.Cache[steno.Keys, string]
+}
+
+// trieNode is a node of the stroke trie, each edge is a single stroke
+type trieNode struct {
+	children map[steno.Keys]*trieNode
+	entry    *Entry
+}
+
+// Match is a piece of a segmented stroke stream, Entry is nil for strokes that have no translation
+type Match struct {
+	Strokes []steno.Keys
+	Entry   *Entry
+}
+
+// NewStore indexes every valid entry of the dictionaries. Entries from later dictionaries replace
+// entries with the same outline, so dictionaries should be given from lowest to highest priority
+func NewStore(dicts ...*Dictionary) *Store {
+	s := &Store{
+		root:   &trieNode{},
+		words:  map[string][]*Entry{},
+		glyphs: lru.New[steno.Keys, string](DefaultGlyphCacheSize),
+	}
+
+	for _, d := range dicts {
+		for _, e := range d.Entries {
+			s.Add(e)
+		}
+	}
+
+	return s
+}
+
+// Add indexes an entry, false is returned if the entry has errors
+func (s *Store) Add(e Entry) bool {
+	if !e.Valid() || len(e.Strokes) == 0 {
+		return false
+	}
+
+	node := s.root
+	for _, k := range e.Strokes {
+		if node.children == nil {
+			node.children = map[steno.Keys]*trieNode{}
+		}
+		next, ok := node.children[k]
+		if !ok {
+			next = &trieNode{}
+			node.children[k] = next
+		}
+		node = next
+	}
+
+	if node.entry != nil {
+		s.removeWord(node.entry)
+	}
+	node.entry = &e
+
+	if _, ok := s.words[e.Translation]; !ok {
+		s.sorted = nil
+	}
+	s.words[e.Translation] = append(s.words[e.Translation], node.entry)
+
+	return true
+}
+
+// removeWord drops a replaced entry from the entries of its translation
+func (s *Store) removeWord(e *Entry) {
+	entries := slices.DeleteFunc(s.words[e.Translation], func(o *Entry) bool {
+		return o == e
+	})
+
+	if len(entries) == 0 {
+		delete(s.words, e.Translation)
+		s.sorted = nil
+		return
+	}
+	s.words[e.Translation] = entries
+}
+
+// Lookup returns the entry for a stroke sequence
+func (s *Store) Lookup(strokes []steno.Keys) (Entry, bool) {
+	node := s.root
+	for _, k := range strokes {
+		node = node.children[k]
+		if node == nil {
+			return Entry{}, false
+		}
+	}
+
+	if node.entry == nil {
+		return Entry{}, false
+	}
+
+	return *node.entry, true
+}
+
+// Outlines returns every stroke sequence that writes the translation, shortest first
+func (s *Store) Outlines(translation string) [][]steno.Keys {
+	ret := [][]steno.Keys{}
+	for _, e := range s.words[translation] {
+		ret = append(ret, e.Strokes)
+	}
+
+	sort.SliceStable(ret, func(i, j int) bool {
+		return len(ret[i]) < len(ret[j])
+	})
+
+	return ret
+}
+
+// Prefix returns every translation that starts with prefix in sorted order
+func (s *Store) Prefix(prefix string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.sorted == nil {
+		s.sorted = make([]string, 0, len(s.words))
+		for word := range s.words {
+			s.sorted = append(s.sorted, word)
+		}
+		sort.Strings(s.sorted)
+	}
+
+	start := sort.SearchStrings(s.sorted, prefix)
+	end := start
+	for end < len(s.sorted) && strings.HasPrefix(s.sorted[end], prefix) {
+		end++
+	}
+
+	return append([]string{}, s.sorted[start:end]...)
+}
+
+// Segment splits a stroke stream into entries, the longest outline that matches is always used.
+// A stroke that does not start any outline becomes a match of its own with no entry
+func (s *Store) Segment(strokes []steno.Keys) []Match {
+	ret := []Match{}
+	for i := 0; i < len(strokes); {
+		var best *Entry
+		length := 1
+
+		node := s.root
+		for j := i; j < len(strokes); j++ {
+			node = node.children[strokes[j]]
+			if node == nil {
+				break
+			}
+			if node.entry != nil {
+				best = node.entry
+				length = j - i + 1
+			}
+		}
+
+		ret = append(ret, Match{Strokes: strokes[i : i+length], Entry: best})
+		i += length
+	}
+
+	return ret
+}
+
+// SetRenderer sets how glyphs are drawn and replaces the glyph cache with one that holds size glyphs
+func (s *Store) SetRenderer(render Renderer, size int) {
+	s.render = render
+	s.glyphs = lru.New[steno.Keys, string](size)
+}
+
+// Glyph returns the rendered glyph for a stroke, recently used glyphs are cached
+func (s *Store) Glyph(k steno.Keys) (string, error) {
+	if glyph, ok := s.glyphs.Get(k); ok {
+		return glyph, nil
+	}
+
+	if s.render == nil {
+		return "", errors.New("store has no glyph renderer")
+	}
+
+	glyph, err := s.render(k)
+	if err != nil {
+		return "", err
+	}
+	s.glyphs.Add(k, glyph)
+
+	return glyph, nil
+}
@@ -0,0 +1,197 @@
+package dict
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bjatkin/silabex/steno"
+)
+
+func strokes(t *testing.T, outline string) []steno.Keys {
+	t.Helper()
+	ret := []steno.Keys{}
+	for _, stroke := range strings.Split(outline, "/") {
+		k, err := steno.ParseKeys(stroke)
+		if err != nil {
+			t.Fatalf("ParseKeys() error = %v", err)
+		}
+		ret = append(ret, k)
+	}
+
+	return ret
+}
+
+func testStore(t *testing.T) *Store {
+	t.Helper()
+	d, err := Load(strings.NewReader(`{
+		"HEL": "hell",
+		"HEL/HROE": "hello",
+		"HEL/HROE/-S": "hellos",
+		"HEL/PH-T": "helmet",
+		"WORLD": "world",
+		"WORLD/WAOEUD": "worldwide",
+		"HAOEL": "hello",
+		"TAK-T": "bad"
+	}`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	override, err := Load(strings.NewReader(`{"HEL": "help"}`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	return NewStore(d, override)
+}
+
+func TestStore_Lookup(t *testing.T) {
+	s := testStore(t)
+	tests := []struct {
+		outline string
+		want    string
+		wantOk  bool
+	}{
+		{"HEL/HROE", "hello", true},
+		{"HEL", "help", true},
+		{"HEL/HROE/-S", "hellos", true},
+		{"HEL/PH", "", false},
+		{"KAT", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.outline, func(t *testing.T) {
+			got, ok := s.Lookup(strokes(t, tt.outline))
+			if ok != tt.wantOk || got.Translation != tt.want {
+				t.Errorf("Store.Lookup() = %v, %v, want %v, %v", got.Translation, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestStore_Outlines(t *testing.T) {
+	s := testStore(t)
+	tests := []struct {
+		word string
+		want []string
+	}{
+		{"hello", []string{"HAOEL", "HEL/HROE"}},
+		{"help", []string{"HEL"}},
+		{"hell", []string{}},
+		{"bad", []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.word, func(t *testing.T) {
+			got := []string{}
+			for _, o := range s.Outlines(tt.word) {
+				names := []string{}
+				for _, k := range o {
+					names = append(names, k.String())
+				}
+				got = append(got, strings.Join(names, "/"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Store.Outlines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_Prefix(t *testing.T) {
+	s := testStore(t)
+	tests := []struct {
+		prefix string
+		want   []string
+	}{
+		{"hel", []string{"hello", "hellos", "helmet", "help"}},
+		{"world", []string{"world", "worldwide"}},
+		{"x", []string{}},
+		{"", []string{"hello", "hellos", "helmet", "help", "world", "worldwide"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if got := s.Prefix(tt.prefix); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Store.Prefix() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_Prefix_concurrent(t *testing.T) {
+	// the first Prefix builds the sorted index, so every goroutine races to build it
+	s := testStore(t)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := s.Prefix("world"); len(got) != 2 {
+				t.Errorf("Store.Prefix() = %v, want 2 translations", got)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestStore_Segment(t *testing.T) {
+	s := testStore(t)
+	tests := []struct {
+		name    string
+		strokes string
+		want    []string
+	}{
+		{"longest match", "HEL/HROE/-S/WORLD", []string{"hellos", "world"}},
+		{"backs off to a shorter match", "HEL/HROE/WORLD/WAOEUD", []string{"hello", "worldwide"}},
+		{"prefix without an entry", "HEL/PH/WORLD", []string{"help", "?PH", "world"}},
+		{"untranslated", "KAT/HEL", []string{"?KAT", "help"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, m := range s.Segment(strokes(t, tt.strokes)) {
+				if m.Entry == nil {
+					got = append(got, "?"+m.Strokes[0].String())
+					continue
+				}
+				got = append(got, m.Entry.Translation)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Store.Segment() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore_Glyph(t *testing.T) {
+	s := testStore(t)
+	if _, err := s.Glyph(steno.LeftS); err == nil {
+		t.Errorf("Store.Glyph() error = nil, want an error without a renderer")
+	}
+
+	calls := 0
+	s.SetRenderer(func(k steno.Keys) (string, error) {
+		calls++
+		if k == steno.Star {
+			return "", errors.New("no glyph")
+		}
+		return "<g>" + k.String() + "</g>", nil
+	}, 2)
+
+	for _, k := range []steno.Keys{steno.LeftS, steno.LeftT, steno.LeftS, steno.LeftK, steno.LeftT} {
+		want := "<g>" + k.String() + "</g>"
+		if got, err := s.Glyph(k); err != nil || got != want {
+			t.Errorf("Store.Glyph() = %v, %v, want %v", got, err, want)
+		}
+	}
+
+	// S is cached on its second use, T is evicted by K before it is used again
+	if calls != 4 {
+		t.Errorf("renderer was called %d times, want 4", calls)
+	}
+
+	if _, err := s.Glyph(steno.Star); err == nil {
+		t.Errorf("Store.Glyph() error = nil, want the renderer error")
+	}
+}
@@ -0,0 +1,225 @@
+package dict
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Op is the kind of a part of a translation
+type Op int
+
+const (
+	// Text is literal text, words in the text are seperated by spaces as usual
+	Text Op = iota
+	// Attach is text that is joined to the word before and/or after it, {^} attaches on both sides
+	Attach
+	// Glue is text that is joined to other glued text, like fingerspelled letters
+	Glue
+	// Punct is a punctuation mark that attaches to the word before it. A sentence ending mark
+	// also capitalizes the next word
+	Punct
+	// CapNext capitalizes the next word
+	CapNext
+	// LowerNext lowercases the first letter of the next word
+	LowerNext
+	// UpperNext uppercases the whole next word
+	UpperNext
+	// CapPrev capitalizes the previous word
+	CapPrev
+	// LowerPrev lowercases the first letter of the previous word
+	LowerPrev
+	// UpperPrev uppercases the whole previous word
+	UpperPrev
+	// KeyCombo presses keys instead of writing text (e.g. {#Return})
+	KeyCombo
+	// Command runs a plover command (e.g. {PLOVER:TOGGLE})
+	Command
+)
+
+func (o Op) String() string {
+	switch o {
+	case Text:
+		return "text"
+	case Attach:
+		return "attach"
+	case Glue:
+		return "glue"
+	case Punct:
+		return "punct"
+	case CapNext:
+		return "cap next"
+	case LowerNext:
+		return "lower next"
+	case UpperNext:
+		return "upper next"
+	case CapPrev:
+		return "cap prev"
+	case LowerPrev:
+		return "lower prev"
+	case UpperPrev:
+		return "upper prev"
+	case KeyCombo:
+		return "key combo"
+	case Command:
+		return "command"
+	default:
+		return "?"
+	}
+}
+
+// sentenceEnd are the punctuation marks that capitalize the next word
+const sentenceEnd = ".?!"
+
+// Part is a piece of text or a single meta command in a translation
+type Part struct {
+	Op   Op
+	Text string
+	// Before and After are set for attached text when it joins the word before or after it
+	Before bool
+	After  bool
+}
+
+// SentenceEnd reports whether the part ends a sentence
+func (p Part) SentenceEnd() bool {
+	return p.Op == Punct && strings.Contains(sentenceEnd, p.Text)
+}
+
+// metaOps are the meta commands that have no arguments
+var metaOps = map[string]Op{
+	"-|":  CapNext,
+	">":   LowerNext,
+	"<":   UpperNext,
+	"*-|": CapPrev,
+	"*>":  LowerPrev,
+	"*<":  UpperPrev,
+}
+
+// ParseTranslation splits a plover translation into text and meta commands. Braces can be written as
+// text by escaping them with a backslash
+func ParseTranslation(translation string) ([]Part, error) {
+	ret := []Part{}
+	text := strings.Builder{}
+	flush := func() {
+		if text.Len() > 0 {
+			ret = append(ret, Part{Op: Text, Text: text.String()})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(translation); i++ {
+		c := translation[i]
+		switch c {
+		case '\\':
+			if i+1 < len(translation) && strings.IndexByte(`{}\`, translation[i+1]) >= 0 {
+				i++
+				c = translation[i]
+			}
+			text.WriteByte(c)
+		case '{':
+			end := strings.IndexByte(translation[i:], '}')
+			if end < 0 {
+				return nil, fmt.Errorf("unclosed meta command at offset %d", i)
+			}
+
+			part, err := parseMeta(translation[i+1 : i+end])
+			if err != nil {
+				return nil, fmt.Errorf("offset %d: %w", i, err)
+			}
+
+			flush()
+			ret = append(ret, part)
+			i += end
+		case '}':
+			return nil, fmt.Errorf("unexpected '}' at offset %d", i)
+		default:
+			text.WriteByte(c)
+		}
+	}
+	flush()
+
+	return ret, nil
+}
+
+// parseMeta parses the inside of a meta command
+func parseMeta(meta string) (Part, error) {
+	if op, ok := metaOps[meta]; ok {
+		return Part{Op: op}, nil
+	}
+
+	switch {
+	case meta == "":
+		return Part{}, fmt.Errorf("empty meta command")
+	case len(meta) == 1 && strings.Contains(".,?!:;", meta):
+		return Part{Op: Punct, Text: meta}, nil
+	case meta[0] == '&':
+		return Part{Op: Glue, Text: meta[1:]}, nil
+	case meta[0] == '#':
+		return Part{Op: KeyCombo, Text: meta[1:]}, nil
+	case strings.HasPrefix(strings.ToUpper(meta), "PLOVER:"):
+		return Part{Op: Command, Text: meta[len("PLOVER:"):]}, nil
+	case meta[0] == '^' || meta[len(meta)-1] == '^':
+		part := Part{Op: Attach, Text: meta}
+		if strings.HasPrefix(part.Text, "^") {
+			part.Before = true
+			part.Text = part.Text[1:]
+		}
+		if strings.HasSuffix(part.Text, "^") {
+			part.After = true
+			part.Text = part.Text[:len(part.Text)-1]
+		}
+		if meta == "^" {
+			part.After = true
+		}
+		return part, nil
+	}
+
+	return Part{}, fmt.Errorf("unknown meta command {%s}", meta)
+}
+
+// String writes the part in plover syntax
+func (p Part) String() string {
+	for meta, op := range metaOps {
+		if op == p.Op {
+			return "{" + meta + "}"
+		}
+	}
+
+	switch p.Op {
+	case Text:
+		return escapeText(p.Text)
+	case Attach:
+		meta := p.Text
+		if p.Before {
+			meta = "^" + meta
+		}
+		if p.After && (p.Text != "" || !p.Before) {
+			meta += "^"
+		}
+		return "{" + meta + "}"
+	case Glue:
+		return "{&" + p.Text + "}"
+	case Punct:
+		return "{" + p.Text + "}"
+	case KeyCombo:
+		return "{#" + p.Text + "}"
+	case Command:
+		return "{PLOVER:" + p.Text + "}"
+	default:
+		return ""
+	}
+}
+
+// FormatTranslation writes the parts as a plover translation
+func FormatTranslation(parts []Part) string {
+	b := strings.Builder{}
+	for _, p := range parts {
+		b.WriteString(p.String())
+	}
+
+	return b.String()
+}
+
+// escapeText escapes the characters that would be read as meta commands
+func escapeText(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "{", `\{`, "}", `\}`).Replace(text)
+}
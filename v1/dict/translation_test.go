@@ -0,0 +1,70 @@
+package dict
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTranslation(t *testing.T) {
+	tests := []struct {
+		name        string
+		translation string
+		want        []Part
+		wantErr     bool
+	}{
+		{"text", "hello world", []Part{{Op: Text, Text: "hello world"}}, false},
+		{"attach", "{^}", []Part{{Op: Attach, Before: true, After: true}}, false},
+		{"suffix", "{^ing}", []Part{{Op: Attach, Text: "ing", Before: true}}, false},
+		{"prefix", "{re^}", []Part{{Op: Attach, Text: "re", After: true}}, false},
+		{"infix", "{^-^}", []Part{{Op: Attach, Text: "-", Before: true, After: true}}, false},
+		{"period", "{.}", []Part{{Op: Punct, Text: "."}}, false},
+		{"comma", "{,}", []Part{{Op: Punct, Text: ","}}, false},
+		{"cap next", "{-|}", []Part{{Op: CapNext}}, false},
+		{"cap prev", "{*-|}", []Part{{Op: CapPrev}}, false},
+		{"glue", "{&a}", []Part{{Op: Glue, Text: "a"}}, false},
+		{"key combo", "{#Return}", []Part{{Op: KeyCombo, Text: "Return"}}, false},
+		{"command", "{PLOVER:TOGGLE}", []Part{{Op: Command, Text: "TOGGLE"}}, false},
+		{"mixed", "{^}n't{-|}", []Part{
+			{Op: Attach, Before: true, After: true},
+			{Op: Text, Text: "n't"},
+			{Op: CapNext},
+		}, false},
+		{"escaped braces", `\{x\}`, []Part{{Op: Text, Text: "{x}"}}, false},
+		{"empty", "", []Part{}, false},
+		{"unclosed", "{^ing", nil, true},
+		{"stray close", "a}", nil, true},
+		{"empty meta", "{}", nil, true},
+		{"unknown meta", "{MODE:CAPS}", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTranslation(tt.translation)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseTranslation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTranslation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPart_SentenceEnd(t *testing.T) {
+	tests := []struct {
+		part Part
+		want bool
+	}{
+		{Part{Op: Punct, Text: "."}, true},
+		{Part{Op: Punct, Text: "?"}, true},
+		{Part{Op: Punct, Text: ","}, false},
+		{Part{Op: Text, Text: "."}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.part.Text, func(t *testing.T) {
+			if got := tt.part.SentenceEnd(); got != tt.want {
+				t.Errorf("Part.SentenceEnd() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
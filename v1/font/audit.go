@@ -0,0 +1,201 @@
+package font
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bjatkin/silabex/geom"
+	"github.com/bjatkin/silabex/pua"
+	"github.com/bjatkin/silabex/steno"
+)
+
+// auditTolerance is the max distance between a curve and the polyline used to check it for collisions
+const auditTolerance = 0.5
+
+// Issue lists the problems with a single character of the font
+type Issue struct {
+	Chord steno.Keys
+	// Collisions are the pairs of components that overlap
+	Collisions [][2]string
+	// Overflows are the components that reach outside of the em box
+	Overflows []string
+}
+
+func (i Issue) String() string {
+	problems := []string{}
+	for _, c := range i.Collisions {
+		problems = append(problems, fmt.Sprintf("%s collides with %s", c[0], c[1]))
+	}
+	for _, o := range i.Overflows {
+		problems = append(problems, fmt.Sprintf("%s overflows the em box", o))
+	}
+
+	return fmt.Sprintf("%s: %s", i.Chord, strings.Join(problems, ", "))
+}
+
+// component is the outline of one part of a character
+type component struct {
+	name     string
+	outline  geom.Path
+	bounds   geom.Rect
+	empty    bool
+	overflow bool
+}
+
+// auditor checks the components of characters, components and pairs of components are only checked once
+type auditor struct {
+	em    geom.Rect
+	pairs map[[2]*component]bool
+}
+
+func (a *auditor) component(name string, group StrokeGroup) (*component, error) {
+	outline, err := group.Outline()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	bounds, ok := outline.Bounds()
+	return &component{
+		name:     name,
+		outline:  outline,
+		bounds:   bounds,
+		empty:    !ok,
+		overflow: ok && bounds.Union(a.em) != a.em,
+	}, nil
+}
+
+// collide reports whether two components overlap
+func (a *auditor) collide(x, y *component) bool {
+	if x.empty || y.empty || !x.bounds.Overlaps(y.bounds) {
+		return false
+	}
+
+	key := [2]*component{x, y}
+	if hit, ok := a.pairs[key]; ok {
+		return hit
+	}
+
+	hit := x.outline.Intersects(y.outline, auditTolerance)
+	a.pairs[key] = hit
+	return hit
+}
+
+// Audit checks every character the font can draw and returns the characters whose components
+// collide with each other or reach outside of the em box. Characters are listed in the order of
+// their keys, number bar chords are not checked because they are drawn the same as the chord without it
+func (f *Font) Audit() ([]Issue, error) {
+	a := &auditor{
+		em:    geom.NewRect(0, 0, f.metrics.EmSize, f.metrics.EmSize),
+		pairs: map[[2]*component]bool{},
+	}
+
+	// vowels only change the consonants through their top and bottom bars
+	bars := func(vowel string) int {
+		seg := segments("", vowel)
+		ret := 0
+		if seg.TopBar {
+			ret |= 1
+		}
+		if seg.BottomBar {
+			ret |= 2
+		}
+		return ret
+	}
+	barVowel := []string{"", "2", "1", "12"}
+
+	// the keys of each bank are found once and joined for every chord, keys are a bit set so the
+	// banks can not overlap
+	vowels := make([]*component, 1<<4)
+	vowelBars := make([]int, 1<<4)
+	vowelChords := make([]steno.Keys, 1<<4)
+	for i := range vowels {
+		vowelChords[i] = pua.FromBanks(0, i, 0)
+		chord := vowelChords[i].Chord()
+		slots := chordSlots(chord.Vowel, vowelKeys)
+		c, err := a.component("vowel "+chord.String(), f.vowelStrokes[slots])
+		if err != nil {
+			return nil, err
+		}
+		vowels[i] = c
+		vowelBars[i] = bars(slots)
+	}
+
+	solos := make([][4]*component, 1<<8)
+	initials := make([][4]*component, 1<<8)
+	initialChords := make([]steno.Keys, 1<<8)
+	for i := range solos {
+		initialChords[i] = pua.FromBanks(i, 0, 0)
+		chord := initialChords[i].Chord()
+		initial := chord.Initial
+		if chord.Star {
+			initial += "*"
+		}
+		slots := chordSlots(initial, initialKeys)
+
+		for b, vowel := range barVowel {
+			c, err := a.component("solo "+chord.String(), f.consonant(f.soloParts, f.soloStrokes, slots, vowel))
+			if err != nil {
+				return nil, err
+			}
+			solos[i][b] = c
+
+			c, err = a.component("initial "+chord.String(), f.consonant(f.initialParts, f.initialStrokes, slots, vowel))
+			if err != nil {
+				return nil, err
+			}
+			initials[i][b] = c
+		}
+	}
+
+	finals := make([][4]*component, 1<<10)
+	finalChords := make([]steno.Keys, 1<<10)
+	for i := 1; i < len(finals); i++ {
+		finalChords[i] = pua.FromBanks(0, 0, i)
+		chord := finalChords[i].Chord()
+		for b, vowel := range barVowel {
+			char, err := f.SlotCharacter("", vowel, chord.Final)
+			if err != nil {
+				return nil, err
+			}
+			c, err := a.component("final "+chord.String(), char.finalStrokes)
+			if err != nil {
+				return nil, err
+			}
+			finals[i][b] = c
+		}
+	}
+
+	issues := []Issue{}
+	check := func(k steno.Keys, parts ...*component) {
+		issue := Issue{Chord: k}
+		for i, x := range parts {
+			if x.overflow && !x.empty {
+				issue.Overflows = append(issue.Overflows, x.name)
+			}
+			for _, y := range parts[i+1:] {
+				if a.collide(x, y) {
+					issue.Collisions = append(issue.Collisions, [2]string{x.name, y.name})
+				}
+			}
+		}
+
+		if len(issue.Collisions) > 0 || len(issue.Overflows) > 0 {
+			issues = append(issues, issue)
+		}
+	}
+
+	for i := range initials {
+		for v := range vowels {
+			b := vowelBars[v]
+			k := initialChords[i] | vowelChords[v]
+			if i != 0 || v != 0 {
+				check(k, solos[i][b], vowels[v])
+			}
+			for fi := 1; fi < len(finals); fi++ {
+				check(k|finalChords[fi], initials[i][b], vowels[v], finals[fi][b])
+			}
+		}
+	}
+
+	return issues, nil
+}
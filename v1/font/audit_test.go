@@ -0,0 +1,77 @@
+package font
+
+import (
+	"testing"
+
+	"github.com/bjatkin/silabex/steno"
+)
+
+func TestFont_Audit(t *testing.T) {
+	// shift moves strokes to the left or right of where the template draws them
+	type shift struct {
+		solo  float64 // the solo T strokes
+		final float64 // the final -T strokes
+	}
+
+	tests := []struct {
+		name  string
+		shift shift
+		chord string
+		want  string
+	}{
+		{"clean solo", shift{}, "TKA", ""},
+		{"clean syllable", shift{}, "TKAFPLT", ""},
+		{"hung final clears the vowel bar", shift{}, "EPLGD", ""},
+		{"vowel hits a final", shift{final: 100}, "EUT", "EUT: vowel EU collides with final -T"},
+		{"initial hits a final", shift{final: -100}, "TAT", "TAT: initial T collides with final -T"},
+		{"solo outside the em box", shift{solo: -300}, "T", "T: solo T overflows the em box"},
+		{"solo over the vowel", shift{solo: -100}, "TA", "TA: solo T collides with vowel A"},
+		{"shifted solo is not used with a final", shift{solo: -100}, "TAF", ""},
+	}
+
+	// the whole font is audited once for each shift
+	audits := map[shift][]Issue{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := steno.ParseKeys(tt.chord)
+			if err != nil {
+				t.Fatalf("ParseKeys() error = %v", err)
+			}
+
+			issues, ok := audits[tt.shift]
+			if !ok {
+				f, err := NewFont("../reference/font2.svg")
+				if err != nil {
+					t.Fatalf("NewFont() error = %v", err)
+				}
+				solo := f.soloStrokes["2"]
+				solo.group.Transform(tt.shift.solo)
+				f.soloStrokes["2"] = solo
+				final := f.finalStrokes["T"]
+				final.group.Transform(tt.shift.final)
+				f.finalStrokes["T"] = final
+
+				issues, err = f.Audit()
+				if err != nil {
+					t.Fatalf("Audit() error = %v", err)
+				}
+				audits[tt.shift] = issues
+			}
+
+			got := ""
+			for _, issue := range issues {
+				if issue.Chord == k {
+					got = issue.String()
+				}
+			}
+			if got != tt.want {
+				t.Errorf("Audit() %s = %q, want %q", tt.chord, got, tt.want)
+			}
+		})
+	}
+
+	// validate runs the audit in CI, so the reference template has to be clean
+	if issues := audits[shift{}]; len(issues) != 0 {
+		t.Errorf("Audit() found %d issues in the reference template, the first is %q", len(issues), issues[0])
+	}
+}
@@ -0,0 +1,322 @@
+package font
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"strings"
+
+	"github.com/JoshVarga/svgparser"
+	"github.com/bjatkin/silabex/derive"
+	"github.com/bjatkin/silabex/stroke"
+	"github.com/bjatkin/silabex/svg"
+)
+
+// Status is how a stroke of the font is drawn
+type Status int
+
+const (
+	// Missing strokes are not in the template and no rule derives them
+	Missing Status = iota
+	// Explicit strokes are drawn in the template
+	Explicit
+	// Derived strokes are built by a derivation rule from other strokes
+	Derived
+)
+
+func (s Status) String() string {
+	switch s {
+	case Explicit:
+		return "explicit"
+	case Derived:
+		return "derived"
+	default:
+		return "missing"
+	}
+}
+
+// Entry is the coverage of a single stroke, consonants are covered for each size class
+type Entry struct {
+	Cluster Cluster
+	Size    SizeClass
+	Name    string
+	Status  Status
+	// Rule builds the stroke, it is only set for derived strokes
+	Rule *derive.Rule
+}
+
+// ref is how derivation rules name the stroke
+func (e Entry) ref() derive.Ref {
+	ref := derive.Ref{Cluster: strokeClusters[e.Cluster], Name: e.Name}
+	if e.Cluster != Vowel {
+		ref.Segment = e.Size.segment()
+	}
+
+	return ref
+}
+
+// strokeClusters maps the clusters of the font to the clusters named by derivation rules
+var strokeClusters = map[Cluster]stroke.Cluster{
+	Vowel:   stroke.Vowel,
+	Solo:    stroke.Solo,
+	Initial: stroke.Initial,
+	Final:   stroke.Final,
+}
+
+// segment is the segment derivation rules draw the size class in
+func (s SizeClass) segment() stroke.Segment {
+	switch s {
+	case Full:
+		return stroke.Tall
+	case TwoThirds:
+		return stroke.Stand
+	default:
+		return stroke.Core
+	}
+}
+
+// Coverage lists how every consonant size class and vowel of the font is drawn. Consonants are
+// listed by slot name with the initial strokes before the solo strokes
+type Coverage struct {
+	Consonants []Entry
+	Vowels     []Entry
+}
+
+// slotNames returns every combination of the slots, ordered by the bit set of slots they use
+func slotNames(slots string) []string {
+	ret := []string{}
+	for mask := 1; mask < 1<<len(slots); mask++ {
+		name := ""
+		for i := range slots {
+			if mask&(1<<i) != 0 {
+				name += string(slots[i])
+			}
+		}
+		ret = append(ret, name)
+	}
+
+	return ret
+}
+
+// drawn reports whether the template element has any strokes, empty groups are left for the rules to derive
+func drawn(elem *svgparser.Element) bool {
+	return elem != nil && svg.NewGroup(elem, 0, 0).SVG() != ""
+}
+
+// Coverage reports whether each consonant size class and vowel is drawn in the template, derived by
+// one of the rules or missing. Rules are run in order like derive.Eval, so the first rule that can
+// build a stroke is the one that is reported
+func (f *Font) Coverage(rules []derive.Rule) Coverage {
+	base := []derive.Ref{}
+	explicit := map[derive.Ref]bool{}
+	for ref := range f.templateParts() {
+		base = append(base, ref)
+		explicit[ref] = true
+
+		// Derive moves the stand strokes up for rules that use hang strokes
+		if ref.Segment == stroke.Stand {
+			base = append(base, derive.Ref{Cluster: ref.Cluster, Name: ref.Name, Segment: stroke.Hang})
+		}
+	}
+	plan := derive.Plan(rules, base)
+
+	cover := func(e Entry) Entry {
+		ref := e.ref()
+		if explicit[ref] {
+			e.Status = Explicit
+		} else if rule, ok := plan[ref]; ok {
+			e.Status = Derived
+			e.Rule = &rule
+		}
+		return e
+	}
+
+	ret := Coverage{}
+	for _, name := range slotNames("234567") {
+		for _, cluster := range []Cluster{Initial, Solo} {
+			for _, size := range SizeClasses {
+				ret.Consonants = append(ret.Consonants, cover(Entry{Cluster: cluster, Size: size, Name: name}))
+			}
+		}
+	}
+
+	for _, name := range slotNames("0123") {
+		ret.Vowels = append(ret.Vowels, cover(Entry{Cluster: Vowel, Name: name}))
+	}
+
+	return ret
+}
+
+// Count returns the number of strokes with each status
+func (c Coverage) Count() map[Status]int {
+	ret := map[Status]int{Explicit: 0, Derived: 0, Missing: 0}
+	for _, e := range append(append([]Entry{}, c.Consonants...), c.Vowels...) {
+		ret[e.Status]++
+	}
+
+	return ret
+}
+
+// Summary is a single line with the count of each status
+func (c Coverage) Summary() string {
+	count := c.Count()
+	return fmt.Sprintf("%d explicit, %d derived, %d missing", count[Explicit], count[Derived], count[Missing])
+}
+
+// cell is the mark used for a status in the markdown tables
+func (s Status) cell() string {
+	switch s {
+	case Explicit:
+		return "  x  "
+	case Derived:
+		return "  d  "
+	default:
+		return "     "
+	}
+}
+
+// Markdown writes the coverage as tables, x marks explicit strokes and d marks derived strokes. The
+// rule used for each derived stroke is listed after the tables
+func (c Coverage) Markdown() string {
+	ret := []string{
+		"<!-- generated by silabex coverage, do not edit -->",
+		"",
+		c.Summary() + ". x is drawn in the template, d is derived and an empty cell is missing.",
+		"",
+		"# Consonants",
+		"",
+		" id | I.t | I.s | I.c | S.t | S.s | S.c | Name",
+		"----|-----|-----|-----|-----|-----|-----|------",
+	}
+	columns := 2 * len(SizeClasses)
+	for i := 0; i < len(c.Consonants); i += columns {
+		row := []string{fmt.Sprintf(" %-3d", i/columns)}
+		for _, e := range c.Consonants[i : i+columns] {
+			row = append(row, e.Status.cell())
+		}
+		row = append(row, " "+c.Consonants[i].Name)
+		ret = append(ret, strings.Join(row, "|"))
+	}
+
+	ret = append(ret,
+		"",
+		"# Vowels",
+		" id |     | Name",
+		"----|-----|------",
+	)
+	for i, v := range c.Vowels {
+		ret = append(ret, fmt.Sprintf(" %-3d|%s| %s", i, v.Status.cell(), v.Name))
+	}
+
+	derived := []string{}
+	for _, e := range append(append([]Entry{}, c.Consonants...), c.Vowels...) {
+		if e.Rule != nil {
+			derived = append(derived, fmt.Sprintf("- `%s` (line %d)", e.Rule, e.Rule.Line))
+		}
+	}
+	if len(derived) > 0 {
+		ret = append(ret, "", "# Derived", "")
+		ret = append(ret, derived...)
+	}
+
+	return strings.Join(ret, "\n") + "\n"
+}
+
+// jsonEntry is how an entry is written in the json report
+type jsonEntry struct {
+	Cluster string `json:"cluster"`
+	Segment string `json:"segment,omitempty"`
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Rule    string `json:"rule,omitempty"`
+	Line    int    `json:"line,omitempty"`
+}
+
+func (e Entry) json() jsonEntry {
+	ret := jsonEntry{
+		Cluster: map[Cluster]string{Initial: "initial", Solo: "solo", Vowel: "vowel"}[e.Cluster],
+		Name:    e.Name,
+		Status:  e.Status.String(),
+	}
+	if e.Cluster != Vowel {
+		ret.Segment = e.Size.label()
+	}
+	if e.Rule != nil {
+		ret.Rule = e.Rule.String()
+		ret.Line = e.Rule.Line
+	}
+
+	return ret
+}
+
+// JSON writes the coverage as a json object with the count of each status and every entry
+func (c Coverage) JSON() ([]byte, error) {
+	report := struct {
+		Summary map[string]int `json:"summary"`
+		Entries []jsonEntry    `json:"entries"`
+	}{
+		Summary: map[string]int{},
+	}
+	for status, n := range c.Count() {
+		report.Summary[status.String()] = n
+	}
+	for _, e := range append(append([]Entry{}, c.Consonants...), c.Vowels...) {
+		report.Entries = append(report.Entries, e.json())
+	}
+
+	return json.MarshalIndent(report, "", "  ")
+}
+
+var coverageTemplate = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>silabex coverage</title>
+<style>
+body { font-family: sans-serif; margin: 24px; }
+table { border-collapse: collapse; margin-bottom: 24px; }
+th, td { border: 1px solid #ccc; padding: 2px 8px; text-align: center; }
+.explicit { background: #c8e6c9; }
+.derived { background: #fff59d; }
+.missing { background: #ffcdd2; }
+</style>
+</head>
+<body>
+<p>{{.Summary}}. Hover a derived cell to see its rule.</p>
+<h1>Consonants</h1>
+<table>
+<tr><th>id</th><th>I.t</th><th>I.s</th><th>I.c</th><th>S.t</th><th>S.s</th><th>S.c</th><th>Name</th></tr>
+{{range $i, $row := .Consonants}}<tr><td>{{$i}}</td>{{range $row}}<td class="{{.Status}}"{{with .Rule}} title="{{.}} (line {{.Line}})"{{end}}>{{.Status}}</td>{{end}}<td>{{(index $row 0).Name}}</td></tr>
+{{end}}</table>
+<h1>Vowels</h1>
+<table>
+<tr><th>id</th><th></th><th>Name</th></tr>
+{{range $i, $v := .Vowels}}<tr><td>{{$i}}</td><td class="{{.Status}}"{{with .Rule}} title="{{.}} (line {{.Line}})"{{end}}>{{.Status}}</td><td>{{.Name}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// HTML writes the coverage as an html page with the same tables as the markdown report
+func (c Coverage) HTML() (string, error) {
+	data := struct {
+		Summary    string
+		Consonants [][]Entry
+		Vowels     []Entry
+	}{
+		Summary: c.Summary(),
+		Vowels:  c.Vowels,
+	}
+	columns := 2 * len(SizeClasses)
+	for i := 0; i < len(c.Consonants); i += columns {
+		data.Consonants = append(data.Consonants, c.Consonants[i:i+columns])
+	}
+
+	buf := &bytes.Buffer{}
+	if err := coverageTemplate.Execute(buf, data); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
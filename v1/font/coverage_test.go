@@ -0,0 +1,118 @@
+package font
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/bjatkin/silabex/derive"
+)
+
+func TestFont_Coverage(t *testing.T) {
+	f, err := NewFont("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("NewFont() error = %v", err)
+	}
+	rules, err := derive.LoadRules("../reference/derive.dat")
+	if err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+
+	// I.3:tall and V.13 have rules that derive them, nothing derives I.23:core
+	f.initialParts.sizes[Full]["3"] = nil
+	f.initialParts.sizes[Half]["23"] = nil
+	f.vowelParts["13"] = nil
+
+	got := f.Coverage(rules)
+	if len(got.Consonants) != 63*6 || len(got.Vowels) != 15 {
+		t.Fatalf("Coverage() has %d consonants and %d vowels, want %d and 15", len(got.Consonants), len(got.Vowels), 63*6)
+	}
+
+	find := func(entries []Entry, cluster Cluster, size SizeClass, name string) Entry {
+		for _, e := range entries {
+			if e.Cluster == cluster && e.Size == size && e.Name == name {
+				return e
+			}
+		}
+		t.Fatalf("Coverage() has no %s", name)
+		return Entry{}
+	}
+
+	tests := []struct {
+		name     string
+		entry    Entry
+		want     Status
+		wantRule string
+	}{
+		{"explicit", find(got.Consonants, Initial, Full, "2"), Explicit, ""},
+		{"explicit solo", find(got.Consonants, Solo, Full, "3"), Explicit, ""},
+		{"derived consonant", find(got.Consonants, Initial, Full, "3"), Derived, "I.3:tall | I.2:tall.x"},
+		{"missing consonant", find(got.Consonants, Initial, Half, "23"), Missing, ""},
+		{"derived vowel", find(got.Vowels, Vowel, Full, "13"), Derived, "V.13 | V.1 V.3"},
+		{"explicit vowel", find(got.Vowels, Vowel, Full, "0123"), Explicit, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.entry.Status != tt.want {
+				t.Errorf("Coverage() status = %v, want %v", tt.entry.Status, tt.want)
+			}
+
+			rule := ""
+			if tt.entry.Rule != nil {
+				rule = tt.entry.Rule.String()
+			}
+			if rule != tt.wantRule {
+				t.Errorf("Coverage() rule = %q, want %q", rule, tt.wantRule)
+			}
+		})
+	}
+
+	count := got.Count()
+	if count[Derived] != 2 || count[Missing] != 1 {
+		t.Errorf("Count() = %v, want 2 derived and 1 missing", count)
+	}
+
+	md := got.Markdown()
+	for _, want := range []string{
+		" 0  |  x  |  x  |  x  |  x  |  x  |  x  | 2\n",
+		" 1  |  d  |  x  |  x  |  x  |  x  |  x  | 3\n",
+		" 2  |  x  |  x  |     |  x  |  x  |  x  | 23\n",
+		" 9  |  d  | 13\n",
+		"- `V.13 | V.1 V.3` (line ",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() is missing %q", want)
+		}
+	}
+
+	raw, err := got.JSON()
+	if err != nil {
+		t.Fatalf("JSON() error = %v", err)
+	}
+	report := struct {
+		Summary map[string]int
+		Entries []map[string]any
+	}{}
+	if err := json.Unmarshal(raw, &report); err != nil {
+		t.Fatalf("JSON() is not valid json: %v", err)
+	}
+	if report.Summary["missing"] != 1 || len(report.Entries) != 63*6+15 {
+		t.Errorf("JSON() summary = %v with %d entries", report.Summary, len(report.Entries))
+	}
+	if e := report.Entries[6]; e["cluster"] != "initial" || e["segment"] != "tall" || e["status"] != "derived" || e["rule"] != "I.3:tall | I.2:tall.x" {
+		t.Errorf("JSON() entry = %v, want the derived I.3:tall", e)
+	}
+
+	html, err := got.HTML()
+	if err != nil {
+		t.Fatalf("HTML() error = %v", err)
+	}
+	for _, want := range []string{
+		"<td class=\"derived\" title=\"I.3:tall | I.2:tall.x (line ",
+		"<td class=\"missing\">missing</td>",
+	} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML() is missing %q", want)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package font
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JoshVarga/svgparser"
+	"github.com/bjatkin/silabex/derive"
+	"github.com/bjatkin/silabex/stroke"
+)
+
+// templateParts returns every part drawn in the template, named the way derivation rules name them.
+// Parts that were built by the rules are left out
+func (f *Font) templateParts() map[derive.Ref]*svgparser.Element {
+	ret := map[derive.Ref]*svgparser.Element{}
+	add := func(ref derive.Ref, elem *svgparser.Element) {
+		if _, ok := f.derived[ref]; !ok && drawn(elem) {
+			ret[ref] = elem
+		}
+	}
+
+	for _, parts := range []clusterParts{f.initialParts, f.soloParts} {
+		cluster := strokeClusters[parts.cluster]
+		for _, size := range SizeClasses {
+			for name, elem := range parts.sizes[size] {
+				add(derive.Ref{Cluster: cluster, Name: name, Segment: size.segment()}, elem)
+			}
+		}
+		for name, elem := range parts.heads {
+			add(derive.Ref{Cluster: cluster, Name: name, Segment: stroke.Head}, elem)
+		}
+		for name, elem := range parts.feet {
+			add(derive.Ref{Cluster: cluster, Name: name, Segment: stroke.Foot}, elem)
+		}
+	}
+
+	for name, elem := range f.vowelParts {
+		add(derive.Ref{Cluster: stroke.Vowel, Name: name}, elem)
+	}
+
+	return ret
+}
+
+// Derive builds the parts the template does not draw with the rules and rebuilds the strokes of the
+// font with them. The rules are run with derive.Eval, so parts drawn in the template are never replaced.
+// The template does not draw finals or hang strokes, so the rules for them (e.g. $B | ^T.xr) replace
+// the initial and stand strokes the font would move into place. The derivations that were added to the
+// font are returned
+func (f *Font) Derive(rules []derive.Rule) []derive.Derivation {
+	base := stroke.StrokeSlice{}
+	for ref, elem := range f.templateParts() {
+		s := templateStroke(ref, elem).SetMetrics(f.metrics)
+		base = append(base, s)
+
+		// the template has no hang strokes, rules use the stand strokes moved up
+		if ref.Segment == stroke.Stand {
+			base = append(base, s.Copy().Up())
+		}
+	}
+
+	ret := []derive.Derivation{}
+	for _, d := range derive.Eval(rules, base) {
+		elem := &svgparser.Element{Name: "g", Attributes: map[string]string{}}
+		// finals are kept in the initial slot, the font moves them into the final slot when it builds them
+		if d.Rule.Target.Cluster == stroke.Final {
+			elem.Attributes["transform"] = fmt.Sprintf("translate(%g 0)", -f.metrics.FinalShift())
+		}
+		for _, child := range d.Stroke.Elements() {
+			child := child
+			elem.Children = append(elem.Children, &child)
+		}
+
+		if f.setPart(d.Rule.Target, elem) {
+			f.derived[d.Rule.Target] = d.Rule
+			ret = append(ret, d)
+		}
+	}
+	f.build()
+
+	return ret
+}
+
+// templateStroke creates a stroke from the children of a template group, the transform of the group
+// is kept on each child
+func templateStroke(ref derive.Ref, elem *svgparser.Element) *stroke.Stroke {
+	children := []svgparser.Element{}
+	for _, child := range elem.Children {
+		c := *child
+		if transform := elem.Attributes["transform"]; transform != "" {
+			attrs := map[string]string{}
+			for k, v := range c.Attributes {
+				attrs[k] = v
+			}
+			attrs["transform"] = strings.TrimSpace(transform + " " + attrs["transform"])
+			c.Attributes = attrs
+		}
+		children = append(children, c)
+	}
+
+	return stroke.New(ref.Name, ref.Cluster, ref.Segment, children...)
+}
+
+// setPart puts a derived part in the font, false is returned for parts the font does not read
+func (f *Font) setPart(ref derive.Ref, elem *svgparser.Element) bool {
+	set := func(parts map[string]*svgparser.Element) bool {
+		if _, ok := parts[ref.Name]; !ok {
+			return false
+		}
+		parts[ref.Name] = elem
+		return true
+	}
+
+	var parts clusterParts
+	switch ref.Cluster {
+	case stroke.Vowel:
+		return set(f.vowelParts)
+	case stroke.Initial:
+		parts = f.initialParts
+	case stroke.Solo:
+		parts = f.soloParts
+	case stroke.Final:
+		parts = f.finalParts
+	default:
+		return false
+	}
+
+	switch ref.Segment {
+	case stroke.Head:
+		return set(parts.heads)
+	case stroke.Foot:
+		return set(parts.feet)
+	case stroke.Hang:
+		return set(parts.hangs)
+	}
+	for _, size := range SizeClasses {
+		if size.segment() == ref.Segment {
+			return set(parts.sizes[size])
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,87 @@
+package font
+
+import (
+	"testing"
+
+	"github.com/bjatkin/silabex/derive"
+	"github.com/bjatkin/silabex/geom"
+	"github.com/bjatkin/silabex/stroke"
+)
+
+func TestFont_Derive(t *testing.T) {
+	rules, err := derive.LoadRules("../reference/derive.dat")
+	if err != nil {
+		t.Fatalf("LoadRules() error = %v", err)
+	}
+
+	f, err := NewFont("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("NewFont() error = %v", err)
+	}
+	// the template draws every initial, solo and vowel but no finals
+	for _, d := range f.Derive(rules) {
+		if d.Rule.Target.Cluster != stroke.Final {
+			t.Errorf("Derive() used %q, want only final rules for a template that draws every other stroke", d.Rule)
+		}
+	}
+
+	bounds := func(s StrokeGroup) geom.Rect {
+		b, err := s.group.Bounds()
+		if err != nil {
+			t.Fatalf("Bounds() error = %v", err)
+		}
+		return b
+	}
+	two, one, three := bounds(f.initialStrokes["2"]), bounds(f.vowelStrokes["1"]), bounds(f.vowelStrokes["3"])
+
+	// I.3:tall | I.2:tall.x and V.13 | V.1 V.3 build the strokes the template no longer draws, and
+	// the stand strokes for 3 are hung with I.3:hang | I.2:hang.x
+	f.initialParts.sizes[Full]["3"] = nil
+	f.initialParts.sizes[TwoThirds]["3"] = nil
+	f.vowelParts["13"] = nil
+	f.build()
+	if f.initialStrokes["3"].SVG() != "" || f.vowelStrokes["13"].SVG() != "" {
+		t.Fatalf("build() drew strokes that were removed from the template")
+	}
+
+	got := map[string]bool{}
+	for _, d := range f.Derive(rules) {
+		got[d.Rule.String()] = true
+	}
+	for _, want := range []string{"I.3:tall | I.2:tall.x", "I.3:hang | I.2:hang.x", "V.13 | V.1 V.3", "F.2:tall | I.2:tall.xr"} {
+		if !got[want] {
+			t.Errorf("Derive() did not use %q", want)
+		}
+	}
+
+	center := f.metrics.Consonant.Center().X
+	flipped := geom.Rect{
+		Min: geom.Point{X: 2*center - two.Max.X, Y: two.Min.Y},
+		Max: geom.Point{X: 2*center - two.Min.X, Y: two.Max.Y},
+	}
+	if b := bounds(f.initialStrokes["3"]); !nearRect(b, flipped) {
+		t.Errorf("initial 3 bounds = %v, want the flipped initial 2 %v", b, flipped)
+	}
+	if f.initialParts.hangs["3"] == nil {
+		t.Errorf("Derive() did not set the hang strokes for initial 3")
+	}
+	// $B | ^T.xr draws the final B as the mirrored initial T instead of the initial T moved over
+	if b := bounds(f.finalStrokes["B"]); !nearRect(b, geom.Rect{Min: flipped.Min.Add(geom.Point{X: f.metrics.FinalShift()}), Max: flipped.Max.Add(geom.Point{X: f.metrics.FinalShift()})}) {
+		t.Errorf("final B bounds = %v, want the mirrored initial T moved into the final slot", b)
+	}
+	moved := f.initialStrokes["2"]
+	moved.group.Transform(f.metrics.FinalShift())
+	if f.finalStrokes["B"].SVG(Flatten()) == moved.SVG(Flatten()) {
+		t.Errorf("final B = %s, want it mirrored", f.finalStrokes["B"].SVG(Flatten()))
+	}
+	if b := bounds(f.vowelStrokes["13"]); b != one.Union(three) {
+		t.Errorf("vowel 13 bounds = %v, want %v", b, one.Union(three))
+	}
+
+	// derived strokes are still reported as derived
+	for _, e := range f.Coverage(rules).Consonants {
+		if e.Cluster == Initial && e.Size == Full && e.Name == "3" && e.Status != Derived {
+			t.Errorf("Coverage() I.3:tall = %v, want derived", e.Status)
+		}
+	}
+}
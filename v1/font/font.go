@@ -1,11 +1,15 @@
 package font
 
 import (
+	"fmt"
 	"os"
 	"slices"
 	"strings"
 
 	"github.com/JoshVarga/svgparser"
+	"github.com/bjatkin/silabex/derive"
+	"github.com/bjatkin/silabex/metrics"
+	"github.com/bjatkin/silabex/steno"
 	"github.com/bjatkin/silabex/svg"
 )
 
@@ -16,6 +20,10 @@ const (
 	Solo
 	Initial
 	Final
+	// Symbol is a punctuation mark or digit that is drawn on its own
+	Symbol
+	// Mark is a diacritic drawn over a syllable
+	Mark
 )
 
 type StrokeGroup struct {
@@ -23,31 +31,88 @@ type StrokeGroup struct {
 	group   svg.Group
 }
 
-func (s StrokeGroup) SVG() string {
+func (s StrokeGroup) SVG(opts ...SVGOption) string {
+	options := svgOptions{}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if options.flatten {
+		return s.group.Flatten().SVG()
+	}
+
 	return s.group.SVG()
 }
 
+type svgOptions struct {
+	flatten bool
+}
+
+// SVGOption changes how strokes are written as svg
+type SVGOption func(*svgOptions)
+
+// Flatten applies every transform to the path data instead of writing transform attributes
+func Flatten() SVGOption {
+	return func(o *svgOptions) {
+		o.flatten = true
+	}
+}
+
 type Character struct {
+	size           float64
 	initialStrokes StrokeGroup
 	vowelStrokes   StrokeGroup
 	finalStrokes   StrokeGroup
+	symbolStrokes  StrokeGroup
+	markStrokes    StrokeGroup
 }
 
-func (c Character) SVG() string {
+func (c Character) SVG(opts ...SVGOption) string {
 	ret := []string{}
-	ret = append(ret, "<svg width=\"1000\" height=\"1000\" viewBox=\"0 0 1000 1000\" xmlns=\"http://www.w3.org/2000/svg\">")
-	ret = append(ret, c.initialStrokes.SVG())
-	ret = append(ret, c.vowelStrokes.SVG())
-	ret = append(ret, c.finalStrokes.SVG())
+	ret = append(ret, fmt.Sprintf("<svg width=\"%[1]g\" height=\"%[1]g\" viewBox=\"0 0 %[1]g %[1]g\" xmlns=\"http://www.w3.org/2000/svg\">", c.size))
+	ret = append(ret, c.Group(opts...))
 	ret = append(ret, "</svg>")
 
 	return strings.Join(ret, "\n")
 }
 
+// Group returns the strokes of the character in template units without the svg document around them,
+// so several characters can be placed on the same page
+func (c Character) Group(opts ...SVGOption) string {
+	ret := []string{}
+	for _, s := range c.strokes() {
+		if group := s.SVG(opts...); group != "" {
+			ret = append(ret, group)
+		}
+	}
+
+	return strings.Join(ret, "\n")
+}
+
+// strokes returns every stroke group of the character in drawing order
+func (c Character) strokes() []StrokeGroup {
+	return []StrokeGroup{c.initialStrokes, c.vowelStrokes, c.finalStrokes, c.symbolStrokes, c.markStrokes}
+}
+
 type Font struct {
+	metrics        metrics.FontMetrics
 	soloStrokes    map[string]StrokeGroup
 	initialStrokes map[string]StrokeGroup
-	vowelStrokes   map[string]StrokeGroup
+	// finalStrokes are keyed by steno final name in steno order (e.g. "FPLT")
+	finalStrokes map[string]StrokeGroup
+	vowelStrokes map[string]StrokeGroup
+	// vowelParts are the vowel groups of the template the vowel strokes are built from
+	vowelParts map[string]*svgparser.Element
+	// initialParts and soloParts build clusters at a different size class when vowel bars need the room
+	initialParts clusterParts
+	soloParts    clusterParts
+	// finalParts start as the initial parts, the rules for finals replace them
+	finalParts clusterParts
+	// derived are the parts the rules built because the template does not draw them
+	derived map[derive.Ref]derive.Rule
+	// symbolStrokes are the punctuation marks and digits
+	symbolStrokes    map[rune]StrokeGroup
+	diacriticStrokes map[Diacritic]StrokeGroup
 }
 
 func NewFont(svgPath string) (*Font, error) {
@@ -56,153 +121,206 @@ func NewFont(svgPath string) (*Font, error) {
 		return nil, err
 	}
 
-	vowels := map[string]StrokeGroup{}
-	for _, name := range combinations([]string{"0", "1", "2", "3"}) {
-		elem := findElem(root, "vowels", name)
-		group := svg.NewGroup(elem, 0, 0)
-		vowels[name] = StrokeGroup{
-			cluster: Vowel,
-			group:   *group,
-		}
+	m, err := loadMetrics(root, svgPath)
+	if err != nil {
+		return nil, err
 	}
 
-	initial := map[string]StrokeGroup{}
-	for _, name := range combinations([]string{"2", "3", "4", "5", "6", "7"}) {
-		elem := findElem(root, "initial", "tall", name)
-		group := svg.NewGroup(elem, 0, 0)
-		initial[name] = StrokeGroup{
-			cluster: Initial,
-			group:   *group,
-		}
+	vowelParts := map[string]*svgparser.Element{}
+	for _, name := range combinations([]string{"0", "1", "2", "3"}) {
+		vowelParts[name] = findElem(root, "vowels", name)
+	}
 
-		for _, prefix := range combinations([]string{"0", "1"}) {
-			elem = findElem(root, "initial", "stand", name)
-			stand := svg.NewGroup(elem, 0, 0)
+	symbols, diacritics := loadMarks(root)
+	initialParts := loadParts(root, "initial", Initial, m, m.Consonant)
+
+	f := &Font{
+		metrics:          m,
+		vowelParts:       vowelParts,
+		initialParts:     initialParts,
+		soloParts:        loadParts(root, "solos", Solo, m, m.Solo),
+		finalParts:       initialParts.copyAs(Final),
+		symbolStrokes:    symbols,
+		diacriticStrokes: diacritics,
+		derived:          map[derive.Ref]derive.Rule{},
+	}
+	f.build()
 
-			elem = findElem(root, "initial", "head", prefix)
-			head := svg.NewGroup(elem, 0, 0)
+	return f, nil
+}
 
-			group = svg.Merge(head, stand)
-			initial[prefix+name] = StrokeGroup{
-				cluster: Initial,
-				group:   *group,
-			}
+// build creates the strokes of every vowel and cluster from the parts of the template
+func (f *Font) build() {
+	f.vowelStrokes = map[string]StrokeGroup{}
+	for name, elem := range f.vowelParts {
+		f.vowelStrokes[name] = StrokeGroup{
+			cluster: Vowel,
+			group:   *svg.NewGroup(elem, 0, 0),
 		}
+	}
 
-		for _, suffix := range combinations([]string{"8", "9"}) {
-			elem = findElem(root, "initial", "stand", name)
-			stand := svg.NewGroup(elem, 0, -140)
+	// the clusters check the drawn vowel bars as well as the metrics when they choose a size class
+	top, _ := svg.NewGroup(f.vowelParts["2"], 0, 0).Bounds()
+	bottom, _ := svg.NewGroup(f.vowelParts["1"], 0, 0).Bounds()
+	f.initialParts.topBar, f.initialParts.bottomBar = top, bottom
+	f.soloParts.topBar, f.soloParts.bottomBar = top, bottom
+	f.finalParts.topBar, f.finalParts.bottomBar = top, bottom
+
+	f.initialStrokes = f.initialParts.all()
+	f.soloStrokes = f.soloParts.all()
+
+	// finals are drawn in the initial slot and shifted into the final slot, the same way the % conversion
+	// in derive.dat turns a final name into the initial slot names it is drawn with
+	f.finalStrokes = map[string]StrokeGroup{}
+	for slots, group := range f.finalParts.all() {
+		group.group.Transform(f.metrics.FinalShift())
+		f.finalStrokes[finalName(slots)] = group
+	}
+}
 
-			elem = findElem(root, "initial", "foot", suffix)
-			foot := svg.NewGroup(elem, 0, 0)
+// NewCharacter builds the character for a steno chord. Chords with no final keys are drawn with the
+// solo strokes, the number bar has no strokes of its own. An error is returned if the final can not be drawn
+func (f *Font) NewCharacter(chord steno.Chord) (*Character, error) {
+	initial := chord.Initial
+	if chord.Star {
+		initial += "*"
+	}
 
-			group = svg.Merge(stand, foot)
-			initial[name+suffix] = StrokeGroup{
-				cluster: Initial,
-				group:   *group,
-			}
-		}
+	return f.SlotCharacter(chordSlots(initial, initialKeys), chordSlots(chord.Vowel, vowelKeys), chord.Final)
+}
 
-		for _, prefix := range combinations([]string{"0", "1"}) {
-			for _, suffix := range combinations([]string{"8", "9"}) {
-				elem = findElem(root, "initial", "core", name)
-				stand := svg.NewGroup(elem, 0, 0)
+// SlotCharacter builds a character from the template slot names of the initial and vowel strokes
+// (e.g. "0459" and "0123") and the steno name of the final strokes (e.g. "FPLT"). Consonant clusters
+// are drawn at the size class that leaves room for the segments that share the character. An error is
+// returned if the final has an unknown key or the font has no strokes for it
+func (f *Font) SlotCharacter(initial, vowel, final string) (*Character, error) {
+	if final == "" {
+		return &Character{
+			size:           f.metrics.EmSize,
+			initialStrokes: f.consonant(f.soloParts, f.soloStrokes, initial, vowel),
+			vowelStrokes:   f.vowelStrokes[vowel],
+		}, nil
+	}
 
-				elem = findElem(root, "initial", "head", prefix)
-				head := svg.NewGroup(elem, 0, 0)
+	finalStroke, ok := f.Final(final)
+	if !ok {
+		return nil, fmt.Errorf("font has no strokes for the final %s", final)
+	}
+	if slots, _ := finalSlots(final); f.resized(f.finalParts, slots, vowel) {
+		finalStroke, _ = f.finalParts.build(slots, vowel)
+		finalStroke.group.Transform(f.metrics.FinalShift())
+	}
 
-				elem = findElem(root, "initial", "foot", suffix)
-				foot := svg.NewGroup(elem, 0, 0)
+	return &Character{
+		size:           f.metrics.EmSize,
+		initialStrokes: f.consonant(f.initialParts, f.initialStrokes, initial, vowel),
+		vowelStrokes:   f.vowelStrokes[vowel],
+		finalStrokes:   finalStroke,
+	}, nil
+}
 
-				group = svg.Merge(head, stand, foot)
-				initial[prefix+name+suffix] = StrokeGroup{
-					cluster: Initial,
-					group:   *group,
-				}
-			}
-		}
+// consonant returns the strokes for a cluster in a syllable with the vowel slots. The prebuilt strokes
+// are used unless the vowel bars change the size class of the cluster
+func (f *Font) consonant(parts clusterParts, prebuilt map[string]StrokeGroup, slots, vowel string) StrokeGroup {
+	if !f.resized(parts, slots, vowel) {
+		return prebuilt[slots]
 	}
 
-	solo := map[string]StrokeGroup{}
-	for _, name := range combinations([]string{"2", "3", "4", "5", "6", "7"}) {
-		elem := findElem(root, "solos", "tall", name)
-		group := svg.NewGroup(elem, 0, 0)
-		solo[name] = StrokeGroup{
-			cluster: Solo,
-			group:   *group,
-		}
-
-		for _, prefix := range combinations([]string{"0", "1"}) {
-			elem = findElem(root, "solos", "stand", name)
-			stand := svg.NewGroup(elem, 0, 0)
+	group, _ := parts.build(slots, vowel)
+	return group
+}
 
-			elem = findElem(root, "solos", "head", prefix)
-			head := svg.NewGroup(elem, 0, 0)
+// resized reports whether the vowel bars change the size class of a cluster
+func (f *Font) resized(parts clusterParts, slots, vowel string) bool {
+	return sizeClass(parts.taken(segments(slots, vowel))) != sizeClass(parts.taken(segments(slots, "")))
+}
 
-			group = svg.Merge(head, stand)
-			solo[prefix+name] = StrokeGroup{
-				cluster: Solo,
-				group:   *group,
-			}
-		}
+// initialKeys maps the steno initial keys and the asterisk to the template slots they are drawn in
+var initialKeys = map[rune]string{
+	'S': "01", 'T': "2", 'K': "3", 'P': "4",
+	'W': "5", 'H': "6", 'R': "7", '*': "89",
+}
 
-		for _, suffix := range combinations([]string{"8", "9"}) {
-			elem = findElem(root, "solos", "stand", name)
-			stand := svg.NewGroup(elem, 0, -140)
+// vowelKeys maps the steno vowel keys to the template slots they are drawn in
+var vowelKeys = map[rune]string{'A': "0", 'O': "1", 'E': "2", 'U': "3"}
 
-			elem = findElem(root, "solos", "foot", suffix)
-			foot := svg.NewGroup(elem, 0, 0)
+// chordSlots converts the keys of a chord bank into a sorted template slot name
+func chordSlots(keys string, slotMap map[rune]string) string {
+	slots := []byte{}
+	for _, r := range keys {
+		slots = append(slots, slotMap[r]...)
+	}
 
-			group = svg.Merge(stand, foot)
-			solo[name+suffix] = StrokeGroup{
-				cluster: Solo,
-				group:   *group,
-			}
-		}
+	slices.Sort(slots)
+	return string(slices.Compact(slots))
+}
 
-		for _, prefix := range combinations([]string{"0", "1"}) {
-			for _, suffix := range combinations([]string{"8", "9"}) {
-				elem = findElem(root, "solos", "core", name)
-				stand := svg.NewGroup(elem, 0, 0)
+// Final returns the strokes for a final cluster by its steno name (e.g. "FPLT"). The keys can be
+// given in any order, false is returned if the name has an unknown key or the font has no strokes for it
+func (f *Font) Final(name string) (StrokeGroup, bool) {
+	slots, ok := finalSlots(name)
+	if !ok {
+		return StrokeGroup{}, false
+	}
 
-				elem = findElem(root, "solos", "head", prefix)
-				head := svg.NewGroup(elem, 0, 0)
+	group, ok := f.finalStrokes[finalName(slots)]
+	return group, ok
+}
 
-				elem = findElem(root, "solos", "foot", suffix)
-				foot := svg.NewGroup(elem, 0, 0)
+// FinalKeys are the steno final keys in steno order. Each key is drawn in the template slot
+// that matches its index, so R is drawn in slot 0, F in slot 1 and D in slot 9
+const FinalKeys = "RFBPGLSTZD"
+
+// finalSlots converts a steno final name (e.g. "FPLT") into the template slot name (e.g. "1357")
+func finalSlots(name string) (string, bool) {
+	slots := []byte{}
+	for _, r := range name {
+		i := strings.IndexRune(FinalKeys, r)
+		if i < 0 {
+			return "", false
+		}
 
-				group = svg.Merge(head, stand, foot)
-				solo[prefix+name+suffix] = StrokeGroup{
-					cluster: Solo,
-					group:   *group,
-				}
-			}
+		slot := byte('0' + i)
+		if !slices.Contains(slots, slot) {
+			slots = append(slots, slot)
 		}
 	}
 
-	return &Font{
-		initialStrokes: initial,
-		vowelStrokes:   vowels,
-		soloStrokes:    solo,
-	}, nil
+	slices.Sort(slots)
+	return string(slots), true
 }
 
-func (f *Font) NewCharacter(initial, vowel, final string) *Character {
-	if final == "" {
-		return &Character{
-			initialStrokes: f.soloStrokes[initial],
-			vowelStrokes:   f.vowelStrokes[vowel],
+// finalName converts a template slot name (e.g. "1357") into the steno final name (e.g. "FPLT")
+func finalName(slots string) string {
+	name := ""
+	for i, key := range FinalKeys {
+		if strings.ContainsRune(slots, rune('0'+i)) {
+			name += string(key)
 		}
 	}
 
-	finalStroke := f.initialStrokes[final]
-	finalStroke.group.Transform(390)
+	return name
+}
 
-	return &Character{
-		initialStrokes: f.initialStrokes[initial],
-		vowelStrokes:   f.vowelStrokes[vowel],
-		finalStrokes:   finalStroke,
+// Metrics returns the proportions of the font's template
+func (f *Font) Metrics() metrics.FontMetrics {
+	return f.metrics
+}
+
+// loadMetrics reads the metrics from the template's Layout layer, templates without
+// a Layout layer need a json sidecar file next to them
+func loadMetrics(root *svgparser.Element, svgPath string) (metrics.FontMetrics, error) {
+	m, err := metrics.Load(root)
+	if err == nil {
+		return m, nil
+	}
+
+	sidecar := metrics.SidecarPath(svgPath)
+	if _, statErr := os.Stat(sidecar); statErr != nil {
+		return metrics.FontMetrics{}, fmt.Errorf("%s: %w, and there is no %s sidecar file", svgPath, err, sidecar)
 	}
+
+	return metrics.LoadFile(sidecar)
 }
 
 func parseSVG(svgPath string) (*svgparser.Element, error) {
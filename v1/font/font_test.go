@@ -0,0 +1,257 @@
+package font
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bjatkin/silabex/pua"
+	"github.com/bjatkin/silabex/steno"
+)
+
+func TestFont_Final(t *testing.T) {
+	f, err := NewFont("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("NewFont() error = %v", err)
+	}
+
+	if len(f.finalStrokes) != len(f.initialStrokes) {
+		t.Errorf("font has %d finals, want one for each of the %d initials", len(f.finalStrokes), len(f.initialStrokes))
+	}
+
+	tests := []struct {
+		name     string
+		final    string
+		initial  string
+		wantOk   bool
+		wantName string
+	}{
+		{"single key", "B", "2", true, "B"},
+		{"steno order", "FPLT", "1357", true, "FPLT"},
+		{"any order", "TLPF", "1357", true, "FPLT"},
+		{"head and foot", "RBZD", "0289", true, "RBZD"},
+		{"unknown key", "FX", "", false, ""},
+		{"foot only", "D", "9", true, "D"},
+		{"both feet", "ZD", "89", true, "ZD"},
+		{"head only", "RF", "01", true, "RF"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := f.Final(tt.final)
+			if ok != tt.wantOk {
+				t.Fatalf("Final() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if !ok {
+				return
+			}
+
+			if got.SVG() == "" {
+				t.Errorf("Final() has no strokes")
+			}
+			if got.cluster != Final {
+				t.Errorf("Final() cluster = %v, want %v", got.cluster, Final)
+			}
+
+			// finals are the matching initial shifted into the final slot
+			want := f.initialStrokes[tt.initial]
+			want.group.Transform(f.metrics.FinalShift())
+			if got.SVG() != want.SVG() {
+				t.Errorf("Final() = %s, want %s", got.SVG(), want.SVG())
+			}
+
+			if slots, _ := finalSlots(tt.final); finalName(slots) != tt.wantName {
+				t.Errorf("finalName() = %s, want %s", finalName(slots), tt.wantName)
+			}
+		})
+	}
+}
+
+func TestFont_NewCharacter(t *testing.T) {
+	f, err := NewFont("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("NewFont() error = %v", err)
+	}
+
+	char, err := f.NewCharacter(steno.Chord{Initial: "TK", Vowel: "A", Final: "FPLT"})
+	if err != nil {
+		t.Fatalf("NewCharacter() error = %v", err)
+	}
+	final, _ := f.Final("FPLT")
+	if !strings.Contains(char.SVG(), final.SVG()) {
+		t.Errorf("NewCharacter() is missing the FPLT final strokes")
+	}
+	if !strings.Contains(char.SVG(), f.initialStrokes["23"].SVG()) {
+		t.Errorf("NewCharacter() is missing the TK initial strokes")
+	}
+
+	// finals drawn only with head or foot strokes have no core to shift into the final slot
+	for _, final := range []string{"D", "Z", "RF"} {
+		char, err := f.NewCharacter(steno.Chord{Initial: "TK", Vowel: "A", Final: final})
+		if err != nil {
+			t.Fatalf("NewCharacter(TKA%s) error = %v", final, err)
+		}
+		if char.finalStrokes.SVG() == "" {
+			t.Errorf("NewCharacter(TKA%s) has no final strokes", final)
+		}
+	}
+
+	if _, err := f.SlotCharacter("23", "0", "X"); err == nil {
+		t.Errorf("SlotCharacter() error = nil, want an error for an unknown final key")
+	}
+}
+
+func TestFont_Glyphs(t *testing.T) {
+	f, err := NewFont("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("NewFont() error = %v", err)
+	}
+
+	kinds := map[Cluster]pua.Kind{
+		Vowel:   pua.VowelComponent,
+		Solo:    pua.SoloComponent,
+		Initial: pua.InitialComponent,
+		Final:   pua.FinalComponent,
+		Symbol:  pua.Unassigned,
+	}
+
+	glyphs := map[rune]bool{}
+	for _, g := range f.Glyphs() {
+		if kind, _ := pua.Lookup(g.CodePoint); kind != kinds[g.Cluster] {
+			t.Errorf("glyph %s code point %U is a %s, want a %s", g.Name, g.CodePoint, kind, kinds[g.Cluster])
+		}
+		glyphs[g.CodePoint] = true
+	}
+
+	for _, r := range Punctuation + "0123456789" {
+		if !glyphs[r] {
+			t.Errorf("%q has no glyph", r)
+		}
+	}
+
+	for _, stroke := range []string{"KAT", "TKAFPLT", "STKPWHRAO*EUFRPBLGTSDZ", "-T", "TA"} {
+		k, err := steno.ParseKeys(stroke)
+		if err != nil {
+			t.Fatalf("ParseKeys() error = %v", err)
+		}
+
+		runes, err := pua.Decompose(k)
+		if err != nil {
+			t.Fatalf("Decompose() error = %v", err)
+		}
+		for _, r := range runes {
+			if !glyphs[r] {
+				t.Errorf("%s component %U has no glyph", stroke, r)
+			}
+		}
+	}
+}
+
+func TestGlyph_SVG(t *testing.T) {
+	f, err := NewFont("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("NewFont() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"vowel_A", "viewBox=\"0 0 1000 1000\""},
+		{"initial_T", "viewBox=\"-1000 0 1000 1000\""},
+		{"final_T", "viewBox=\"-1000 0 1000 1000\""},
+		{"punct_period", "viewBox=\"0 0 1000 1000\""},
+	}
+
+	glyphs := map[string]Glyph{}
+	for _, g := range f.Glyphs() {
+		glyphs[g.Name] = g
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, ok := glyphs[tt.name]
+			if !ok {
+				t.Fatalf("Glyphs() has no %s", tt.name)
+			}
+
+			got := g.SVG()
+			if !strings.Contains(got, tt.want) || !strings.Contains(got, g.group.SVG()) {
+				t.Errorf("SVG() = %.100s, want %s with the glyph strokes", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFont_Symbol(t *testing.T) {
+	f, err := NewFont("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("NewFont() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		symbol rune
+		wantOk bool
+	}{
+		{"period", '.', true},
+		{"open paren", '(', true},
+		{"digit", '7', true},
+		{"letter", 'a', false},
+		{"hyphen", '-', false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := f.Symbol(tt.symbol)
+			if ok != tt.wantOk {
+				t.Fatalf("Symbol() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if ok && got.Group() == "" {
+				t.Errorf("Symbol() has no strokes")
+			}
+		})
+	}
+}
+
+func TestFont_Digits(t *testing.T) {
+	f, err := NewFont("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("NewFont() error = %v", err)
+	}
+
+	got, ok := f.Digits("19")
+	if !ok || len(got) != 2 {
+		t.Fatalf("Digits(19) = %d characters, %v, want 2, true", len(got), ok)
+	}
+	if one, _ := f.Symbol('1'); got[0].Group() != one.Group() {
+		t.Errorf("Digits(19) first character is not 1")
+	}
+
+	if _, ok := f.Digits("1a"); ok {
+		t.Errorf("Digits(1a) ok = true, want false")
+	}
+}
+
+func TestFont_Mark(t *testing.T) {
+	f, err := NewFont("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("NewFont() error = %v", err)
+	}
+
+	char, err := f.NewCharacter(steno.Chord{Initial: "HR", Vowel: "O"})
+	if err != nil {
+		t.Fatalf("NewCharacter() error = %v", err)
+	}
+	for _, d := range []Diacritic{Capital, Emphasis} {
+		got := f.Mark(char, d)
+		mark := f.diacriticStrokes[d].SVG()
+		if mark == "" || !strings.Contains(got.Group(), mark) || !strings.Contains(got.Group(), char.Group()) {
+			t.Errorf("Mark(%s) is missing the character or the diacritic strokes", d)
+		}
+	}
+
+	if strings.Contains(char.Group(), f.diacriticStrokes[Capital].SVG()) {
+		t.Errorf("Mark() changed the original character")
+	}
+	if got := f.Mark(char, NoDiacritic); got.Group() != char.Group() {
+		t.Errorf("Mark(none) = %s, want %s", got.Group(), char.Group())
+	}
+}
@@ -0,0 +1,130 @@
+package font
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bjatkin/silabex/geom"
+	"github.com/bjatkin/silabex/pua"
+)
+
+// Glyph is a single component of the font. Characters are written as a vowel base followed by
+// an initial (or solo) mark and a final mark, see the pua package. Only vowels advance the pen,
+// marks are drawn back into the character of the vowel in front of them
+type Glyph struct {
+	Name      string
+	Cluster   Cluster
+	CodePoint rune
+	Advance   float64
+	group     StrokeGroup
+	size      float64
+}
+
+// SVG returns the glyph as an svg document the size of the em box. Marks are drawn back into the
+// character in front of them, so the view box of a mark is moved back one em
+func (g Glyph) SVG(opts ...SVGOption) string {
+	x := 0.0
+	if g.Advance == 0 {
+		x = -g.size
+	}
+
+	return strings.Join([]string{
+		fmt.Sprintf("<svg width=\"%[2]g\" height=\"%[2]g\" viewBox=\"%[1]g 0 %[2]g %[2]g\" xmlns=\"http://www.w3.org/2000/svg\">", x, g.size),
+		g.group.SVG(opts...),
+		"</svg>",
+	}, "\n")
+}
+
+// Outline returns the outline of the glyph in template units
+func (g Glyph) Outline() (geom.Path, error) {
+	return g.group.Outline()
+}
+
+// Outline returns the outline of the strokes in template units
+func (s StrokeGroup) Outline() (geom.Path, error) {
+	return s.group.Outline()
+}
+
+// Outline returns the outline of the full character in template units
+func (c Character) Outline() (geom.Path, error) {
+	ret := geom.Path{}
+	for _, s := range c.strokes() {
+		path, err := s.Outline()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, path...)
+	}
+
+	return ret, nil
+}
+
+// Glyphs lists every component in the font with its private use code point. The vowel base with
+// no vowel keys is always included so syllables without vowels still advance the pen. Punctuation
+// marks and digits use their own code points
+func (f *Font) Glyphs() []Glyph {
+	em := f.metrics.EmSize
+	ret := []Glyph{}
+	add := func(cluster Cluster, prefix string, r rune, keys string, group StrokeGroup, advance, dx float64) {
+		group.group.Transform(dx)
+		ret = append(ret, Glyph{
+			Name:      prefix + glyphName(keys),
+			Cluster:   cluster,
+			CodePoint: r,
+			Advance:   advance,
+			group:     group,
+			size:      em,
+		})
+	}
+
+	for i := 0; i < 1<<4; i++ {
+		chord := pua.FromBanks(0, i, 0).Chord()
+		add(Vowel, "vowel_", pua.VowelBase+rune(i), chord.Vowel, f.vowelStrokes[chordSlots(chord.Vowel, vowelKeys)], em, 0)
+	}
+
+	for i := 1; i < 1<<8; i++ {
+		chord := pua.FromBanks(i, 0, 0).Chord()
+		initial := chord.Initial
+		if chord.Star {
+			initial += "*"
+		}
+
+		slots := chordSlots(initial, initialKeys)
+		if group, ok := f.soloStrokes[slots]; ok {
+			add(Solo, "solo_", pua.SoloMark+rune(i), initial, group, 0, -em)
+		}
+		if group, ok := f.initialStrokes[slots]; ok {
+			add(Initial, "initial_", pua.InitialMark+rune(i), initial, group, 0, -em)
+		}
+	}
+
+	for i := 1; i < 1<<10; i++ {
+		chord := pua.FromBanks(0, 0, i).Chord()
+		if group, ok := f.Final(chord.Final); ok {
+			add(Final, "final_", pua.FinalMark+rune(i), chord.Final, group, 0, -em)
+		}
+	}
+
+	for _, r := range Punctuation {
+		if group, ok := f.symbolStrokes[r]; ok {
+			add(Symbol, "punct_", r, punctuationLabels[r], group, em, 0)
+		}
+	}
+
+	for r := '0'; r <= '9'; r++ {
+		if group, ok := f.symbolStrokes[r]; ok {
+			add(Symbol, "digit_", r, string(r), group, em, 0)
+		}
+	}
+
+	return ret
+}
+
+// glyphName turns the keys of a component into a valid glyph name
+func glyphName(keys string) string {
+	if keys == "" {
+		return "none"
+	}
+
+	return strings.ReplaceAll(keys, "*", "_star")
+}
@@ -0,0 +1,273 @@
+package font
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/JoshVarga/svgparser"
+)
+
+// ProblemKind is the kind of mistake the linter found in the template's labels
+type ProblemKind int
+
+const (
+	// MissingLabel is a group the font needs that is not in the template
+	MissingLabel ProblemKind = iota
+	// DuplicateLabel is a label used by more than one group with the same parent, only the first is read
+	DuplicateLabel
+	// MisnamedLabel is a label that is close to a missing label, e.g. "32" instead of "23"
+	MisnamedLabel
+	// UnexpectedLabel is a label the font does not read
+	UnexpectedLabel
+)
+
+func (k ProblemKind) String() string {
+	switch k {
+	case MissingLabel:
+		return "missing"
+	case DuplicateLabel:
+		return "duplicate"
+	case MisnamedLabel:
+		return "misnamed"
+	default:
+		return "unexpected"
+	}
+}
+
+// Problem is a mistake in the labels of the template
+type Problem struct {
+	Kind ProblemKind
+	// Path is the labels of the parent groups, e.g. "initial/stand"
+	Path string
+	// Label is the label of the element, or the label that is missing
+	Label string
+	// ID is the id of the element with the problem, missing labels use the id of the parent group
+	ID string
+	// Want is the label a misnamed element should have, or the id of the first element of a duplicate
+	Want string
+}
+
+func (p Problem) String() string {
+	at := p.Label
+	if p.Path != "" {
+		at = p.Path + "/" + p.Label
+	}
+
+	switch p.Kind {
+	case MissingLabel:
+		return fmt.Sprintf("%s: missing, add it to #%s", at, p.ID)
+	case DuplicateLabel:
+		return fmt.Sprintf("%s: duplicate label on #%s, only #%s is used", at, p.ID, p.Want)
+	case MisnamedLabel:
+		return fmt.Sprintf("%s: #%s should be labeled %q", at, p.ID, p.Want)
+	default:
+		return fmt.Sprintf("%s: unexpected label on #%s", at, p.ID)
+	}
+}
+
+// schema is a labeled group the font reads from the template
+type schema struct {
+	label string
+	// optional groups are not reported when they are missing
+	optional bool
+	// open groups do not have their children checked
+	open     bool
+	children []schema
+}
+
+// leaves creates a schema for each of the labels, the labels hold strokes so their children are not checked
+func leaves(labels []string, optional bool) []schema {
+	ret := []schema{}
+	for _, label := range labels {
+		ret = append(ret, schema{label: label, optional: optional, open: true})
+	}
+
+	return ret
+}
+
+// consonantSchema is the layout of the initial and solos layers
+func consonantSchema(layer string) schema {
+	ret := schema{label: layer}
+	for _, size := range SizeClasses {
+		// null is an empty placeholder some templates keep at the top of the size class
+		children := append(leaves(combinations([]string{"2", "3", "4", "5", "6", "7"}), false), leaves([]string{"null"}, true)...)
+		ret.children = append(ret.children, schema{label: size.label(), children: children})
+	}
+	ret.children = append(ret.children,
+		schema{label: "head", children: leaves(combinations([]string{"0", "1"}), false)},
+		schema{label: "foot", children: leaves(combinations([]string{"8", "9"}), false)},
+	)
+
+	return ret
+}
+
+// templateSchema is every group NewFont reads from the template
+func templateSchema() schema {
+	punctuation := []string{}
+	for _, r := range Punctuation {
+		punctuation = append(punctuation, punctuationLabels[r])
+	}
+	digits := []string{}
+	for r := '0'; r <= '9'; r++ {
+		digits = append(digits, string(r))
+	}
+
+	return schema{children: []schema{
+		{label: "vowels", children: leaves(combinations([]string{"0", "1", "2", "3"}), false)},
+		consonantSchema("initial"),
+		consonantSchema("solos"),
+		{label: "marks", optional: true, children: []schema{
+			{label: "punctuation", optional: true, children: leaves(punctuation, true)},
+			{label: "digits", optional: true, children: leaves(digits, true)},
+			{label: "diacritics", optional: true, children: leaves([]string{Capital.String(), Emphasis.String()}, true)},
+		}},
+		// the metrics package checks the Layout layer when the template is loaded
+		{label: "Layout", optional: true, open: true},
+	}}
+}
+
+// Lint checks the labels of the template against the groups the font reads. Missing, duplicate,
+// misnamed and unexpected labels are reported in the order they are found in the template
+func Lint(svgPath string) ([]Problem, error) {
+	root, err := parseSVG(svgPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return lint(root), nil
+}
+
+func lint(root *svgparser.Element) []Problem {
+	return lintGroup(root, templateSchema(), "")
+}
+
+// lintGroup checks the labeled children of elem against the schema and then checks each child
+func lintGroup(elem *svgparser.Element, s schema, path string) []Problem {
+	want := map[string]schema{}
+	for _, child := range s.children {
+		want[child.label] = child
+	}
+
+	found := map[string]*svgparser.Element{}
+	unknown := []*svgparser.Element{}
+	ret := []Problem{}
+	for _, child := range elem.Children {
+		label, ok := child.Attributes["label"]
+		if !ok {
+			continue
+		}
+
+		if first, ok := found[label]; ok {
+			ret = append(ret, Problem{Kind: DuplicateLabel, Path: path, Label: label, ID: child.Attributes["id"], Want: first.Attributes["id"]})
+			continue
+		}
+		if _, ok := want[label]; !ok {
+			unknown = append(unknown, child)
+			continue
+		}
+		found[label] = child
+	}
+
+	missing := []string{}
+	for _, child := range s.children {
+		if _, ok := found[child.label]; !ok {
+			missing = append(missing, child.label)
+		}
+	}
+
+	for _, child := range unknown {
+		label := child.Attributes["label"]
+		if name := closestLabel(label, missing); name != "" {
+			ret = append(ret, Problem{Kind: MisnamedLabel, Path: path, Label: label, ID: child.Attributes["id"], Want: name})
+			missing = slices.DeleteFunc(missing, func(m string) bool { return m == name })
+			continue
+		}
+		ret = append(ret, Problem{Kind: UnexpectedLabel, Path: path, Label: label, ID: child.Attributes["id"]})
+	}
+
+	for _, label := range missing {
+		if !want[label].optional {
+			ret = append(ret, Problem{Kind: MissingLabel, Path: path, Label: label, ID: elem.Attributes["id"]})
+		}
+	}
+
+	for _, child := range s.children {
+		elem, ok := found[child.label]
+		if !ok || child.open {
+			continue
+		}
+		ret = append(ret, lintGroup(elem, child, strings.TrimPrefix(path+"/"+child.label, "/"))...)
+	}
+
+	return ret
+}
+
+// closestLabel returns the missing label that the label was most likely meant to be. Labels match
+// if they only differ by case, spaces or the order of their slots, or if a word label is within two
+// edits of the missing label. An empty string is returned if no label is close enough
+func closestLabel(label string, missing []string) string {
+	norm := normalizeLabel(label)
+	for _, name := range missing {
+		if norm == normalizeLabel(name) {
+			return name
+		}
+	}
+
+	// slot names that differ by a single slot are different strokes, so only words are compared
+	if isSlotName(norm) {
+		return ""
+	}
+
+	best, bestDist := "", 3
+	for _, name := range missing {
+		if isSlotName(name) {
+			continue
+		}
+		if dist := editDistance(norm, strings.ToLower(name)); dist < bestDist {
+			best, bestDist = name, dist
+		}
+	}
+
+	return best
+}
+
+// normalizeLabel lowercases the label, removes its spaces and sorts the slots of slot names
+func normalizeLabel(label string) string {
+	label = strings.ToLower(strings.Join(strings.Fields(label), ""))
+	if isSlotName(label) {
+		slots := []byte(label)
+		slices.Sort(slots)
+		label = string(slots)
+	}
+
+	return label
+}
+
+// isSlotName reports whether the label is made of slot numbers
+func isSlotName(label string) bool {
+	return label != "" && strings.Trim(label, "0123456789") == ""
+}
+
+// editDistance is the number of single character inserts, deletes and changes needed to turn a into b
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,112 @@
+package font
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/JoshVarga/svgparser"
+)
+
+func TestLint(t *testing.T) {
+	problems, err := Lint("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	if len(problems) != 0 {
+		t.Errorf("Lint() = %v, want the reference template to have no problems", problems)
+	}
+
+	if _, err := Lint("missing.svg"); err == nil {
+		t.Errorf("Lint() error = nil, want an error for a missing file")
+	}
+}
+
+func TestLint_Problems(t *testing.T) {
+	// relabel finds the element at the labels and changes its label, an empty label removes it
+	relabel := func(root *svgparser.Element, label string, names ...string) {
+		elem := findElem(root, names...)
+		if elem == nil {
+			t.Fatalf("findElem(%v) = nil", names)
+		}
+		elem.Attributes["label"] = label
+		if label == "" {
+			delete(elem.Attributes, "label")
+		}
+	}
+	group := func(id, label string) *svgparser.Element {
+		return &svgparser.Element{Name: "g", Attributes: map[string]string{"id": id, "label": label}}
+	}
+
+	tests := []struct {
+		name   string
+		change func(root *svgparser.Element)
+		want   []string
+	}{
+		{
+			name:   "missing",
+			change: func(root *svgparser.Element) { relabel(root, "", "initial", "stand", "23") },
+			want:   []string{"initial/stand/23: missing, add it to #g1106"},
+		},
+		{
+			name:   "misnamed slots",
+			change: func(root *svgparser.Element) { relabel(root, "32", "solos", "core", "23") },
+			want:   []string{"solos/core/32: #g749 should be labeled \"23\""},
+		},
+		{
+			name:   "misnamed word",
+			change: func(root *svgparser.Element) { relabel(root, "Vowel", "vowels") },
+			want:   []string{"Vowel: #layer11 should be labeled \"vowels\""},
+		},
+		{
+			name: "duplicate",
+			change: func(root *svgparser.Element) {
+				head := findElem(root, "initial", "head")
+				head.Children = append(head.Children, group("g1", "01"))
+			},
+			want: []string{"initial/head/01: duplicate label on #g1, only #layer19 is used"},
+		},
+		{
+			name: "unexpected",
+			change: func(root *svgparser.Element) {
+				marks := findElem(root, "marks", "punctuation")
+				marks.Children = append(marks.Children, group("g1", "tilde"), &svgparser.Element{Name: "path"})
+			},
+			want: []string{"marks/punctuation/tilde: unexpected label on #g1"},
+		},
+		{
+			name: "slots are not guessed",
+			change: func(root *svgparser.Element) {
+				relabel(root, "", "initial", "foot", "89")
+				foot := findElem(root, "initial", "foot")
+				foot.Children = append(foot.Children, group("g1", "7"))
+			},
+			want: []string{
+				"initial/foot/7: unexpected label on #g1",
+				"initial/foot/89: missing, add it to #layer75",
+			},
+		},
+		{
+			name:   "optional groups",
+			change: func(root *svgparser.Element) { relabel(root, "", "marks") },
+			want:   []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := parseSVG("../reference/font2.svg")
+			if err != nil {
+				t.Fatalf("parseSVG() error = %v", err)
+			}
+			tt.change(root)
+
+			got := []string{}
+			for _, p := range lint(root) {
+				got = append(got, p.String())
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("lint() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package font
+
+import (
+	"strings"
+
+	"github.com/JoshVarga/svgparser"
+	"github.com/bjatkin/silabex/svg"
+)
+
+// Diacritic is a mark drawn over a syllable that changes how its word is written
+type Diacritic int
+
+const (
+	NoDiacritic Diacritic = iota
+	// Capital marks the first syllable of a capitalized word
+	Capital
+	// Emphasis marks the first syllable of a word written in upper case
+	Emphasis
+)
+
+func (d Diacritic) String() string {
+	switch d {
+	case NoDiacritic:
+		return "none"
+	case Capital:
+		return "capital"
+	case Emphasis:
+		return "emphasis"
+	default:
+		return "?"
+	}
+}
+
+// Punctuation are the punctuation marks the font has glyphs for
+const Punctuation = ".,;:!?'\"()"
+
+// punctuationLabels are the labels of the punctuation groups in the template's marks layer
+var punctuationLabels = map[rune]string{
+	'.': "period", ',': "comma", ';': "semicolon", ':': "colon", '!': "exclamation",
+	'?': "question", '\'': "apostrophe", '"': "quote", '(': "open_paren", ')': "close_paren",
+}
+
+// loadMarks reads the punctuation, digit and diacritic strokes from the template's marks layer.
+// Templates without a marks layer, or without some of its groups, leave those marks out of the font
+func loadMarks(root *svgparser.Element) (map[rune]StrokeGroup, map[Diacritic]StrokeGroup) {
+	symbols := map[rune]StrokeGroup{}
+	for r, label := range punctuationLabels {
+		if elem := findElem(root, "marks", "punctuation", label); elem != nil {
+			symbols[r] = StrokeGroup{cluster: Symbol, group: *svg.NewGroup(elem, 0, 0)}
+		}
+	}
+
+	for r := '0'; r <= '9'; r++ {
+		if elem := findElem(root, "marks", "digits", string(r)); elem != nil {
+			symbols[r] = StrokeGroup{cluster: Symbol, group: *svg.NewGroup(elem, 0, 0)}
+		}
+	}
+
+	diacritics := map[Diacritic]StrokeGroup{}
+	for _, d := range []Diacritic{Capital, Emphasis} {
+		if elem := findElem(root, "marks", "diacritics", d.String()); elem != nil {
+			diacritics[d] = StrokeGroup{cluster: Mark, group: *svg.NewGroup(elem, 0, 0)}
+		}
+	}
+
+	return symbols, diacritics
+}
+
+// Symbol returns the character for a punctuation mark or a digit, false is returned if the
+// font has no strokes for it
+func (f *Font) Symbol(r rune) (*Character, bool) {
+	group, ok := f.symbolStrokes[r]
+	if !ok {
+		return nil, false
+	}
+
+	return &Character{
+		size:          f.metrics.EmSize,
+		symbolStrokes: group,
+	}, true
+}
+
+// Digits returns the characters for a number written with the number bar (e.g. "19")
+func (f *Font) Digits(number string) ([]*Character, bool) {
+	ret := []*Character{}
+	for _, r := range number {
+		if !strings.ContainsRune("0123456789", r) {
+			return nil, false
+		}
+
+		char, ok := f.Symbol(r)
+		if !ok {
+			return nil, false
+		}
+		ret = append(ret, char)
+	}
+
+	return ret, true
+}
+
+// Diacritic returns the strokes for a diacritic, false is returned if the font has no strokes for it
+func (f *Font) Diacritic(d Diacritic) (StrokeGroup, bool) {
+	group, ok := f.diacriticStrokes[d]
+	return group, ok
+}
+
+// Mark returns a copy of the character with the diacritic drawn over it. The character is returned
+// unchanged if the font has no strokes for the diacritic
+func (f *Font) Mark(c *Character, d Diacritic) *Character {
+	group, ok := f.Diacritic(d)
+	if !ok {
+		return c
+	}
+
+	ret := *c
+	ret.markStrokes = group
+	return &ret
+}
@@ -0,0 +1,255 @@
+package font
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/JoshVarga/svgparser"
+	"github.com/bjatkin/silabex/geom"
+	"github.com/bjatkin/silabex/metrics"
+	"github.com/bjatkin/silabex/svg"
+)
+
+// SizeClass is the height a consonant cluster is drawn at. Smaller classes leave room for the head
+// and foot strokes, and for vowel bars that reach into the consonant slot
+type SizeClass int
+
+const (
+	// Full clusters fill the head, core and foot of the slot, they are the tall strokes in the template
+	Full SizeClass = iota
+	// TwoThirds clusters share the slot with a head or a foot, they are the stand strokes in the template
+	TwoThirds
+	// Half clusters share the slot with both a head and a foot, they are the core strokes in the template
+	Half
+)
+
+// SizeClasses lists every size class from the largest to the smallest
+var SizeClasses = []SizeClass{Full, TwoThirds, Half}
+
+func (s SizeClass) String() string {
+	switch s {
+	case Full:
+		return "full"
+	case TwoThirds:
+		return "2/3"
+	case Half:
+		return "1/2"
+	default:
+		return "?"
+	}
+}
+
+// label is the name of the template group the size class is drawn in
+func (s SizeClass) label() string {
+	switch s {
+	case Full:
+		return "tall"
+	case TwoThirds:
+		return "stand"
+	default:
+		return "core"
+	}
+}
+
+// Segments are the parts of a glyph that share the consonant slot with a cluster
+type Segments struct {
+	Head bool
+	Foot bool
+	// TopBar and BottomBar are set when the vowel has a bar along the top or bottom of the vowel box
+	TopBar    bool
+	BottomBar bool
+}
+
+// Size chooses the size class for a cluster in the slot. A vowel bar takes up the head or foot of the
+// slot when its box in the metrics overlaps it, so dense syllables shrink the cluster rather than letting
+// the strokes collide
+func Size(m metrics.FontMetrics, slot metrics.Slot, s Segments) SizeClass {
+	return sizeClass(taken(slot, s, []geom.Rect{m.TopBar()}, []geom.Rect{m.BottomBar()}))
+}
+
+// sizeClass is the size class of a cluster that shares the slot with a head or foot
+func sizeClass(head, foot bool) SizeClass {
+	switch {
+	case head && foot:
+		return Half
+	case head || foot:
+		return TwoThirds
+	default:
+		return Full
+	}
+}
+
+// taken reports whether the head and foot of the slot are used by other segments of the glyph. A
+// vowel bar takes the head or foot when any of its boxes overlaps it
+func taken(slot metrics.Slot, s Segments, top, bottom []geom.Rect) (bool, bool) {
+	head := s.Head || (s.TopBar && slices.ContainsFunc(top, slot.Head.Overlaps))
+	foot := s.Foot || (s.BottomBar && slices.ContainsFunc(bottom, slot.Foot.Overlaps))
+
+	return head, foot
+}
+
+// segments returns the head and foot segments of a template slot name (e.g. "0459") and the vowel
+// bars of a vowel slot name (e.g. "12")
+func segments(slots, vowel string) Segments {
+	return Segments{
+		Head:      strings.ContainsAny(slots, "01"),
+		Foot:      strings.ContainsAny(slots, "89"),
+		TopBar:    strings.Contains(vowel, "2"),
+		BottomBar: strings.Contains(vowel, "1"),
+	}
+}
+
+// clusterParts are the strokes consonant clusters are built from. Every size class of the core
+// strokes is kept so a cluster can be drawn at any size
+type clusterParts struct {
+	cluster Cluster
+	metrics metrics.FontMetrics
+	slot    metrics.Slot
+	sizes   map[SizeClass]map[string]*svgparser.Element
+	heads   map[string]*svgparser.Element
+	feet    map[string]*svgparser.Element
+	// hangs are stand strokes drawn at the top of the slot. The template does not draw them, they are only
+	// set when the rules build them, otherwise the stand strokes are moved up
+	hangs map[string]*svgparser.Element
+	// topBar and bottomBar are the bounds of the vowel bar strokes, they are empty when the bars are not drawn
+	topBar    geom.Rect
+	bottomBar geom.Rect
+}
+
+// taken reports whether the head and foot of the slot are used by the segments. The strokes drawn for
+// the vowel bars are checked as well as their boxes in the metrics, so a template can draw a bar that
+// reaches further into the slot than the metrics say
+func (p clusterParts) taken(seg Segments) (bool, bool) {
+	return taken(p.slot, seg, []geom.Rect{p.metrics.TopBar(), p.topBar}, []geom.Rect{p.metrics.BottomBar(), p.bottomBar})
+}
+
+// loadParts reads the size classes, heads and feet from a consonant layer of the template
+func loadParts(root *svgparser.Element, layer string, cluster Cluster, m metrics.FontMetrics, slot metrics.Slot) clusterParts {
+	parts := clusterParts{
+		cluster: cluster,
+		metrics: m,
+		slot:    slot,
+		sizes:   map[SizeClass]map[string]*svgparser.Element{},
+		heads:   map[string]*svgparser.Element{},
+		feet:    map[string]*svgparser.Element{},
+		hangs:   map[string]*svgparser.Element{},
+	}
+
+	for _, size := range SizeClasses {
+		parts.sizes[size] = map[string]*svgparser.Element{}
+		for _, name := range combinations([]string{"2", "3", "4", "5", "6", "7"}) {
+			parts.sizes[size][name] = findElem(root, layer, size.label(), name)
+		}
+	}
+	for _, name := range combinations([]string{"2", "3", "4", "5", "6", "7"}) {
+		parts.hangs[name] = nil
+	}
+
+	for _, name := range combinations([]string{"0", "1"}) {
+		parts.heads[name] = findElem(root, layer, "head", name)
+	}
+	for _, name := range combinations([]string{"8", "9"}) {
+		parts.feet[name] = findElem(root, layer, "foot", name)
+	}
+
+	return parts
+}
+
+// copyAs returns a copy of the parts for another cluster. The maps are copied, so setting a part in
+// the copy does not change p
+func (p clusterParts) copyAs(cluster Cluster) clusterParts {
+	p.cluster = cluster
+	p.sizes = maps.Clone(p.sizes)
+	for size, parts := range p.sizes {
+		p.sizes[size] = maps.Clone(parts)
+	}
+	p.heads = maps.Clone(p.heads)
+	p.feet = maps.Clone(p.feet)
+	p.hangs = maps.Clone(p.hangs)
+
+	return p
+}
+
+// splitSlots splits a template slot name into its head, core and foot slots
+func splitSlots(slots string) (string, string, string) {
+	head, core, foot := "", "", ""
+	for _, r := range slots {
+		switch {
+		case r == '0' || r == '1':
+			head += string(r)
+		case r == '8' || r == '9':
+			foot += string(r)
+		default:
+			core += string(r)
+		}
+	}
+
+	return head, core, foot
+}
+
+// build draws the cluster for a template slot name in a syllable with the vowel slots. The size
+// class leaves room for the head and foot the segments take, a two thirds cluster hangs from the top
+// of the slot when the foot is taken. Clusters with no core slots are drawn with only their head and
+// foot strokes. False is returned for slot names the parts can not draw
+func (p clusterParts) build(slots, vowel string) (StrokeGroup, bool) {
+	head, core, foot := splitSlots(slots)
+	headTaken, footTaken := p.taken(segments(slots, vowel))
+	size := sizeClass(headTaken, footTaken)
+
+	groups := []*svg.Group{}
+	if head != "" {
+		elem, ok := p.heads[head]
+		if !ok {
+			return StrokeGroup{}, false
+		}
+		groups = append(groups, svg.NewGroup(elem, 0, 0))
+	}
+	if core != "" {
+		elem, ok := p.sizes[size][core]
+		if !ok {
+			return StrokeGroup{}, false
+		}
+
+		dy := 0.0
+		if size == TwoThirds && !headTaken {
+			dy = -p.slot.HangShift()
+			if hang := p.hangs[core]; hang != nil {
+				elem, dy = hang, 0
+			}
+		}
+		groups = append(groups, svg.NewGroup(elem, 0, dy))
+	}
+	if foot != "" {
+		elem, ok := p.feet[foot]
+		if !ok {
+			return StrokeGroup{}, false
+		}
+		groups = append(groups, svg.NewGroup(elem, 0, 0))
+	}
+
+	if len(groups) == 0 {
+		return StrokeGroup{}, false
+	}
+
+	return StrokeGroup{
+		cluster: p.cluster,
+		group:   *svg.Merge(groups...),
+	}, true
+}
+
+// all builds every cluster the parts can make without vowel bars in the slot
+func (p clusterParts) all() map[string]StrokeGroup {
+	ret := map[string]StrokeGroup{}
+	for _, core := range append([]string{""}, combinations([]string{"2", "3", "4", "5", "6", "7"})...) {
+		for _, head := range append([]string{""}, combinations([]string{"0", "1"})...) {
+			for _, foot := range append([]string{""}, combinations([]string{"8", "9"})...) {
+				if group, ok := p.build(head+core+foot, ""); ok {
+					ret[head+core+foot] = group
+				}
+			}
+		}
+	}
+
+	return ret
+}
@@ -0,0 +1,142 @@
+package font
+
+import (
+	"testing"
+
+	"github.com/bjatkin/silabex/geom"
+	"github.com/bjatkin/silabex/metrics"
+	"github.com/bjatkin/silabex/svg"
+)
+
+// tightMetrics are metrics where the vowel bars reach into the head and foot of the consonant slots
+func tightMetrics() metrics.FontMetrics {
+	m := metrics.Default()
+	m.Vowel = geom.NewRect(20, 100, 960, 800)
+	m.VowelBar = 80
+
+	return m
+}
+
+func TestSize(t *testing.T) {
+	tests := []struct {
+		name     string
+		metrics  metrics.FontMetrics
+		segments Segments
+		want     SizeClass
+	}{
+		{"alone", metrics.Default(), Segments{}, Full},
+		{"head", metrics.Default(), Segments{Head: true}, TwoThirds},
+		{"foot", metrics.Default(), Segments{Foot: true}, TwoThirds},
+		{"head and foot", metrics.Default(), Segments{Head: true, Foot: true}, Half},
+		{"bars outside the slot", metrics.Default(), Segments{TopBar: true, BottomBar: true}, Full},
+		{"top bar in the head", tightMetrics(), Segments{TopBar: true}, TwoThirds},
+		{"bottom bar in the foot", tightMetrics(), Segments{BottomBar: true}, TwoThirds},
+		{"both bars", tightMetrics(), Segments{TopBar: true, BottomBar: true}, Half},
+		{"head and top bar", tightMetrics(), Segments{Head: true, TopBar: true}, TwoThirds},
+		{"head and bottom bar", tightMetrics(), Segments{Head: true, BottomBar: true}, Half},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Size(tt.metrics, tt.metrics.Consonant, tt.segments); got != tt.want {
+				t.Errorf("Size() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFont_SlotCharacter_size(t *testing.T) {
+	f, err := NewFont("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("NewFont() error = %v", err)
+	}
+
+	// the reference template leaves room for the vowel bars so they never change the size class
+	if got, _ := f.SlotCharacter("4", "12", ""); got.initialStrokes.SVG() != f.soloStrokes["4"].SVG() {
+		t.Errorf("SlotCharacter() = %s, want the full solo strokes", got.initialStrokes.SVG())
+	}
+
+	f.metrics = tightMetrics()
+	f.soloParts.metrics = f.metrics
+	f.initialParts.metrics = f.metrics
+	f.finalParts.metrics = f.metrics
+
+	// want draws the core strokes of a slot name at a size class, hanging from the top of the slot
+	want := func(parts clusterParts, core string, size SizeClass, hang bool) string {
+		dy := 0.0
+		if hang {
+			dy = -parts.slot.HangShift()
+		}
+		return svg.NewGroup(parts.sizes[size][core], 0, dy).SVG()
+	}
+
+	tests := []struct {
+		name        string
+		initial     string
+		vowel       string
+		final       string
+		wantInitial string
+		wantFinal   string
+	}{
+		{"no bars", "4", "03", "", want(f.soloParts, "4", Full, false), ""},
+		{"top bar", "4", "2", "", want(f.soloParts, "4", TwoThirds, false), ""},
+		{"bottom bar", "4", "1", "", want(f.soloParts, "4", TwoThirds, true), ""},
+		{"both bars", "45", "12", "", want(f.soloParts, "45", Half, false), ""},
+		{"final", "45", "1", "B", want(f.initialParts, "45", TwoThirds, true), "B"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := f.SlotCharacter(tt.initial, tt.vowel, tt.final)
+			if err != nil {
+				t.Fatalf("SlotCharacter() error = %v", err)
+			}
+			if got.initialStrokes.SVG() != tt.wantInitial {
+				t.Errorf("SlotCharacter() initial = %s, want %s", got.initialStrokes.SVG(), tt.wantInitial)
+			}
+
+			if tt.wantFinal == "" {
+				return
+			}
+			slots, _ := finalSlots(tt.wantFinal)
+			wantFinal, _ := f.finalParts.build(slots, tt.vowel)
+			wantFinal.group.Transform(f.metrics.FinalShift())
+			if got.finalStrokes.SVG() != wantFinal.SVG() {
+				t.Errorf("SlotCharacter() final = %s, want %s", got.finalStrokes.SVG(), wantFinal.SVG())
+			}
+			if prebuilt, _ := f.Final(tt.wantFinal); got.finalStrokes.SVG() == prebuilt.SVG() {
+				t.Errorf("SlotCharacter() final was not resized")
+			}
+		})
+	}
+}
+
+func TestFont_SlotCharacter_drawnBars(t *testing.T) {
+	f, err := NewFont("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("NewFont() error = %v", err)
+	}
+
+	// the reference bars stay clear of the head and foot, so the cluster is only resized once the top
+	// bar is drawn lower than the box the metrics give it
+	if got, _ := f.SlotCharacter("4", "2", ""); got.initialStrokes.SVG() != f.soloStrokes["4"].SVG() {
+		t.Errorf("SlotCharacter() = %s, want the full solo strokes", got.initialStrokes.SVG())
+	}
+
+	f.vowelParts["2"].Attributes["transform"] = "translate(0 100)"
+	f.build()
+
+	got, err := f.SlotCharacter("4", "2", "B")
+	if err != nil {
+		t.Fatalf("SlotCharacter() error = %v", err)
+	}
+	if want := svg.NewGroup(f.initialParts.sizes[TwoThirds]["4"], 0, 0).SVG(); got.initialStrokes.SVG() != want {
+		t.Errorf("SlotCharacter() initial = %s, want the stand strokes %s", got.initialStrokes.SVG(), want)
+	}
+	if prebuilt, _ := f.Final("B"); got.finalStrokes.SVG() == prebuilt.SVG() {
+		t.Errorf("SlotCharacter() final was not resized")
+	}
+
+	// the bottom bar is still clear of the foot
+	if got, _ := f.SlotCharacter("4", "1", ""); got.initialStrokes.SVG() != f.soloStrokes["4"].SVG() {
+		t.Errorf("SlotCharacter() = %s, want the full solo strokes", got.initialStrokes.SVG())
+	}
+}
@@ -0,0 +1,242 @@
+package font
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bjatkin/silabex/geom"
+	"github.com/bjatkin/silabex/metrics"
+)
+
+// skeleton writes the groups of an empty template
+type skeleton struct {
+	m            metrics.FontMetrics
+	placeholders bool
+	lines        []string
+}
+
+// Skeleton returns an empty inkscape template for a new font. It has every labeled group NewFont reads,
+// a Layout layer and guides drawn from the metrics, so the template loads and lints before any strokes
+// are drawn. When placeholders is set each vowel, core, head and foot group is filled with a block for
+// every slot in its name, marks are always left empty
+func Skeleton(m metrics.FontMetrics, placeholders bool) (string, error) {
+	if err := m.Validate(); err != nil {
+		return "", err
+	}
+
+	s := &skeleton{m: m, placeholders: placeholders}
+	s.add(0,
+		`<?xml version="1.0" encoding="UTF-8" standalone="no"?>`,
+		`<!-- generated by silabex skeleton -->`,
+		fmt.Sprintf(`<svg width="%[1]g" height="%[1]g" viewBox="0 0 %[1]g %[1]g" version="1.1" id="svg1"`, m.EmSize),
+		`   xmlns:inkscape="http://www.inkscape.org/namespaces/inkscape"`,
+		`   xmlns:sodipodi="http://sodipodi.sourceforge.net/DTD/sodipodi-0.dtd"`,
+		`   xmlns="http://www.w3.org/2000/svg"`,
+		`   xmlns:svg="http://www.w3.org/2000/svg">`,
+	)
+	s.guides()
+
+	for _, child := range templateSchema().children {
+		if child.label == "Layout" {
+			s.layout()
+			continue
+		}
+		s.group(child, []string{child.label})
+	}
+	s.add(0, "</svg>")
+
+	return strings.Join(s.lines, "\n") + "\n", nil
+}
+
+// add writes the lines indented to the depth of the element
+func (s *skeleton) add(depth int, lines ...string) {
+	for _, line := range lines {
+		s.lines = append(s.lines, strings.Repeat("  ", depth)+line)
+	}
+}
+
+// group writes a labeled group and its children. Groups that hold strokes are hidden, like the
+// reference template, so a single group can be shown while it is drawn
+func (s *skeleton) group(g schema, path []string) {
+	depth := len(path)
+	display := "inline"
+	if g.open {
+		display = "none"
+	}
+
+	s.add(depth, fmt.Sprintf(`<g inkscape:groupmode="layer" id="%s" inkscape:label="%s" style="display:%s">`, strings.Join(path, "-"), g.label, display))
+	for _, child := range g.children {
+		// null is only kept by older templates, new templates leave it out
+		if child.label == "null" {
+			continue
+		}
+		s.group(child, append(append([]string{}, path...), child.label))
+	}
+	if g.open && s.placeholders {
+		for i, box := range s.slots(path) {
+			s.add(depth+1, rect(fmt.Sprintf("%s-%d", strings.Join(path, "-"), i), "", box, "fill:#000000;fill-opacity:0.25;stroke:none"))
+		}
+	}
+	s.add(depth, "</g>")
+}
+
+// slots returns a block for every slot in the name of the group at the end of the path, groups
+// without slots return no blocks. Only the vowels, initial and solos layers are drawn in slots, the
+// digits in the marks layer are named with the same numbers
+func (s *skeleton) slots(path []string) []geom.Rect {
+	name := path[len(path)-1]
+	if !isSlotName(name) {
+		return nil
+	}
+
+	switch path[0] {
+	case "vowels":
+		v, bar := s.m.Vowel, s.m.VowelBar
+		bars := map[rune]geom.Rect{
+			'0': geom.NewRect(v.Min.X, v.Min.Y, bar, v.Height()),
+			'1': s.m.BottomBar(),
+			'2': s.m.TopBar(),
+			'3': geom.NewRect(v.Max.X-bar, v.Min.Y, bar, v.Height()),
+		}
+
+		ret := []geom.Rect{}
+		for _, r := range name {
+			ret = append(ret, bars[r])
+		}
+		return ret
+	case "initial", "solos":
+	default:
+		return nil
+	}
+
+	slot := s.m.Consonant
+	if path[0] == "solos" {
+		slot = s.m.Solo
+	}
+
+	// the head and foot have a slot on each side, the core has three rows of two slots
+	box, first, rows := slot.Core, '2', 3
+	switch path[1] {
+	case "head":
+		box, first, rows = slot.Head, '0', 1
+	case "foot":
+		box, first, rows = slot.Foot, '8', 1
+	case Full.label():
+		box = geom.Rect{Min: geom.Point{X: slot.Core.Min.X, Y: slot.Head.Min.Y}, Max: geom.Point{X: slot.Core.Max.X, Y: slot.Foot.Max.Y}}
+	case TwoThirds.label():
+		box = geom.Rect{Min: slot.Core.Min, Max: geom.Point{X: slot.Core.Max.X, Y: slot.Foot.Max.Y}}
+	}
+
+	w, h := box.Width()/2, box.Height()/float64(rows)
+	inset := 0.1 * min(w, h)
+	ret := []geom.Rect{}
+	for _, r := range name {
+		i := int(r - first)
+		x, y := box.Min.X+float64(i%2)*w, box.Min.Y+float64(i/2)*h
+		ret = append(ret, geom.NewRect(x+inset, y+inset, w-2*inset, h-2*inset))
+	}
+
+	return ret
+}
+
+// layout writes the Layout layer that metrics.Load reads the metrics back from. The layer is locked
+// so the boxes are not moved by accident while strokes are drawn over them
+func (s *skeleton) layout() {
+	v, bar := s.m.Vowel, s.m.VowelBar
+	s.add(1, `<g inkscape:groupmode="layer" id="Layout" inkscape:label="Layout" style="display:inline" sodipodi:insensitive="true">`)
+	s.add(2, `<g inkscape:groupmode="layer" id="Layout-vowel" inkscape:label="vowel" style="display:inline">`)
+	s.add(3, fmt.Sprintf(`<path id="Layout-vowel-inner" inkscape:label="inner" style="fill:#f68a55;fill-opacity:0.08;stroke:none" d="M %g,%g V %g H %g V %g Z M %g,%g H %g V %g H %g Z" />`,
+		v.Min.X, v.Min.Y, v.Max.Y, v.Max.X, v.Min.Y,
+		v.Min.X+bar, v.Min.Y+bar, v.Max.X-bar, v.Max.Y-bar, v.Min.X+bar,
+	))
+	s.add(2, "</g>")
+
+	slots := []struct {
+		label string
+		slot  metrics.Slot
+	}{
+		{"consonant", s.m.Consonant},
+		{"solo", s.m.Solo},
+	}
+	for _, slot := range slots {
+		id := "Layout-" + slot.label
+		s.add(2, fmt.Sprintf(`<g inkscape:groupmode="layer" id="%s" inkscape:label="%s" style="display:inline">`, id, slot.label))
+		boxes := []struct {
+			label string
+			box   geom.Rect
+			fill  string
+		}{
+			{"core", slot.slot.Core, "#ffffff"},
+			{"head", slot.slot.Head, "#55e997"},
+			{"foot", slot.slot.Foot, "#55e997"},
+			{"head_center", slot.slot.HeadCenter, "#55f668"},
+			{"foot_center", slot.slot.FootCenter, "#55f668"},
+		}
+		for _, b := range boxes {
+			s.add(3, rect(id+"-"+b.label, b.label, b.box, "fill:"+b.fill+";fill-opacity:0.08;stroke:none"))
+		}
+		s.add(2, "</g>")
+	}
+	s.add(1, "</g>")
+}
+
+// guides writes the named view with locked guides along the edges of the vowel box, the vowel bars
+// and the boxes of the initial, final and solo slots
+func (s *skeleton) guides() {
+	m := s.m
+	type guide struct {
+		label string
+		x, y  float64
+	}
+	vertical := []guide{
+		{"vowel left", m.Vowel.Min.X, 0},
+		{"vowel left bar", m.Vowel.Min.X + m.VowelBar, 0},
+		{"vowel right bar", m.Vowel.Max.X - m.VowelBar, 0},
+		{"vowel right", m.Vowel.Max.X, 0},
+		{"initial left", m.Consonant.Core.Min.X, 0},
+		{"initial right", m.Consonant.Core.Max.X, 0},
+		{"final left", m.Consonant.Core.Min.X + m.FinalShift(), 0},
+		{"final right", m.Consonant.Core.Max.X + m.FinalShift(), 0},
+		{"solo left", m.Solo.Core.Min.X, 0},
+		{"solo right", m.Solo.Core.Max.X, 0},
+	}
+	horizontal := []guide{
+		{"vowel top", 0, m.Vowel.Min.Y},
+		{"vowel top bar", 0, m.Vowel.Min.Y + m.VowelBar},
+		{"vowel bottom bar", 0, m.Vowel.Max.Y - m.VowelBar},
+		{"vowel bottom", 0, m.Vowel.Max.Y},
+		{"head top", 0, m.Consonant.Head.Min.Y},
+		{"head bottom", 0, m.Consonant.Head.Max.Y},
+		{"core top", 0, m.Consonant.Core.Min.Y},
+		{"core bottom", 0, m.Consonant.Core.Max.Y},
+		{"foot top", 0, m.Consonant.Foot.Min.Y},
+		{"foot bottom", 0, m.Consonant.Foot.Max.Y},
+	}
+
+	s.add(1, `<sodipodi:namedview id="namedview1" pagecolor="#505050" bordercolor="#eeeeee" inkscape:deskcolor="#505050" showguides="true" inkscape:lockguides="true">`)
+	id := 1
+	// inkscape measures guide positions up from the bottom of the page
+	for _, list := range []struct {
+		guides      []guide
+		orientation string
+	}{
+		{vertical, "-1,0"},
+		{horizontal, "0,1"},
+	} {
+		for _, g := range list.guides {
+			s.add(2, fmt.Sprintf(`<sodipodi:guide id="guide%d" inkscape:label="%s" position="%g,%g" orientation="%s" inkscape:locked="true" />`, id, g.label, g.x, m.EmSize-g.y, list.orientation))
+			id++
+		}
+	}
+	s.add(1, "</sodipodi:namedview>")
+}
+
+// rect is an svg rect element that covers the box, unlabeled rects pass an empty label
+func rect(id, label string, box geom.Rect, style string) string {
+	attrs := fmt.Sprintf(`id="%s"`, id)
+	if label != "" {
+		attrs += fmt.Sprintf(` inkscape:label="%s"`, label)
+	}
+
+	return fmt.Sprintf(`<rect %s x="%g" y="%g" width="%g" height="%g" style="%s" />`, attrs, box.Min.X, box.Min.Y, box.Width(), box.Height(), style)
+}
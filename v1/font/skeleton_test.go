@@ -0,0 +1,148 @@
+package font
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JoshVarga/svgparser"
+	"github.com/bjatkin/silabex/geom"
+	"github.com/bjatkin/silabex/metrics"
+	"github.com/bjatkin/silabex/svg"
+)
+
+func TestSkeleton(t *testing.T) {
+	wide := metrics.Default()
+	wide.EmSize = 1200
+	wide.Vowel = geom.NewRect(20, 20, 1160, 960)
+
+	bad := metrics.Default()
+	bad.VowelBar = 0
+
+	tests := []struct {
+		name         string
+		metrics      metrics.FontMetrics
+		placeholders bool
+		wantErr      bool
+		// want is the status every stroke should have in the coverage of the skeleton
+		want Status
+	}{
+		{"empty", metrics.Default(), false, false, Missing},
+		{"placeholders", metrics.Default(), true, false, Explicit},
+		{"wide placeholders", wide, true, false, Explicit},
+		{"bad metrics", bad, false, true, Missing},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Skeleton(tt.metrics, tt.placeholders)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Skeleton() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			path := filepath.Join(t.TempDir(), "skeleton.svg")
+			if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			problems, err := Lint(path)
+			if err != nil || len(problems) != 0 {
+				t.Errorf("Lint() = %v, %v, want no problems", problems, err)
+			}
+
+			f, err := NewFont(path)
+			if err != nil {
+				t.Fatalf("NewFont() error = %v", err)
+			}
+			if f.Metrics() != tt.metrics {
+				t.Errorf("Metrics() = %+v, want %+v", f.Metrics(), tt.metrics)
+			}
+
+			count := f.Coverage(nil).Count()
+			if count[tt.want] != 63*6+15 {
+				t.Errorf("Coverage() = %v, want every stroke to be %v", count, tt.want)
+			}
+
+			if !strings.Contains(got, `inkscape:label="vowel left bar"`) {
+				t.Errorf("Skeleton() has no guide for the left vowel bar")
+			}
+		})
+	}
+}
+
+func TestSkeleton_placeholders(t *testing.T) {
+	m := metrics.Default()
+	got, err := Skeleton(m, true)
+	if err != nil {
+		t.Fatalf("Skeleton() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "skeleton.svg")
+	if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root, err := parseSVG(path)
+	if err != nil {
+		t.Fatalf("parseSVG() error = %v", err)
+	}
+
+	// each slot in the name is an inset block in the box the group is drawn in
+	tests := []struct {
+		names []string
+		want  geom.Rect
+	}{
+		{[]string{"vowels", "0"}, geom.NewRect(20, 20, 80, 960)},
+		{[]string{"vowels", "12"}, geom.NewRect(20, 20, 960, 960)},
+		{[]string{"initial", "tall", "234567"}, geom.NewRect(156.5, 166.5, 297, 667)},
+		{[]string{"initial", "core", "2"}, geom.NewRect(154, 304, 137, 112)},
+		{[]string{"solos", "stand", "67"}, geom.NewRect(158.67, 682, 682.67, 149.33)},
+		{[]string{"solos", "head", "01"}, geom.NewRect(148, 158, 704, 64)},
+		{[]string{"initial", "foot", "9"}, geom.NewRect(313, 778, 149, 64)},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.names, "/"), func(t *testing.T) {
+			elem := findElem(root, tt.names...)
+			if elem == nil {
+				t.Fatalf("findElem() = nil")
+			}
+
+			got, err := svg.NewGroup(elem, 0, 0).Bounds()
+			if err != nil {
+				t.Fatalf("Bounds() error = %v", err)
+			}
+			if !nearRect(got, tt.want) {
+				t.Errorf("Bounds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// the digits are named like slots but are not drawn in one
+	var blocks func(elem *svgparser.Element) int
+	blocks = func(elem *svgparser.Element) int {
+		n := 0
+		for _, child := range elem.Children {
+			if child.Name == "rect" {
+				n++
+			}
+			n += blocks(child)
+		}
+		return n
+	}
+	marks := findElem(root, "marks")
+	if marks == nil {
+		t.Fatalf("findElem() = nil, want the marks layer")
+	}
+	if n := blocks(marks); n != 0 {
+		t.Errorf("Skeleton() drew %d blocks in the marks layer, want none", n)
+	}
+}
+
+func nearRect(a, b geom.Rect) bool {
+	near := func(x, y float64) bool { return x-y < 0.1 && y-x < 0.1 }
+	return near(a.Min.X, b.Min.X) && near(a.Min.Y, b.Min.Y) && near(a.Max.X, b.Max.X) && near(a.Max.Y, b.Max.Y)
+}
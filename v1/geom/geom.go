@@ -0,0 +1,177 @@
+package geom
+
+import (
+	"math"
+
+	"github.com/bjatkin/silabex/linalg"
+)
+
+// Point is a 2d point
+type Point struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// Add returns the sum of p and q
+func (p Point) Add(q Point) Point {
+	return Point{X: p.X + q.X, Y: p.Y + q.Y}
+}
+
+// Sub returns the difference of p and q
+func (p Point) Sub(q Point) Point {
+	return Point{X: p.X - q.X, Y: p.Y - q.Y}
+}
+
+// Mul scales p by s
+func (p Point) Mul(s float64) Point {
+	return Point{X: p.X * s, Y: p.Y * s}
+}
+
+// Lerp returns the point t of the way from p to q
+func (p Point) Lerp(q Point, t float64) Point {
+	return p.Add(q.Sub(p).Mul(t))
+}
+
+// Dist returns the distance between p and q
+func (p Point) Dist(q Point) float64 {
+	return math.Hypot(q.X-p.X, q.Y-p.Y)
+}
+
+// Transform applies the affine transform m to p
+func (p Point) Transform(m linalg.Mat3x) Point {
+	v := linalg.VecMul(m, linalg.NewPoint2(p.X, p.Y))
+	return Point{X: v.X, Y: v.Y}
+}
+
+type Op int
+
+const (
+	LineTo Op = iota
+	QuadTo
+	CubicTo
+)
+
+// Segment is a single piece of a contour. The segment starts at the end of the previous segment
+// and ends at its last point, any points before that are control points
+type Segment struct {
+	Op     Op
+	Points [3]Point
+}
+
+// Line creates a straight segment that ends at p
+func Line(p Point) Segment {
+	return Segment{Op: LineTo, Points: [3]Point{p}}
+}
+
+// Quad creates a quadratic bezier segment with control point c that ends at p
+func Quad(c, p Point) Segment {
+	return Segment{Op: QuadTo, Points: [3]Point{c, p}}
+}
+
+// Cubic creates a cubic bezier segment with control points c1 and c2 that ends at p
+func Cubic(c1, c2, p Point) Segment {
+	return Segment{Op: CubicTo, Points: [3]Point{c1, c2, p}}
+}
+
+// End returns the point the segment ends at
+func (s Segment) End() Point {
+	switch s.Op {
+	case QuadTo:
+		return s.Points[1]
+	case CubicTo:
+		return s.Points[2]
+	default:
+		return s.Points[0]
+	}
+}
+
+// Contour is a connected series of segments
+type Contour struct {
+	Start    Point
+	Segments []Segment
+	Closed   bool
+}
+
+// Path is a set of contours that make up a single shape
+type Path []Contour
+
+// Transform returns a copy of the path with the affine transform m applied to every point.
+// Affine transforms map bezier curves onto bezier curves so the control points can be transformed directly
+func (p Path) Transform(m linalg.Mat3x) Path {
+	ret := Path{}
+	for _, c := range p {
+		contour := Contour{
+			Start:  c.Start.Transform(m),
+			Closed: c.Closed,
+		}
+
+		for _, s := range c.Segments {
+			for i := range s.Points {
+				s.Points[i] = s.Points[i].Transform(m)
+			}
+			contour.Segments = append(contour.Segments, s)
+		}
+
+		ret = append(ret, contour)
+	}
+
+	return ret
+}
+
+// QuadApprox returns a copy of the path where every cubic segment has been replaced by quadratic
+// segments that stay within tolerance of the original curve
+func (p Path) QuadApprox(tolerance float64) Path {
+	ret := Path{}
+	for _, c := range p {
+		contour := Contour{
+			Start:  c.Start,
+			Closed: c.Closed,
+		}
+
+		start := c.Start
+		for _, s := range c.Segments {
+			if s.Op != CubicTo {
+				contour.Segments = append(contour.Segments, s)
+				start = s.End()
+				continue
+			}
+
+			contour.Segments = append(contour.Segments, cubicToQuads(start, s.Points[0], s.Points[1], s.Points[2], tolerance, 0)...)
+			start = s.End()
+		}
+
+		ret = append(ret, contour)
+	}
+
+	return ret
+}
+
+// cubicToQuads approximates a cubic bezier with quadratics by splitting it in half until a single
+// quadratic is close enough to each piece
+func cubicToQuads(p0, c1, c2, p3 Point, tolerance float64, depth int) []Segment {
+	// the best single quadratic control point is the average of the two points the cubic
+	// control points predict, the distance between them bounds the error of the approximation
+	q1 := c1.Mul(3).Sub(p0).Mul(0.5)
+	q2 := c2.Mul(3).Sub(p3).Mul(0.5)
+	if q1.Dist(q2)*math.Sqrt(3)/18 <= tolerance || depth > 8 {
+		return []Segment{Quad(q1.Lerp(q2, 0.5), p3)}
+	}
+
+	l1, l2, mid, r1, r2 := splitCubic(p0, c1, c2, p3, 0.5)
+	return append(
+		cubicToQuads(p0, l1, l2, mid, tolerance, depth+1),
+		cubicToQuads(mid, r1, r2, p3, tolerance, depth+1)...,
+	)
+}
+
+// splitCubic splits a cubic bezier at t and returns the control points of both halves
+func splitCubic(p0, c1, c2, p3 Point, t float64) (l1, l2, mid, r1, r2 Point) {
+	a := p0.Lerp(c1, t)
+	b := c1.Lerp(c2, t)
+	c := c2.Lerp(p3, t)
+	l2 = a.Lerp(b, t)
+	r1 = b.Lerp(c, t)
+	mid = l2.Lerp(r1, t)
+
+	return a, l2, mid, r1, c
+}
@@ -0,0 +1,140 @@
+package geom
+
+import "math"
+
+// polygon is a flattened contour, fills close every contour so the last point joins the first
+type polygon []Point
+
+// flatten flattens every contour of the path into a polygon
+func (p Path) flatten(tolerance float64) []polygon {
+	ret := []polygon{}
+	for _, c := range p {
+		if points := c.Flatten(tolerance); len(points) > 1 {
+			ret = append(ret, points)
+		}
+	}
+
+	return ret
+}
+
+// edges calls fn with every edge of the polygon until it returns true
+func (p polygon) edges(fn func(a, b Point) bool) bool {
+	for i := range p {
+		if fn(p[i], p[(i+1)%len(p)]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// windingNumber returns the winding number of the polygons around pt
+func windingNumber(polygons []polygon, pt Point) int {
+	ret := 0
+	for _, poly := range polygons {
+		poly.edges(func(a, b Point) bool {
+			switch {
+			case a.Y <= pt.Y && b.Y > pt.Y && cross(b.Sub(a), pt.Sub(a)) > 0:
+				ret++
+			case a.Y > pt.Y && b.Y <= pt.Y && cross(b.Sub(a), pt.Sub(a)) < 0:
+				ret--
+			}
+			return false
+		})
+	}
+
+	return ret
+}
+
+// Contains reports whether the point is inside the filled path. Paths are filled with the nonzero
+// rule and curves are flattened to within tolerance
+func (p Path) Contains(pt Point, tolerance float64) bool {
+	return windingNumber(p.flatten(tolerance), pt) != 0
+}
+
+// Intersects reports whether the filled shapes of the paths overlap. The bounding boxes are checked
+// first, then the flattened edges are checked for crossings and each shape is checked for a point
+// inside the other. Shapes that only touch along an edge or at a corner do not intersect
+func (p Path) Intersects(q Path, tolerance float64) bool {
+	pb, ok := p.Bounds()
+	if !ok {
+		return false
+	}
+	qb, ok := q.Bounds()
+	if !ok || !pb.Overlaps(qb) {
+		return false
+	}
+
+	pp, qp := p.flatten(tolerance), q.flatten(tolerance)
+	for _, a := range pp {
+		for _, b := range qp {
+			crossed := a.edges(func(a1, a2 Point) bool {
+				return b.edges(func(b1, b2 Point) bool {
+					return crosses(a1, a2, b1, b2)
+				})
+			})
+			if crossed {
+				return true
+			}
+		}
+	}
+
+	// with no crossing edges the shapes are either apart or one is inside the other. The corners and
+	// edge midpoints are sampled, a shape with its whole outline on the other's outline is the same shape
+	inside := func(polygons []polygon, other []polygon) bool {
+		for _, poly := range polygons {
+			onOutline := true
+			found := poly.edges(func(a, b Point) bool {
+				for _, pt := range []Point{a, a.Lerp(b, 0.5)} {
+					if onEdge(other, pt) {
+						continue
+					}
+					onOutline = false
+					if windingNumber(other, pt) != 0 {
+						return true
+					}
+				}
+				return false
+			})
+			if found || onOutline {
+				return true
+			}
+		}
+		return false
+	}
+
+	return inside(pp, qp) || inside(qp, pp)
+}
+
+// crosses reports whether the segments a1 a2 and b1 b2 cross at a single point inside both of them
+func crosses(a1, a2, b1, b2 Point) bool {
+	if max(a1.X, a2.X) < min(b1.X, b2.X) || max(b1.X, b2.X) < min(a1.X, a2.X) ||
+		max(a1.Y, a2.Y) < min(b1.Y, b2.Y) || max(b1.Y, b2.Y) < min(a1.Y, a2.Y) {
+		return false
+	}
+
+	d1 := cross(a2.Sub(a1), b1.Sub(a1))
+	d2 := cross(a2.Sub(a1), b2.Sub(a1))
+	d3 := cross(b2.Sub(b1), a1.Sub(b1))
+	d4 := cross(b2.Sub(b1), a2.Sub(b1))
+
+	return d1*d2 < 0 && d3*d4 < 0
+}
+
+// onEdge reports whether pt lies on an edge of the polygons
+func onEdge(polygons []polygon, pt Point) bool {
+	const epsilon = 1e-9
+	for _, poly := range polygons {
+		found := poly.edges(func(a, b Point) bool {
+			if math.Abs(cross(b.Sub(a), pt.Sub(a))) > epsilon*max(1, a.Dist(b)) {
+				return false
+			}
+			return dot(pt.Sub(a), pt.Sub(b)) <= epsilon
+		})
+		if found {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,81 @@
+package geom
+
+import "testing"
+
+func square(x, y, size float64) Path {
+	return polyline(true, Point{X: x, Y: y}, Point{X: x + size, Y: y}, Point{X: x + size, Y: y + size}, Point{X: x, Y: y + size})
+}
+
+func TestPath_Intersects(t *testing.T) {
+	// frame is a square with a square hole, the inner contour winds the other way
+	frame := append(square(0, 0, 100), polyline(true, Point{X: 20, Y: 20}, Point{X: 20, Y: 80}, Point{X: 80, Y: 80}, Point{X: 80, Y: 20})...)
+
+	tests := []struct {
+		name string
+		p    Path
+		q    Path
+		want bool
+	}{
+		{"overlapping squares", square(0, 0, 10), square(5, 5, 10), true},
+		{"apart", square(0, 0, 10), square(20, 0, 10), false},
+		{"touching edge", square(0, 0, 10), square(10, 0, 10), false},
+		{"touching corner", square(0, 0, 10), square(10, 10, 10), false},
+		{"inside", square(0, 0, 10), square(2, 2, 2), true},
+		{"same shape", square(0, 0, 10), square(0, 0, 10), true},
+		{"in the hole of a frame", frame, square(30, 30, 10), false},
+		{"across a frame", frame, square(10, 40, 20), true},
+		{"inside a frame bar", frame, square(5, 40, 10), true},
+		{
+			"curve bulging into a square",
+			Path{{
+				Start:    Point{X: 0, Y: 0},
+				Segments: []Segment{Quad(Point{X: 20, Y: 5}, Point{X: 0, Y: 10})},
+				Closed:   true,
+			}},
+			square(8, 0, 10),
+			true,
+		},
+		{
+			"curve short of a square",
+			Path{{
+				Start:    Point{X: 0, Y: 0},
+				Segments: []Segment{Quad(Point{X: 10, Y: 5}, Point{X: 0, Y: 10})},
+				Closed:   true,
+			}},
+			square(8, 0, 10),
+			false,
+		},
+		{"empty", Path{}, square(0, 0, 10), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.p.Intersects(tt.q, 0.01); got != tt.want {
+				t.Errorf("Intersects() = %v, want %v", got, tt.want)
+			}
+			if got := tt.q.Intersects(tt.p, 0.01); got != tt.want {
+				t.Errorf("Intersects() reversed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPath_Contains(t *testing.T) {
+	frame := append(square(0, 0, 100), polyline(true, Point{X: 20, Y: 20}, Point{X: 20, Y: 80}, Point{X: 80, Y: 80}, Point{X: 80, Y: 20})...)
+
+	tests := []struct {
+		name string
+		pt   Point
+		want bool
+	}{
+		{"in a bar", Point{X: 10, Y: 50}, true},
+		{"in the hole", Point{X: 50, Y: 50}, false},
+		{"outside", Point{X: 150, Y: 50}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := frame.Contains(tt.pt, 0.01); got != tt.want {
+				t.Errorf("Contains() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
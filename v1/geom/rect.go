@@ -0,0 +1,148 @@
+package geom
+
+import "math"
+
+// Rect is an axis aligned rectangle
+type Rect struct {
+	Min Point `json:"min"`
+	Max Point `json:"max"`
+}
+
+// NewRect creates a rectangle from its top left corner and its size
+func NewRect(x, y, width, height float64) Rect {
+	return Rect{
+		Min: Point{X: x, Y: y},
+		Max: Point{X: x + width, Y: y + height},
+	}
+}
+
+// Width returns the width of the rectangle
+func (r Rect) Width() float64 {
+	return r.Max.X - r.Min.X
+}
+
+// Height returns the height of the rectangle
+func (r Rect) Height() float64 {
+	return r.Max.Y - r.Min.Y
+}
+
+// Center returns the point in the middle of the rectangle
+func (r Rect) Center() Point {
+	return r.Min.Lerp(r.Max, 0.5)
+}
+
+// Empty reports whether the rectangle has no area
+func (r Rect) Empty() bool {
+	return r.Max.X <= r.Min.X || r.Max.Y <= r.Min.Y
+}
+
+// Union returns the smallest rectangle that contains both rectangles
+func (r Rect) Union(s Rect) Rect {
+	return Rect{
+		Min: Point{X: math.Min(r.Min.X, s.Min.X), Y: math.Min(r.Min.Y, s.Min.Y)},
+		Max: Point{X: math.Max(r.Max.X, s.Max.X), Y: math.Max(r.Max.Y, s.Max.Y)},
+	}
+}
+
+// Overlaps reports whether the rectangles share any area, rectangles that only touch do not overlap
+func (r Rect) Overlaps(s Rect) bool {
+	return r.Min.X < s.Max.X && s.Min.X < r.Max.X && r.Min.Y < s.Max.Y && s.Min.Y < r.Max.Y
+}
+
+// extend grows the rectangle to include p
+func (r Rect) extend(p Point) Rect {
+	return r.Union(Rect{Min: p, Max: p})
+}
+
+// Bounds returns the smallest rectangle that contains the path. Curves are bounded by their
+// extreme points rather than their control points, false is returned for an empty path
+func (p Path) Bounds() (Rect, bool) {
+	if len(p) == 0 {
+		return Rect{}, false
+	}
+
+	r := Rect{Min: p[0].Start, Max: p[0].Start}
+	for _, c := range p {
+		r = r.extend(c.Start)
+
+		start := c.Start
+		for _, s := range c.Segments {
+			r = r.extend(s.End())
+			for _, t := range s.extremes(start) {
+				r = r.extend(s.At(start, t))
+			}
+			start = s.End()
+		}
+	}
+
+	return r, true
+}
+
+// At returns the point t of the way along the segment, start is the end of the previous segment
+func (s Segment) At(start Point, t float64) Point {
+	switch s.Op {
+	case QuadTo:
+		return start.Lerp(s.Points[0], t).Lerp(s.Points[0].Lerp(s.Points[1], t), t)
+	case CubicTo:
+		_, _, p, _, _ := splitCubic(start, s.Points[0], s.Points[1], s.Points[2], t)
+		return p
+	default:
+		return start.Lerp(s.Points[0], t)
+	}
+}
+
+// extremes returns the values of t between 0 and 1 where the segment turns around in x or y
+func (s Segment) extremes(start Point) []float64 {
+	ret := []float64{}
+	add := func(ts ...float64) {
+		for _, t := range ts {
+			if t > 0 && t < 1 {
+				ret = append(ret, t)
+			}
+		}
+	}
+
+	axis := func(p Point, x bool) float64 {
+		if x {
+			return p.X
+		}
+		return p.Y
+	}
+
+	for _, x := range []bool{true, false} {
+		switch s.Op {
+		case QuadTo:
+			p0, c, p1 := axis(start, x), axis(s.Points[0], x), axis(s.Points[1], x)
+			if den := p0 - 2*c + p1; den != 0 {
+				add((p0 - c) / den)
+			}
+		case CubicTo:
+			p0, c1, c2, p3 := axis(start, x), axis(s.Points[0], x), axis(s.Points[1], x), axis(s.Points[2], x)
+			// the derivative divided by 3 is a t^2 + b t + c
+			a := -p0 + 3*c1 - 3*c2 + p3
+			b := 2 * (p0 - 2*c1 + c2)
+			c := c1 - p0
+			add(quadraticRoots(a, b, c)...)
+		}
+	}
+
+	return ret
+}
+
+// quadraticRoots returns the real roots of a t^2 + b t + c
+func quadraticRoots(a, b, c float64) []float64 {
+	if math.Abs(a) < 1e-12 {
+		if b == 0 {
+			return nil
+		}
+		return []float64{-c / b}
+	}
+
+	disc := b*b - 4*a*c
+	if disc < 0 {
+		return nil
+	}
+
+	sq := math.Sqrt(disc)
+	return []float64{(-b + sq) / (2 * a), (-b - sq) / (2 * a)}
+}
@@ -0,0 +1,91 @@
+package geom
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPath_Bounds(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   Path
+		want   Rect
+		wantOk bool
+	}{
+		{
+			"empty",
+			Path{},
+			Rect{},
+			false,
+		},
+		{
+			"lines",
+			polyline(true, Point{X: 10, Y: 20}, Point{X: 30, Y: 5}, Point{X: -5, Y: 15}),
+			Rect{Min: Point{X: -5, Y: 5}, Max: Point{X: 30, Y: 20}},
+			true,
+		},
+		{
+			"cubic extremes",
+			Path{{
+				Start:    Point{X: 0, Y: 0},
+				Segments: []Segment{Cubic(Point{X: 0, Y: 10}, Point{X: 10, Y: 10}, Point{X: 10, Y: 0})},
+			}},
+			Rect{Min: Point{X: 0, Y: 0}, Max: Point{X: 10, Y: 7.5}},
+			true,
+		},
+		{
+			"quad extremes",
+			Path{{
+				Start:    Point{X: 0, Y: 0},
+				Segments: []Segment{Quad(Point{X: 5, Y: -10}, Point{X: 10, Y: 0})},
+			}},
+			Rect{Min: Point{X: 0, Y: -5}, Max: Point{X: 10, Y: 0}},
+			true,
+		},
+		{
+			"two contours",
+			append(polyline(false, Point{X: 0, Y: 0}, Point{X: 1, Y: 1}), polyline(false, Point{X: 5, Y: 5}, Point{X: 6, Y: 4})...),
+			Rect{Min: Point{X: 0, Y: 0}, Max: Point{X: 6, Y: 5}},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.path.Bounds()
+			if ok != tt.wantOk {
+				t.Fatalf("Bounds() ok = %v, want %v", ok, tt.wantOk)
+			}
+
+			near := func(a, b Point) bool {
+				return math.Abs(a.X-b.X) < 1e-9 && math.Abs(a.Y-b.Y) < 1e-9
+			}
+			if !near(got.Min, tt.want.Min) || !near(got.Max, tt.want.Max) {
+				t.Errorf("Bounds() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRect_Overlaps(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Rect
+		s    Rect
+		want bool
+	}{
+		{"overlap", NewRect(0, 0, 10, 10), NewRect(5, 5, 10, 10), true},
+		{"inside", NewRect(0, 0, 10, 10), NewRect(2, 2, 2, 2), true},
+		{"touching", NewRect(0, 0, 10, 10), NewRect(10, 0, 10, 10), false},
+		{"apart", NewRect(0, 0, 10, 10), NewRect(0, 20, 10, 10), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.Overlaps(tt.s); got != tt.want {
+				t.Errorf("Overlaps() = %v, want %v", got, tt.want)
+			}
+			if got := tt.s.Overlaps(tt.r); got != tt.want {
+				t.Errorf("Overlaps() reversed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
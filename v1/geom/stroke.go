@@ -0,0 +1,299 @@
+package geom
+
+import "math"
+
+// Join is the shape drawn where two segments of a stroked contour meet
+type Join int
+
+const (
+	MiterJoin Join = iota
+	RoundJoin
+	BevelJoin
+)
+
+// Cap is the shape drawn at the ends of an open stroked contour
+type Cap int
+
+const (
+	ButtCap Cap = iota
+	RoundCap
+	SquareCap
+)
+
+// StrokeStyle describes how a centerline path is stroked, it follows the svg stroke properties
+type StrokeStyle struct {
+	Width float64
+	Join  Join
+	Cap   Cap
+	// MiterLimit is the max ratio of the miter length to the stroke width before
+	// a miter join falls back to a bevel join, zero uses the svg default of 4
+	MiterLimit float64
+}
+
+// Flatten returns the points of a polyline that stays within tolerance of the contour.
+// The first point is the start of the contour, closed contours do not repeat the start point
+func (c Contour) Flatten(tolerance float64) []Point {
+	points := []Point{c.Start}
+	add := func(p Point) {
+		if p != points[len(points)-1] {
+			points = append(points, p)
+		}
+	}
+
+	start := c.Start
+	for _, s := range c.Segments {
+		switch s.Op {
+		case QuadTo:
+			// the error of n uniform lines is bounded by max|B''| / (8 n^2)
+			curve := start.Sub(s.Points[0].Mul(2)).Add(s.Points[1]).Mul(2)
+			n := pieces(math.Hypot(curve.X, curve.Y), tolerance)
+			for i := 1; i <= n; i++ {
+				add(s.At(start, float64(i)/float64(n)))
+			}
+		case CubicTo:
+			a := start.Sub(s.Points[0].Mul(2)).Add(s.Points[1])
+			b := s.Points[0].Sub(s.Points[1].Mul(2)).Add(s.Points[2])
+			n := pieces(6*math.Max(math.Hypot(a.X, a.Y), math.Hypot(b.X, b.Y)), tolerance)
+			for i := 1; i <= n; i++ {
+				add(s.At(start, float64(i)/float64(n)))
+			}
+		default:
+			add(s.Points[0])
+		}
+		start = s.End()
+	}
+
+	if c.Closed && len(points) > 1 && points[0] == points[len(points)-1] {
+		points = points[:len(points)-1]
+	}
+
+	return points
+}
+
+// pieces returns the number of lines needed to flatten a curve with the given max second derivative
+func pieces(curve, tolerance float64) int {
+	if tolerance <= 0 {
+		tolerance = 0.1
+	}
+
+	return max(1, int(math.Ceil(math.Sqrt(curve/(8*tolerance)))))
+}
+
+// Stroke returns the outline of the path stroked with the given style. Curves are flattened to within
+// tolerance before they are offset. Open contours become a single closed contour and closed contours
+// become an outer and an inner contour with opposite directions, so the result fills correctly with
+// the nonzero fill rule
+func (p Path) Stroke(style StrokeStyle, tolerance float64) Path {
+	if style.Width <= 0 {
+		return Path{}
+	}
+
+	if style.MiterLimit == 0 {
+		style.MiterLimit = 4
+	}
+
+	ret := Path{}
+	for _, c := range p {
+		points := c.Flatten(tolerance)
+		switch {
+		case len(points) == 1:
+			if dot, ok := style.zeroLength(points[0]); ok {
+				ret = append(ret, dot)
+			}
+		case c.Closed && len(points) > 2:
+			ret = append(ret,
+				style.offset(points, true),
+				style.offset(reversed(points), true),
+			)
+		default:
+			outline := style.offset(points, false)
+			b := &contourBuilder{contour: outline}
+			style.cap(b, points[len(points)-1], points[len(points)-1].Sub(points[len(points)-2]))
+
+			back := style.offset(reversed(points), false)
+			b.lineTo(back.Start)
+			b.contour.Segments = append(b.contour.Segments, back.Segments...)
+			style.cap(b, points[0], points[0].Sub(points[1]))
+
+			b.contour.Closed = true
+			ret = append(ret, b.contour)
+		}
+	}
+
+	return ret
+}
+
+// zeroLength is the dot drawn for a zero length contour, butt caps draw nothing
+func (s StrokeStyle) zeroLength(p Point) (Contour, bool) {
+	d := s.Width / 2
+	b := &contourBuilder{contour: Contour{Start: p.Add(Point{X: d}), Closed: true}}
+
+	switch s.Cap {
+	case RoundCap:
+		b.arc(p, 2*math.Pi)
+	case SquareCap:
+		b.lineTo(p.Add(Point{X: d, Y: d}))
+		b.lineTo(p.Add(Point{X: -d, Y: d}))
+		b.lineTo(p.Add(Point{X: -d, Y: -d}))
+		b.lineTo(p.Add(Point{X: d, Y: -d}))
+	default:
+		return Contour{}, false
+	}
+
+	return b.contour, true
+}
+
+// offset offsets the polyline to its left side (a quarter turn counter clockwise from its direction
+// in a y up coordinate system). Joins on the inside of a turn pivot through the vertex, which leaves
+// overlapping loops that the nonzero fill rule covers
+func (s StrokeStyle) offset(points []Point, closed bool) Contour {
+	d := s.Width / 2
+
+	normals := []Point{}
+	segments := len(points) - 1
+	if closed {
+		segments = len(points)
+	}
+	for i := 0; i < segments; i++ {
+		normals = append(normals, normal(points[(i+1)%len(points)].Sub(points[i]), d))
+	}
+
+	b := &contourBuilder{contour: Contour{Start: points[0].Add(normals[0]), Closed: closed}}
+	for i := 0; i < segments; i++ {
+		end := points[(i+1)%len(points)]
+		b.lineTo(end.Add(normals[i]))
+		if i+1 < segments || closed {
+			s.join(b, end, normals[i], normals[(i+1)%segments])
+		}
+	}
+
+	return b.contour
+}
+
+// join connects the offset of one segment to the offset of the next segment at vertex p
+func (s StrokeStyle) join(b *contourBuilder, p, from, to Point) {
+	d := s.Width / 2
+	turn := cross(from, to)
+
+	switch {
+	case from.Dist(to) < 1e-9*d:
+		return
+	case turn > 0:
+		// the inside of the turn
+		b.lineTo(p)
+		b.lineTo(p.Add(to))
+		return
+	}
+
+	switch s.Join {
+	case RoundJoin:
+		angle := math.Atan2(turn, dot(from, to))
+		if turn == 0 {
+			// a full reversal goes around the front of the segment that ends at p
+			angle = -math.Pi
+		}
+		b.arc(p, angle)
+	case MiterJoin:
+		m := from.Add(to)
+		length := m.X*m.X + m.Y*m.Y
+		if length > 0 && 2*d/math.Sqrt(length) <= s.MiterLimit {
+			b.lineTo(p.Add(m.Mul(2 * d * d / length)))
+		}
+		b.lineTo(p.Add(to))
+	default:
+		b.lineTo(p.Add(to))
+	}
+}
+
+// cap draws the end of an open stroke at p, dir points out of the end of the stroke
+func (s StrokeStyle) cap(b *contourBuilder, p, dir Point) {
+	d := s.Width / 2
+	n := normal(dir, d)
+
+	switch s.Cap {
+	case RoundCap:
+		b.arc(p, -math.Pi)
+	case SquareCap:
+		out := normal(n, d)
+		out = Point{X: -out.X, Y: -out.Y}
+		b.lineTo(p.Add(n).Add(out))
+		b.lineTo(p.Sub(n).Add(out))
+		b.lineTo(p.Sub(n))
+	default:
+		b.lineTo(p.Sub(n))
+	}
+}
+
+// contourBuilder appends segments to a contour while tracking the current point
+type contourBuilder struct {
+	contour Contour
+}
+
+func (b *contourBuilder) current() Point {
+	if len(b.contour.Segments) == 0 {
+		return b.contour.Start
+	}
+
+	return b.contour.Segments[len(b.contour.Segments)-1].End()
+}
+
+func (b *contourBuilder) lineTo(p Point) {
+	if p != b.current() {
+		b.contour.Segments = append(b.contour.Segments, Line(p))
+	}
+}
+
+// arc draws a circular arc around center from the current point, sweeping angle radians
+// counter clockwise (in a y up coordinate system). The arc is split into cubics of at most 90 degrees
+func (b *contourBuilder) arc(center Point, angle float64) {
+	from := b.current().Sub(center)
+	radius := math.Hypot(from.X, from.Y)
+	start := math.Atan2(from.Y, from.X)
+
+	n := int(math.Ceil(math.Abs(angle) / (math.Pi / 2)))
+	step := angle / float64(n)
+	k := 4.0 / 3.0 * math.Tan(step/4) * radius
+
+	point := func(theta float64) Point {
+		return center.Add(Point{X: math.Cos(theta), Y: math.Sin(theta)}.Mul(radius))
+	}
+	tangent := func(theta float64) Point {
+		return Point{X: -math.Sin(theta), Y: math.Cos(theta)}.Mul(k)
+	}
+
+	for i := 0; i < n; i++ {
+		a, z := start+step*float64(i), start+step*float64(i+1)
+		b.contour.Segments = append(b.contour.Segments, Cubic(
+			point(a).Add(tangent(a)),
+			point(z).Sub(tangent(z)),
+			point(z),
+		))
+	}
+}
+
+// normal returns the vector of length d a quarter turn counter clockwise from v
+func normal(v Point, d float64) Point {
+	length := math.Hypot(v.X, v.Y)
+	if length == 0 {
+		return Point{}
+	}
+
+	return Point{X: -v.Y, Y: v.X}.Mul(d / length)
+}
+
+func cross(a, b Point) float64 {
+	return a.X*b.Y - a.Y*b.X
+}
+
+func dot(a, b Point) float64 {
+	return a.X*b.X + a.Y*b.Y
+}
+
+func reversed(points []Point) []Point {
+	ret := make([]Point, len(points))
+	for i, p := range points {
+		ret[len(points)-1-i] = p
+	}
+
+	return ret
+}
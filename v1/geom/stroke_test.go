@@ -0,0 +1,144 @@
+package geom
+
+import (
+	"testing"
+)
+
+func polyline(closed bool, points ...Point) Path {
+	c := Contour{Start: points[0], Closed: closed}
+	for _, p := range points[1:] {
+		c.Segments = append(c.Segments, Line(p))
+	}
+
+	return Path{c}
+}
+
+// winding returns the winding number of the path around p, a point is filled if it is not zero
+func winding(path Path, p Point) int {
+	ret := 0
+	for _, c := range path {
+		points := c.Flatten(0.01)
+		for i := range points {
+			a, b := points[i], points[(i+1)%len(points)]
+			switch {
+			case a.Y <= p.Y && b.Y > p.Y && cross(b.Sub(a), p.Sub(a)) > 0:
+				ret++
+			case a.Y > p.Y && b.Y <= p.Y && cross(b.Sub(a), p.Sub(a)) < 0:
+				ret--
+			}
+		}
+	}
+
+	return ret
+}
+
+func TestPath_Stroke(t *testing.T) {
+	line := polyline(false, Point{X: 0, Y: 0}, Point{X: 10, Y: 0})
+	square := polyline(true, Point{X: 0, Y: 0}, Point{X: 10, Y: 0}, Point{X: 10, Y: 10}, Point{X: 0, Y: 10})
+	corner := polyline(false, Point{X: 0, Y: 0}, Point{X: 10, Y: 0}, Point{X: 10, Y: 10})
+	curve := Path{{
+		Start:    Point{X: 0, Y: 0},
+		Segments: []Segment{Cubic(Point{X: 0, Y: 10}, Point{X: 10, Y: 10}, Point{X: 10, Y: 0})},
+	}}
+
+	tests := []struct {
+		name    string
+		path    Path
+		style   StrokeStyle
+		inside  []Point
+		outside []Point
+	}{
+		{
+			"butt cap",
+			line,
+			StrokeStyle{Width: 2, Cap: ButtCap},
+			[]Point{{X: 5, Y: 0.9}, {X: 5, Y: -0.9}, {X: 0.1, Y: 0}, {X: 9.9, Y: 0}},
+			[]Point{{X: 5, Y: 1.1}, {X: -0.1, Y: 0}, {X: 10.1, Y: 0}},
+		},
+		{
+			"square cap",
+			line,
+			StrokeStyle{Width: 2, Cap: SquareCap},
+			[]Point{{X: -0.9, Y: 0.9}, {X: 10.9, Y: -0.9}},
+			[]Point{{X: -1.1, Y: 0}, {X: 11.1, Y: 0}},
+		},
+		{
+			"round cap",
+			line,
+			StrokeStyle{Width: 2, Cap: RoundCap},
+			[]Point{{X: -0.9, Y: 0}, {X: 10.9, Y: 0}},
+			[]Point{{X: -0.9, Y: 0.9}, {X: 10.9, Y: -0.9}},
+		},
+		{
+			"miter join",
+			square,
+			StrokeStyle{Width: 2, Join: MiterJoin},
+			[]Point{{X: -0.9, Y: -0.9}, {X: 10.9, Y: 10.9}, {X: 5, Y: 0.9}, {X: 0.9, Y: 0.9}},
+			[]Point{{X: 5, Y: 5}, {X: 1.1, Y: 1.1}, {X: -1.1, Y: 5}},
+		},
+		{
+			"bevel join",
+			square,
+			StrokeStyle{Width: 2, Join: BevelJoin},
+			[]Point{{X: -0.4, Y: -0.4}, {X: 5, Y: -0.9}},
+			[]Point{{X: -0.9, Y: -0.9}, {X: 5, Y: 5}},
+		},
+		{
+			"round join",
+			square,
+			StrokeStyle{Width: 2, Join: RoundJoin},
+			[]Point{{X: -0.6, Y: -0.6}, {X: 10.6, Y: 10.6}},
+			[]Point{{X: -0.8, Y: -0.8}, {X: 5, Y: 5}},
+		},
+		{
+			"miter limit",
+			polyline(false, Point{X: 0, Y: 0}, Point{X: 10, Y: 0}, Point{X: 0, Y: 1}),
+			StrokeStyle{Width: 2, Join: MiterJoin, MiterLimit: 2},
+			[]Point{{X: 5, Y: 0}, {X: 9.9, Y: 0}},
+			[]Point{{X: 12, Y: 0}},
+		},
+		{
+			"open corner",
+			corner,
+			StrokeStyle{Width: 2, Join: MiterJoin},
+			[]Point{{X: 10.9, Y: -0.9}, {X: 9.1, Y: 0.9}, {X: 10, Y: 5}},
+			[]Point{{X: 5, Y: 5}, {X: 8.9, Y: 1.1}},
+		},
+		{
+			"curve",
+			curve,
+			StrokeStyle{Width: 2},
+			[]Point{{X: 5, Y: 7.5}, {X: 5, Y: 6.6}, {X: 5, Y: 8.4}},
+			[]Point{{X: 5, Y: 6.4}, {X: 5, Y: 8.6}, {X: 5, Y: 0}},
+		},
+		{
+			"round dot",
+			Path{{Start: Point{X: 0, Y: 0}}},
+			StrokeStyle{Width: 2, Cap: RoundCap},
+			[]Point{{X: 0, Y: 0}, {X: 0.9, Y: 0}},
+			[]Point{{X: 0.9, Y: 0.9}},
+		},
+		{
+			"butt dot",
+			Path{{Start: Point{X: 0, Y: 0}}},
+			StrokeStyle{Width: 2, Cap: ButtCap},
+			nil,
+			[]Point{{X: 0, Y: 0}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.path.Stroke(tt.style, 0.01)
+			for _, p := range tt.inside {
+				if winding(got, p) == 0 {
+					t.Errorf("Stroke() does not fill %v", p)
+				}
+			}
+			for _, p := range tt.outside {
+				if winding(got, p) != 0 {
+					t.Errorf("Stroke() fills %v", p)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,498 @@
+package layout
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/bjatkin/silabex/dict"
+	"github.com/bjatkin/silabex/font"
+	"github.com/bjatkin/silabex/geom"
+	"github.com/bjatkin/silabex/linalg"
+	"github.com/bjatkin/silabex/steno"
+)
+
+// Options controls how text is set on the page, every distance is in output pixels
+type Options struct {
+	// GlyphSize is the width and height of a single syllable
+	GlyphSize float64
+	// SyllableSpace is the gap between the syllables of a word
+	SyllableSpace float64
+	// WordSpace is the gap between words
+	WordSpace float64
+	// LineSpace is the gap between lines
+	LineSpace float64
+	// MaxWidth is the width of the page, lines wrap before they reach it. A MaxWidth of 0 never wraps
+	// and the page is as wide as the longest line
+	MaxWidth float64
+	// Margin is the space around the text on every side of the page
+	Margin float64
+}
+
+// DefaultOptions returns the options used when none are given
+func DefaultOptions() Options {
+	return Options{
+		GlyphSize:     48,
+		SyllableSpace: 4,
+		WordSpace:     24,
+		LineSpace:     16,
+		MaxWidth:      800,
+		Margin:        24,
+	}
+}
+
+// Layout sets text in silabex by looking up every word in a dictionary
+type Layout struct {
+	font  *font.Font
+	store *dict.Store
+	opts  Options
+}
+
+// New creates a layout engine. The store's glyph renderer is set to draw characters with the font
+// so rendered syllables are cached between pages
+func New(f *font.Font, store *dict.Store, opts Options) *Layout {
+	store.SetRenderer(func(k steno.Keys) (string, error) {
+		char, err := f.NewCharacter(k.Chord())
+		if err != nil {
+			return "", err
+		}
+		return char.Group(), nil
+	}, dict.DefaultGlyphCacheSize)
+
+	return &Layout{
+		font:  f,
+		store: store,
+		opts:  opts,
+	}
+}
+
+// Sign is a single glyph of a word. A sign is either a syllable written with steno keys, which can
+// have a diacritic drawn over it, or a punctuation mark or digit
+type Sign struct {
+	Keys      steno.Keys
+	Symbol    rune
+	Diacritic font.Diacritic
+}
+
+// Word is a word of the text and the signs it is written with
+type Word struct {
+	Text  string
+	Signs []Sign
+}
+
+// Glyph is a sign placed on the page, X and Y are the top left corner of the glyph
+type Glyph struct {
+	Sign
+	X, Y float64
+}
+
+// Page is text that has been set, it can be written out as a single svg document
+type Page struct {
+	Width, Height float64
+	Glyphs        []Glyph
+	// Missing lists the words that are not in the dictionary and the punctuation the font has no symbol
+	// for, they are left off the page
+	Missing []string
+
+	scale float64
+	font  *font.Font
+	store *dict.Store
+}
+
+// Words looks up every word of the text. Words are seperated by white space, punctuation around a word
+// and numbers are written with the font's symbols and punctuation the font has no symbol for is missing.
+// A word that is not found is looked up again in lower case and its first syllable is marked as
+// capitalized or upper case
+func (l *Layout) Words(text string) ([]Word, []string) {
+	words := []Word{}
+	missing := []string{}
+	for _, field := range strings.Fields(text) {
+		core := strings.TrimLeftFunc(field, unicode.IsPunct)
+		lead := field[:len(field)-len(core)]
+		core = strings.TrimRightFunc(core, unicode.IsPunct)
+		trail := field[len(lead)+len(core):]
+
+		signs, unknown := l.punctuation(lead)
+		word := Word{Text: field, Signs: signs}
+		missing = append(missing, unknown...)
+		switch signs, ok := l.lookup(core); {
+		case core == "":
+		case ok:
+			word.Signs = append(word.Signs, signs...)
+		default:
+			missing = append(missing, core)
+		}
+		signs, unknown = l.punctuation(trail)
+		word.Signs = append(word.Signs, signs...)
+		missing = append(missing, unknown...)
+
+		if len(word.Signs) > 0 {
+			words = append(words, word)
+		}
+	}
+
+	return words, missing
+}
+
+// lookup returns the signs for a single word without punctuation
+func (l *Layout) lookup(word string) ([]Sign, bool) {
+	if signs, ok := l.digits(word); ok {
+		return signs, true
+	}
+
+	if outlines := l.store.Outlines(word); len(outlines) > 0 {
+		return l.syllables(outlines[0]), true
+	}
+
+	lower := strings.ToLower(word)
+	outlines := l.store.Outlines(lower)
+	if len(outlines) == 0 {
+		return nil, false
+	}
+
+	signs := l.syllables(outlines[0])
+	switch {
+	case len([]rune(word)) > 1 && word == strings.ToUpper(word):
+		signs[0].Diacritic = font.Emphasis
+	case word != lower:
+		signs[0].Diacritic = font.Capital
+	}
+
+	return signs, true
+}
+
+// syllables returns the signs for the strokes of an outline, number strokes are written as digits
+func (l *Layout) syllables(strokes []steno.Keys) []Sign {
+	ret := []Sign{}
+	for _, k := range strokes {
+		if number, ok := dict.Number(k); ok {
+			if signs, ok := l.digits(number); ok {
+				ret = append(ret, signs...)
+				continue
+			}
+		}
+		ret = append(ret, Sign{Keys: k})
+	}
+
+	return ret
+}
+
+// digits returns the signs for a number, false is returned if text is not a number the font can write
+func (l *Layout) digits(text string) ([]Sign, bool) {
+	if _, ok := l.font.Digits(text); !ok || text == "" {
+		return nil, false
+	}
+
+	return l.symbols(text), true
+}
+
+// punctuation returns a sign for every mark of the punctuation around a word. Marks the font has
+// no symbol for are returned as missing, the same as words that are not in the dictionary
+func (l *Layout) punctuation(text string) ([]Sign, []string) {
+	signs := []Sign{}
+	missing := []string{}
+	for _, r := range text {
+		if _, ok := l.font.Symbol(r); !ok {
+			missing = append(missing, string(r))
+			continue
+		}
+		signs = append(signs, Sign{Symbol: r})
+	}
+
+	return signs, missing
+}
+
+// symbols returns a sign for every rune of the text that the font has a symbol for
+func (l *Layout) symbols(text string) []Sign {
+	ret := []Sign{}
+	for _, r := range text {
+		if _, ok := l.font.Symbol(r); ok {
+			ret = append(ret, Sign{Symbol: r})
+		}
+	}
+
+	return ret
+}
+
+// Translate segments a stroke stream with the dictionary and returns the words it writes. Plover meta
+// commands are written with signs, punctuation like {.} is drawn with the font's symbols, {-|} and {<}
+// mark the next word as capitalized or upper case and attached text like {^ing} joins the word before it.
+// Punctuation the font has no symbol for is returned as missing
+func (l *Layout) Translate(strokes []steno.Keys) ([]Word, []string) {
+	t := translator{}
+	missing := []string{}
+	for _, m := range l.store.Segment(strokes) {
+		signs := l.syllables(m.Strokes)
+		if m.Entry == nil {
+			t.add(outline(m.Strokes), signs, false)
+			continue
+		}
+
+		drawn := false
+		for _, p := range m.Entry.Parts {
+			switch p.Op {
+			case dict.Text, dict.Attach, dict.Glue:
+				// {^} only joins the words next to it
+				if p.Op == dict.Attach && p.Text == "" {
+					t.attach = true
+					continue
+				}
+				if drawn {
+					continue
+				}
+				drawn = true
+
+				// text that is only punctuation or a number is drawn with symbols instead of strokes
+				if symbols, unknown, ok := l.textSymbols(p.Text); ok {
+					signs = symbols
+					missing = append(missing, unknown...)
+				}
+
+				glue := p.Op == dict.Glue && t.glue
+				t.add(p.Text, signs, (p.Op == dict.Attach && p.Before) || glue)
+				t.attach = p.Op == dict.Attach && p.After
+				t.glue = p.Op == dict.Glue
+			case dict.Punct:
+				signs, unknown := l.punctuation(p.Text)
+				missing = append(missing, unknown...)
+				t.add(p.Text, signs, true)
+			case dict.CapNext:
+				t.next = font.Capital
+			case dict.UpperNext:
+				t.next = font.Emphasis
+			case dict.LowerNext:
+				t.next = font.NoDiacritic
+			case dict.CapPrev:
+				t.prev(font.Capital)
+			case dict.UpperPrev:
+				t.prev(font.Emphasis)
+			case dict.LowerPrev:
+				t.prev(font.NoDiacritic)
+			}
+		}
+	}
+
+	return t.words, missing
+}
+
+// outline writes strokes the way they appear in a dictionary
+func outline(strokes []steno.Keys) string {
+	ret := []string{}
+	for _, k := range strokes {
+		ret = append(ret, k.String())
+	}
+
+	return strings.Join(ret, "/")
+}
+
+// textSymbols returns the symbols for text that is only punctuation or only a number, with the
+// punctuation the font has no symbol for
+func (l *Layout) textSymbols(text string) ([]Sign, []string, bool) {
+	if signs, ok := l.digits(text); ok {
+		return signs, nil, true
+	}
+
+	if text == "" || strings.Trim(text, font.Punctuation) != "" {
+		return nil, nil, false
+	}
+
+	signs, missing := l.punctuation(text)
+	return signs, missing, true
+}
+
+// translator collects the words written by a stroke stream
+type translator struct {
+	words []Word
+	// attach joins the next word to the last one
+	attach bool
+	// glue is set when the last word ended with glued text
+	glue bool
+	// next is the diacritic for the first syllable of the next word
+	next font.Diacritic
+}
+
+// add writes signs as a new word or joins them to the last word
+func (t *translator) add(text string, signs []Sign, join bool) {
+	if len(signs) == 0 {
+		return
+	}
+
+	if t.next != font.NoDiacritic && signs[0].Symbol == 0 {
+		signs[0].Diacritic = t.next
+		t.next = font.NoDiacritic
+	}
+
+	if (join || t.attach) && len(t.words) > 0 {
+		last := &t.words[len(t.words)-1]
+		last.Text += text
+		last.Signs = append(last.Signs, signs...)
+	} else {
+		t.words = append(t.words, Word{Text: text, Signs: signs})
+	}
+
+	t.attach = false
+	t.glue = false
+}
+
+// prev sets the diacritic of the first syllable of the last word
+func (t *translator) prev(d font.Diacritic) {
+	if len(t.words) == 0 {
+		return
+	}
+
+	for i, s := range t.words[len(t.words)-1].Signs {
+		if s.Symbol == 0 {
+			t.words[len(t.words)-1].Signs[i].Diacritic = d
+			return
+		}
+	}
+}
+
+// Layout sets the text on a page. Newlines in the text always start a new line, otherwise words wrap
+// when the next word would cross the right margin
+func (l *Layout) Layout(text string) *Page {
+	lines := [][]Word{}
+	missing := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		words, m := l.Words(line)
+		lines = append(lines, words)
+		missing = append(missing, m...)
+	}
+
+	page := l.place(lines)
+	if len(missing) > 0 {
+		page.Missing = missing
+	}
+
+	return page
+}
+
+// LayoutStrokes translates a stroke stream with the dictionary and sets it on a page
+func (l *Layout) LayoutStrokes(strokes []steno.Keys) *Page {
+	words, missing := l.Translate(strokes)
+	page := l.place([][]Word{words})
+	if len(missing) > 0 {
+		page.Missing = missing
+	}
+
+	return page
+}
+
+// place sets each line of words on the page, long lines are wrapped
+func (l *Layout) place(lines [][]Word) *Page {
+	o := l.opts
+	page := &Page{
+		scale: o.GlyphSize / l.font.Metrics().EmSize,
+		font:  l.font,
+		store: l.store,
+	}
+
+	right := 0.0
+	y := o.Margin
+	for i, words := range lines {
+		if i > 0 {
+			y += o.GlyphSize + o.LineSpace
+		}
+
+		x := o.Margin
+		for j, w := range words {
+			width := float64(len(w.Signs))*(o.GlyphSize+o.SyllableSpace) - o.SyllableSpace
+			if j > 0 {
+				x += o.WordSpace
+				if o.MaxWidth > 0 && x+width > o.MaxWidth-o.Margin {
+					x = o.Margin
+					y += o.GlyphSize + o.LineSpace
+				}
+			}
+
+			for _, s := range w.Signs {
+				page.Glyphs = append(page.Glyphs, Glyph{Sign: s, X: x, Y: y})
+				x += o.GlyphSize + o.SyllableSpace
+			}
+			x -= o.SyllableSpace
+			right = max(right, x)
+		}
+	}
+
+	page.Width = o.MaxWidth
+	if page.Width <= 0 {
+		page.Width = max(right, o.Margin) + o.Margin
+	}
+	page.Height = y + o.GlyphSize + o.Margin
+
+	return page
+}
+
+// SVG writes the page as a single svg document
+func (p *Page) SVG() (string, error) {
+	ret := []string{
+		fmt.Sprintf("<svg width=\"%[1]g\" height=\"%[2]g\" viewBox=\"0 0 %[1]g %[2]g\" xmlns=\"http://www.w3.org/2000/svg\">", p.Width, p.Height),
+	}
+
+	for _, g := range p.Glyphs {
+		group, err := p.glyph(g.Sign)
+		if err != nil {
+			return "", err
+		}
+
+		ret = append(ret, fmt.Sprintf("<g transform=\"translate(%g %g) scale(%g)\">", g.X, g.Y, p.scale))
+		ret = append(ret, group)
+		ret = append(ret, "</g>")
+	}
+	ret = append(ret, "</svg>")
+
+	return strings.Join(ret, "\n"), nil
+}
+
+// glyph returns the strokes for a sign in template units
+func (p *Page) glyph(s Sign) (string, error) {
+	if s.Symbol != 0 {
+		char, ok := p.font.Symbol(s.Symbol)
+		if !ok {
+			return "", fmt.Errorf("font has no symbol for %q", s.Symbol)
+		}
+		return char.Group(), nil
+	}
+
+	group, err := p.store.Glyph(s.Keys)
+	if err != nil {
+		return "", fmt.Errorf("glyph %s: %w", s.Keys, err)
+	}
+
+	if mark, ok := p.font.Diacritic(s.Diacritic); ok {
+		group += "\n" + mark.SVG()
+	}
+
+	return group, nil
+}
+
+// Outline returns the shape of every glyph on the page in output pixels, so the page can be drawn
+// without an svg renderer
+func (p *Page) Outline() (geom.Path, error) {
+	ret := geom.Path{}
+	for _, g := range p.Glyphs {
+		var char *font.Character
+		if g.Symbol != 0 {
+			symbol, ok := p.font.Symbol(g.Symbol)
+			if !ok {
+				return nil, fmt.Errorf("font has no symbol for %q", g.Symbol)
+			}
+			char = symbol
+		} else {
+			syllable, err := p.font.NewCharacter(g.Keys.Chord())
+			if err != nil {
+				return nil, fmt.Errorf("glyph %s: %w", g.Keys, err)
+			}
+			char = p.font.Mark(syllable, g.Diacritic)
+		}
+
+		path, err := char.Outline()
+		if err != nil {
+			return nil, fmt.Errorf("glyph %s: %w", g.Keys, err)
+		}
+
+		ret = append(ret, path.Transform(linalg.Transform(linalg.Translate(g.X, g.Y), linalg.Scale(p.scale, p.scale)))...)
+	}
+
+	return ret, nil
+}
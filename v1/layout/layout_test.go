@@ -0,0 +1,301 @@
+package layout
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bjatkin/silabex/dict"
+	"github.com/bjatkin/silabex/font"
+	"github.com/bjatkin/silabex/geom"
+	"github.com/bjatkin/silabex/steno"
+)
+
+func testLayout(t *testing.T, opts Options) *Layout {
+	f, err := font.NewFont("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("NewFont() error = %v", err)
+	}
+
+	d, err := dict.Load(strings.NewReader(`{
+"HEL/HROE": "hello", "WORLD": "world", "TAOEU/TPH*EU": "tiny", "PHAOPB": "moon",
+"TP-PL": "{.}", "KW-BG": "{,}", "KPA": "{-|}", "KPA*": "{<}", "-G": "{^ing}", "H-L": "{^}",
+"TK-LS": "{^'^}", "1-9": "19", "SKWR": "{&j}", "PWOUFP": "{*-|}"
+}`))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	return New(f, dict.NewStore(d), opts)
+}
+
+func TestLayout_Layout(t *testing.T) {
+	opts := Options{GlyphSize: 10, SyllableSpace: 1, WordSpace: 5, LineSpace: 2, MaxWidth: 60, Margin: 3}
+
+	tests := []struct {
+		name        string
+		text        string
+		opts        Options
+		wantX       []float64
+		wantY       []float64
+		wantWidth   float64
+		wantHeight  float64
+		wantMissing []string
+	}{
+		{
+			name:       "one line",
+			text:       "hello world",
+			opts:       opts,
+			wantX:      []float64{3, 14, 29},
+			wantY:      []float64{3, 3, 3},
+			wantWidth:  60,
+			wantHeight: 16,
+		},
+		{
+			name:       "wrap",
+			text:       "hello world tiny",
+			opts:       opts,
+			wantX:      []float64{3, 14, 29, 3, 14},
+			wantY:      []float64{3, 3, 3, 15, 15},
+			wantWidth:  60,
+			wantHeight: 28,
+		},
+		{
+			name:       "newline",
+			text:       "moon\nmoon",
+			opts:       opts,
+			wantX:      []float64{3, 3},
+			wantY:      []float64{3, 15},
+			wantWidth:  60,
+			wantHeight: 28,
+		},
+		{
+			name:        "missing word",
+			text:        "hello stars moon",
+			opts:        opts,
+			wantX:       []float64{3, 14, 29},
+			wantY:       []float64{3, 3, 3},
+			wantWidth:   60,
+			wantHeight:  16,
+			wantMissing: []string{"stars"},
+		},
+		{
+			name:       "no max width",
+			text:       "hello world tiny",
+			opts:       Options{GlyphSize: 10, SyllableSpace: 1, WordSpace: 5, LineSpace: 2, Margin: 3},
+			wantX:      []float64{3, 14, 29, 44, 55},
+			wantY:      []float64{3, 3, 3, 3, 3},
+			wantWidth:  68,
+			wantHeight: 16,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := testLayout(t, tt.opts).Layout(tt.text)
+
+			gotX, gotY := []float64{}, []float64{}
+			for _, g := range got.Glyphs {
+				gotX = append(gotX, g.X)
+				gotY = append(gotY, g.Y)
+			}
+			if !reflect.DeepEqual(gotX, tt.wantX) || !reflect.DeepEqual(gotY, tt.wantY) {
+				t.Errorf("Layout() x = %v y = %v, want x = %v y = %v", gotX, gotY, tt.wantX, tt.wantY)
+			}
+			if got.Width != tt.wantWidth || got.Height != tt.wantHeight {
+				t.Errorf("Layout() size = %gx%g, want %gx%g", got.Width, got.Height, tt.wantWidth, tt.wantHeight)
+			}
+			if !reflect.DeepEqual(got.Missing, tt.wantMissing) {
+				t.Errorf("Layout() missing = %v, want %v", got.Missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestPage_SVG(t *testing.T) {
+	l := testLayout(t, DefaultOptions())
+	got, err := l.Layout("Hello moon.").SVG()
+	if err != nil {
+		t.Fatalf("SVG() error = %v", err)
+	}
+
+	if strings.Count(got, "<svg") != 1 {
+		t.Errorf("SVG() has %d svg elements, want 1", strings.Count(got, "<svg"))
+	}
+	scale := fmt.Sprintf(") scale(%g)\">", 48/l.font.Metrics().EmSize)
+	if strings.Count(got, scale) != 4 {
+		t.Errorf("SVG() has %d placed glyphs, want 4", strings.Count(got, scale))
+	}
+
+	k, _ := steno.ParseKeys("PHAOPB")
+	want, _ := l.store.Glyph(k)
+	if !strings.Contains(got, want) {
+		t.Errorf("SVG() is missing the glyph for PHAOPB")
+	}
+
+	period, _ := l.font.Symbol('.')
+	if !strings.Contains(got, period.Group()) {
+		t.Errorf("SVG() is missing the period")
+	}
+	capital, _ := l.font.Diacritic(font.Capital)
+	if !strings.Contains(got, capital.SVG()) {
+		t.Errorf("SVG() is missing the capital diacritic")
+	}
+}
+
+func TestPage_Outline(t *testing.T) {
+	l := testLayout(t, DefaultOptions())
+	page := l.Layout("Hello moon.")
+	got, err := page.Outline()
+	if err != nil {
+		t.Fatalf("Outline() error = %v", err)
+	}
+
+	bounds, ok := got.Bounds()
+	if !ok {
+		t.Fatalf("Outline() is empty")
+	}
+
+	// every glyph is drawn inside the margins, the first one starts in the top left corner
+	o := DefaultOptions()
+	text := geom.NewRect(o.Margin, o.Margin, page.Width-2*o.Margin, page.Height-2*o.Margin)
+	if bounds.Union(text) != text {
+		t.Errorf("Outline() bounds = %v, want inside %v", bounds, text)
+	}
+	if bounds.Min.X > o.Margin+o.GlyphSize/2 || bounds.Min.Y > o.Margin+o.GlyphSize/2 {
+		t.Errorf("Outline() bounds = %v, want the first glyph at %v", bounds, text.Min)
+	}
+}
+
+// signs writes the signs of each word, symbols are written in brackets and diacritics after a plus
+func signs(words []Word) []string {
+	ret := []string{}
+	for _, w := range words {
+		signs := []string{}
+		for _, s := range w.Signs {
+			sign := s.Keys.String()
+			if s.Symbol != 0 {
+				sign = "[" + string(s.Symbol) + "]"
+			}
+			if s.Diacritic != font.NoDiacritic {
+				sign += "+" + s.Diacritic.String()
+			}
+			signs = append(signs, sign)
+		}
+		ret = append(ret, strings.Join(signs, " "))
+	}
+
+	return ret
+}
+
+func TestLayout_Words(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		want        []string
+		wantMissing []string
+	}{
+		{"words", "hello world", []string{"HEL HROE", "WORLD"}, []string{}},
+		{"punctuation", "(hello, world!)", []string{"[(] HEL HROE [,]", "WORLD [!] [)]"}, []string{}},
+		{"capital", "Hello World", []string{"HEL+capital HROE", "WORLD+capital"}, []string{}},
+		{"upper case", "MOON", []string{"PHAOPB+emphasis"}, []string{}},
+		{"number", "19 moons.", []string{"[1] [9]", "[.]"}, []string{"moons"}},
+		{"punctuation only", "hello ...", []string{"HEL HROE", "[.] [.] [.]"}, []string{}},
+		{"unknown punctuation", "[hello] “world”—", []string{"HEL HROE", "WORLD"}, []string{"[", "]", "“", "”", "—"}},
+		{"unknown punctuation only", "--", []string{}, []string{"-", "-"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, missing := testLayout(t, DefaultOptions()).Words(tt.text)
+			if !reflect.DeepEqual(signs(got), tt.want) {
+				t.Errorf("Words() = %v, want %v", signs(got), tt.want)
+			}
+			if !reflect.DeepEqual(missing, tt.wantMissing) {
+				t.Errorf("Words() missing = %v, want %v", missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestLayout_Translate(t *testing.T) {
+	tests := []struct {
+		name    string
+		strokes string
+		want    []string
+	}{
+		{"words", "HEL/HROE/WORLD", []string{"HEL HROE", "WORLD"}},
+		{"punctuation", "HEL/HROE/KW-BG/WORLD/TP-PL", []string{"HEL HROE [,]", "WORLD [.]"}},
+		{"cap next", "KPA/WORLD", []string{"WORLD+capital"}},
+		{"upper next", "KPA*/PHAOPB", []string{"PHAOPB+emphasis"}},
+		{"cap prev", "PHAOPB/PWOUFP", []string{"PHAOPB+capital"}},
+		{"attach", "WORLD/-G", []string{"WORLD -G"}},
+		{"attach both sides", "PHAOPB/H-L/WORLD", []string{"PHAOPB WORLD"}},
+		{"attached punctuation", "PHAOPB/TK-LS/WORLD", []string{"PHAOPB ['] WORLD"}},
+		{"glue", "SKWR/SKWR/PHAOPB", []string{"SKWR SKWR", "PHAOPB"}},
+		{"number entry", "1-9", []string{"[1] [9]"}},
+		{"number stroke", "#T-Z", []string{"2-Z"}},
+		{"untranslated", "TK", []string{"TK"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strokes := []steno.Keys{}
+			for _, stroke := range strings.Split(tt.strokes, "/") {
+				k, err := steno.ParseKeys(stroke)
+				if err != nil {
+					t.Fatalf("ParseKeys() error = %v", err)
+				}
+				strokes = append(strokes, k)
+			}
+
+			got, missing := testLayout(t, DefaultOptions()).Translate(strokes)
+			if !reflect.DeepEqual(signs(got), tt.want) {
+				t.Errorf("Translate() = %v, want %v", signs(got), tt.want)
+			}
+			if len(missing) != 0 {
+				t.Errorf("Translate() missing = %v, want none", missing)
+			}
+		})
+	}
+}
+
+func TestLayout_LayoutStrokes_missingPunctuation(t *testing.T) {
+	raw, err := os.ReadFile("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	// the font is drawn without the period
+	fontPath := filepath.Join(t.TempDir(), "font.svg")
+	if err := os.WriteFile(fontPath, []byte(strings.Replace(string(raw), `inkscape:label="period"`, "", 1)), 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	f, err := font.NewFont(fontPath)
+	if err != nil {
+		t.Fatalf("NewFont() error = %v", err)
+	}
+	if _, ok := f.Symbol('.'); ok {
+		t.Fatalf("Symbol() found the period that was removed from the font")
+	}
+
+	l := testLayout(t, DefaultOptions())
+	l.font = f
+
+	strokes := []steno.Keys{}
+	for _, stroke := range []string{"PHAOPB", "TP-PL", "KW-BG"} {
+		k, err := steno.ParseKeys(stroke)
+		if err != nil {
+			t.Fatalf("ParseKeys() error = %v", err)
+		}
+		strokes = append(strokes, k)
+	}
+
+	page := l.LayoutStrokes(strokes)
+	if want := []string{"."}; !reflect.DeepEqual(page.Missing, want) {
+		t.Errorf("LayoutStrokes() missing = %v, want %v", page.Missing, want)
+	}
+	// the comma is still drawn after the word
+	if len(page.Glyphs) != 2 {
+		t.Errorf("LayoutStrokes() placed %d glyphs, want 2", len(page.Glyphs))
+	}
+}
@@ -15,8 +15,8 @@ func (m Mat3x) String() string {
 	return fmt.Sprintf(
 		"[%.2f, %.2f, %.2f]\n[%.2f, %.2f, %.2f]\n[%.2f, %.2f, %.2f]",
 		m.Data[0][0], m.Data[0][1], m.Data[0][2],
-		m.Data[1][0], m.Data[1][1], m.Data[2][2],
-		m.Data[2][0], m.Data[2][1], m.Data[1][2],
+		m.Data[1][0], m.Data[1][1], m.Data[1][2],
+		m.Data[2][0], m.Data[2][1], m.Data[2][2],
 	)
 }
 
@@ -65,6 +65,18 @@ func Translate(tx, ty float64) Mat3x {
 	}
 }
 
+// ScaleAt returns a matrix that scales the x and y axes by sx and sy around the point ox, oy.
+// A scale of -1 mirrors across the line through the point
+func ScaleAt(sx, sy, ox, oy float64) Mat3x {
+	return Transform(Translate(ox, oy), Scale(sx, sy), Translate(-ox, -oy))
+}
+
+// RotateAt returns a matrix that rotates the x and y axes by angle around the point ox, oy
+// angle is in radians
+func RotateAt(angle, ox, oy float64) Mat3x {
+	return Transform(Translate(ox, oy), Rotate(angle), Translate(-ox, -oy))
+}
+
 // MatMul returns the result of multiplying two Mat3x
 func MatMul(a, b Mat3x) Mat3x {
 	ret := Mat3x{}
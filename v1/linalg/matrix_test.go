@@ -79,3 +79,73 @@ func matEqual(a, b Mat3x, e float64) bool {
 
 	return true
 }
+
+func TestScaleAt(t *testing.T) {
+	type args struct {
+		sx, sy float64
+		ox, oy float64
+		p      Vec3
+	}
+	tests := []struct {
+		name string
+		args args
+		want Vec3
+	}{
+		{
+			"mirror x",
+			args{sx: -1, sy: 1, ox: 305, oy: 500, p: NewPoint2(140, 290)},
+			NewPoint2(470, 290),
+		},
+		{
+			"mirror y",
+			args{sx: 1, sy: -1, ox: 305, oy: 500, p: NewPoint2(140, 290)},
+			NewPoint2(140, 710),
+		},
+		{
+			"scale",
+			args{sx: 2, sy: 2, ox: 10, oy: 10, p: NewPoint2(20, 0)},
+			NewPoint2(30, -10),
+		},
+		{
+			"origin is fixed",
+			args{sx: -1, sy: -1, ox: 10, oy: 20, p: NewPoint2(10, 20)},
+			NewPoint2(10, 20),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := VecMul(ScaleAt(tt.args.sx, tt.args.sy, tt.args.ox, tt.args.oy), tt.args.p)
+			if math.Abs(got.X-tt.want.X) > 0.01 || math.Abs(got.Y-tt.want.Y) > 0.01 {
+				t.Errorf("ScaleAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRotateAt(t *testing.T) {
+	got := VecMul(RotateAt(math.Pi/2, 10, 10), NewPoint2(20, 10))
+	if math.Abs(got.X-10) > 0.01 || math.Abs(got.Y-20) > 0.01 {
+		t.Errorf("RotateAt() = %v, want {10 20 1}", got)
+	}
+}
+
+func TestVecMul(t *testing.T) {
+	// the last column of the matrix used to be dropped, so translations did not move points
+	tests := []struct {
+		name string
+		m    Mat3x
+		v    Vec3
+		want Vec3
+	}{
+		{"translate point", Translate(5, -10), NewPoint2(1, 2), Vec3{X: 6, Y: -8, Z: 1}},
+		{"translate direction", Translate(5, -10), Vec3{X: 1, Y: 2}, Vec3{X: 1, Y: 2}},
+		{"scale then translate", MatMul(Translate(5, 10), Scale(2, 3)), NewPoint2(1, 1), Vec3{X: 7, Y: 13, Z: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VecMul(tt.m, tt.v); got != tt.want {
+				t.Errorf("VecMul() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
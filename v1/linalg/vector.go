@@ -17,8 +17,8 @@ func NewPoint2(x, y float64) Vec3 {
 // VecMul returns the result of multiplying the Mat3x m by the Vec3 v
 func VecMul(m Mat3x, v Vec3) Vec3 {
 	return Vec3{
-		X: m.Data[0][0]*v.X + m.Data[0][1]*v.Y,
-		Y: m.Data[1][0]*v.X + m.Data[1][1]*v.Y,
-		Z: m.Data[2][0]*v.X + m.Data[2][1]*v.Y,
+		X: m.Data[0][0]*v.X + m.Data[0][1]*v.Y + m.Data[0][2]*v.Z,
+		Y: m.Data[1][0]*v.X + m.Data[1][1]*v.Y + m.Data[1][2]*v.Z,
+		Z: m.Data[2][0]*v.X + m.Data[2][1]*v.Y + m.Data[2][2]*v.Z,
 	}
 }
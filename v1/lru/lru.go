@@ -0,0 +1,82 @@
+package lru
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache is a fixed size cache that evicts the least recently used value when it is full.
+// It is safe to use from multiple goroutines
+type Cache[K comparable, V any] struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[K]*list.Element
+}
+
+type item[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// New creates a cache that holds at most size values, a size less than 1 is treated as 1
+func New[K comparable, V any](size int) *Cache[K, V] {
+	return &Cache[K, V]{
+		size:  max(size, 1),
+		order: list.New(),
+		items: map[K]*list.Element{},
+	}
+}
+
+// Get returns the value for the key and marks it as the most recently used
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+
+	c.order.MoveToFront(elem)
+	return elem.Value.(*item[K, V]).value, true
+}
+
+// Add sets the value for the key, the least recently used value is evicted if the cache is full
+func (c *Cache[K, V]) Add(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*item[K, V]).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&item[K, V]{key: key, value: value})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*item[K, V]).key)
+	}
+}
+
+// Remove drops the value for the key
+func (c *Cache[K, V]) Remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.items[key]; ok {
+		c.order.Remove(elem)
+		delete(c.items, key)
+	}
+}
+
+// Len returns the number of values in the cache
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.order.Len()
+}
@@ -0,0 +1,67 @@
+package lru
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	c := New[string, int](2)
+	c.Add("a", 1)
+	c.Add("b", 2)
+
+	if got, ok := c.Get("a"); !ok || got != 1 {
+		t.Errorf("Cache.Get(a) = %v, %v, want 1, true", got, ok)
+	}
+
+	// b is the least recently used value now
+	c.Add("c", 3)
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("Cache.Get(b) found an evicted value")
+	}
+	if got, ok := c.Get("c"); !ok || got != 3 {
+		t.Errorf("Cache.Get(c) = %v, %v, want 3, true", got, ok)
+	}
+
+	c.Add("a", 4)
+	if got, _ := c.Get("a"); got != 4 {
+		t.Errorf("Cache.Get(a) = %v, want 4", got)
+	}
+	if c.Len() != 2 {
+		t.Errorf("Cache.Len() = %v, want 2", c.Len())
+	}
+
+	c.Remove("a")
+	if _, ok := c.Get("a"); ok || c.Len() != 1 {
+		t.Errorf("Cache.Remove() left the value in the cache")
+	}
+}
+
+func TestNew_minSize(t *testing.T) {
+	c := New[int, int](0)
+	c.Add(1, 1)
+	c.Add(2, 2)
+	if c.Len() != 1 {
+		t.Errorf("Cache.Len() = %v, want 1", c.Len())
+	}
+}
+
+func TestCache_concurrent(t *testing.T) {
+	c := New[int, int](8)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.Add(i*100+j, j)
+				c.Get(i*100 + j - 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if c.Len() != 8 {
+		t.Errorf("Cache.Len() = %v, want 8", c.Len())
+	}
+}
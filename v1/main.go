@@ -1,23 +1,21 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
-	"github.com/bjatkin/silabex/font"
+	"github.com/bjatkin/silabex/cli"
 )
 
 func main() {
-	f, err := font.NewFont("reference/font2.svg")
-	if err != nil {
-		fmt.Println("err: ", err)
-		return
-	}
-
-	char := f.NewCharacter("0459", "0123", "")
-	err = os.WriteFile("reference/test.svg", []byte(char.SVG()), 0o0655)
-	if err != nil {
-		fmt.Println("err: ", err)
-		return
+	err := cli.Run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	switch {
+	case errors.Is(err, cli.ErrUsage):
+		fmt.Fprintln(os.Stderr, "err: ", err)
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "err: ", err)
+		os.Exit(1)
 	}
 }
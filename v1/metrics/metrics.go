@@ -0,0 +1,304 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/JoshVarga/svgparser"
+	"github.com/bjatkin/silabex/geom"
+	"github.com/bjatkin/silabex/linalg"
+	"github.com/bjatkin/silabex/svg"
+)
+
+// Slot is the layout of a consonant slot, every box is in template units
+type Slot struct {
+	// Core is the box the stand and core strokes are drawn in
+	Core geom.Rect `json:"core"`
+	// Head is the box above the core that head strokes and hanging strokes are drawn in
+	Head geom.Rect `json:"head"`
+	// Foot is the box below the core that foot strokes are drawn in
+	Foot       geom.Rect `json:"foot"`
+	HeadCenter geom.Rect `json:"head_center"`
+	FootCenter geom.Rect `json:"foot_center"`
+}
+
+// HangShift is the distance a stand moves up to become a hang
+func (s Slot) HangShift() float64 {
+	return s.Core.Min.Y - s.Head.Min.Y
+}
+
+// FootShift is the distance a foot moves up to become a head
+func (s Slot) FootShift() float64 {
+	return s.Foot.Min.Y - s.Head.Min.Y
+}
+
+// Center is the point strokes in the slot are mirrored around
+func (s Slot) Center() geom.Point {
+	return s.Core.Center()
+}
+
+// FontMetrics describes the proportions of a template. The initial consonant slot is on the left
+// side of the character and the final slot is the initial slot mirrored across the middle of the em
+type FontMetrics struct {
+	// EmSize is the width and height of a full character
+	EmSize float64 `json:"em_size"`
+	// Vowel is the outer edge of the vowel box
+	Vowel geom.Rect `json:"vowel"`
+	// VowelBar is the thickness of the bars that make up the vowel box
+	VowelBar  float64 `json:"vowel_bar"`
+	Consonant Slot    `json:"consonant"`
+	Solo      Slot    `json:"solo"`
+}
+
+// Default returns the metrics of the reference template
+func Default() FontMetrics {
+	return FontMetrics{
+		EmSize:   1000,
+		Vowel:    geom.NewRect(20, 20, 960, 960),
+		VowelBar: 80,
+		Consonant: Slot{
+			Core:       geom.NewRect(140, 290, 330, 420),
+			Head:       geom.NewRect(140, 150, 330, 80),
+			Foot:       geom.NewRect(140, 770, 330, 80),
+			HeadCenter: geom.NewRect(230, 150, 150, 80),
+			FootCenter: geom.NewRect(230, 770, 150, 80),
+		},
+		Solo: Slot{
+			Core:       geom.NewRect(140, 290, 720, 420),
+			Head:       geom.NewRect(140, 150, 720, 80),
+			Foot:       geom.NewRect(140, 770, 720, 80),
+			HeadCenter: geom.NewRect(320, 150, 360, 80),
+			FootCenter: geom.NewRect(320, 770, 360, 80),
+		},
+	}
+}
+
+// VowelShift is the distance a vowel bar moves to reach the opposite side of the vowel box
+func (m FontMetrics) VowelShift() float64 {
+	return m.Vowel.Width() - m.VowelBar
+}
+
+// TopBar is the box of the vowel bar along the top of the vowel box
+func (m FontMetrics) TopBar() geom.Rect {
+	return geom.NewRect(m.Vowel.Min.X, m.Vowel.Min.Y, m.Vowel.Width(), m.VowelBar)
+}
+
+// BottomBar is the box of the vowel bar along the bottom of the vowel box
+func (m FontMetrics) BottomBar() geom.Rect {
+	return geom.NewRect(m.Vowel.Min.X, m.Vowel.Max.Y-m.VowelBar, m.Vowel.Width(), m.VowelBar)
+}
+
+// FinalShift is the distance an initial moves to the right to become a final
+func (m FontMetrics) FinalShift() float64 {
+	return m.EmSize - m.Consonant.Core.Max.X - m.Consonant.Core.Min.X
+}
+
+// VowelCenter is the point vowel strokes are mirrored around
+func (m FontMetrics) VowelCenter() geom.Point {
+	return m.Vowel.Center()
+}
+
+// FinalCenter is the point final strokes are mirrored around
+func (m FontMetrics) FinalCenter() geom.Point {
+	return m.Consonant.Center().Add(geom.Point{X: m.FinalShift()})
+}
+
+// Validate checks that every box in the metrics has an area
+func (m FontMetrics) Validate() error {
+	errs := []error{}
+	if m.EmSize <= 0 {
+		errs = append(errs, fmt.Errorf("em size must be positive but got %v", m.EmSize))
+	}
+
+	if m.VowelBar <= 0 || 2*m.VowelBar >= m.Vowel.Width() {
+		errs = append(errs, fmt.Errorf("vowel bar must be positive and fit in the vowel box but got %v", m.VowelBar))
+	}
+
+	boxes := []struct {
+		name string
+		box  geom.Rect
+	}{
+		{"vowel", m.Vowel},
+		{"consonant core", m.Consonant.Core},
+		{"consonant head", m.Consonant.Head},
+		{"consonant foot", m.Consonant.Foot},
+		{"solo core", m.Solo.Core},
+		{"solo head", m.Solo.Head},
+		{"solo foot", m.Solo.Foot},
+	}
+	for _, b := range boxes {
+		if b.box.Empty() {
+			errs = append(errs, fmt.Errorf("the %s box is empty", b.name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// SidecarPath returns the path of the json sidecar file for a template, the sidecar file
+// for font.svg is font.metrics.json
+func SidecarPath(svgPath string) string {
+	return strings.TrimSuffix(svgPath, filepath.Ext(svgPath)) + ".metrics.json"
+}
+
+// LoadFile loads the metrics from a json sidecar file, or from the Layout layer of an svg template
+func LoadFile(path string) (FontMetrics, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return FontMetrics{}, err
+	}
+	defer f.Close()
+
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		ret := FontMetrics{}
+		if err := json.NewDecoder(f).Decode(&ret); err != nil {
+			return FontMetrics{}, fmt.Errorf("%s: %w", path, err)
+		}
+
+		if err := ret.Validate(); err != nil {
+			return FontMetrics{}, fmt.Errorf("%s: %w", path, err)
+		}
+
+		return ret, nil
+	}
+
+	root, err := svgparser.Parse(f, true)
+	if err != nil {
+		return FontMetrics{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	ret, err := Load(root)
+	if err != nil {
+		return FontMetrics{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return ret, nil
+}
+
+// Load reads the metrics from the Layout layer of a template. The layer has a vowel group with an
+// inner path that outlines the vowel box, and a consonant and solo group with core, head, foot,
+// head_center and foot_center boxes
+func Load(root *svgparser.Element) (FontMetrics, error) {
+	layout := child(root, "Layout")
+	if layout == nil {
+		return FontMetrics{}, errors.New("template has no Layout layer")
+	}
+
+	ret := FontMetrics{EmSize: emSize(root)}
+
+	inner, err := shape(layout, "vowel", "inner")
+	if err != nil {
+		return FontMetrics{}, err
+	}
+
+	// the outer contour of the inner path is the edge of the vowel box, and the inner contour
+	// is the inside edge of the bars
+	if len(inner) != 2 {
+		return FontMetrics{}, fmt.Errorf("Layout/vowel/inner must have an outer and an inner contour but has %d contours", len(inner))
+	}
+
+	outer, _ := inner[:1].Bounds()
+	hole, _ := inner[1:].Bounds()
+	if hole.Width() > outer.Width() {
+		outer, hole = hole, outer
+	}
+	ret.Vowel = outer
+	ret.VowelBar = hole.Min.X - outer.Min.X
+
+	slots := []struct {
+		name string
+		slot *Slot
+	}{
+		{"consonant", &ret.Consonant},
+		{"solo", &ret.Solo},
+	}
+	for _, s := range slots {
+		boxes := []struct {
+			label string
+			box   *geom.Rect
+		}{
+			{"core", &s.slot.Core},
+			{"head", &s.slot.Head},
+			{"foot", &s.slot.Foot},
+			{"head_center", &s.slot.HeadCenter},
+			{"foot_center", &s.slot.FootCenter},
+		}
+
+		for _, b := range boxes {
+			p, err := shape(layout, s.name, b.label)
+			if err != nil {
+				return FontMetrics{}, err
+			}
+
+			bounds, ok := p.Bounds()
+			if !ok {
+				return FontMetrics{}, fmt.Errorf("Layout/%s/%s has no shape", s.name, b.label)
+			}
+			*b.box = bounds
+		}
+	}
+
+	if err := ret.Validate(); err != nil {
+		return FontMetrics{}, err
+	}
+
+	return ret, nil
+}
+
+// emSize returns the width of the template's view box
+func emSize(root *svgparser.Element) float64 {
+	fields := strings.Fields(strings.ReplaceAll(root.Attributes["viewBox"], ",", " "))
+	if len(fields) == 4 {
+		if f, err := strconv.ParseFloat(fields[2], 64); err == nil {
+			return f
+		}
+	}
+
+	f, _ := strconv.ParseFloat(strings.TrimSuffix(root.Attributes["width"], "px"), 64)
+	return f
+}
+
+// shape finds the labeled element under the layout layer and returns its shape with all the
+// transforms between the layer and the shape applied
+func shape(layout *svgparser.Element, labels ...string) (geom.Path, error) {
+	name := "Layout/" + strings.Join(labels, "/")
+
+	elem := layout
+	transform := linalg.Identity()
+	for _, label := range labels {
+		elem = child(elem, label)
+		if elem == nil {
+			return nil, fmt.Errorf("template is missing %s", name)
+		}
+
+		m, err := svg.ParseTransform(elem.Attributes["transform"])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		transform = linalg.MatMul(transform, m)
+	}
+
+	ret, err := svg.Shape(elem)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return ret.Transform(transform), nil
+}
+
+// child returns the first child of elem with the given label
+func child(elem *svgparser.Element, label string) *svgparser.Element {
+	for _, e := range elem.Children {
+		if e.Attributes["label"] == label {
+			return e
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JoshVarga/svgparser"
+)
+
+func TestLoadFile(t *testing.T) {
+	got, err := LoadFile("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	want := Default()
+	if !metricsEqual(got, want) {
+		t.Errorf("LoadFile() = %+v, want %+v", got, want)
+	}
+
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"vowel shift", got.VowelShift(), 880},
+		{"hang shift", got.Consonant.HangShift(), 140},
+		{"foot shift", got.Consonant.FootShift(), 620},
+		{"final shift", got.FinalShift(), 390},
+		{"consonant center", got.Consonant.Center().X, 305},
+		{"final center", got.FinalCenter().X, 695},
+		{"solo center", got.Solo.Center().X, 500},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if math.Abs(tt.got-tt.want) > 0.01 {
+				t.Errorf("%s = %v, want %v", tt.name, tt.got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFile_sidecar(t *testing.T) {
+	want := Default()
+	want.EmSize = 2000
+	want.Consonant.Head.Min.Y = 100
+
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "font.metrics.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("os.WriteFile() error = %v", err)
+	}
+
+	got, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	if !metricsEqual(got, want) {
+		t.Errorf("LoadFile() = %+v, want %+v", got, want)
+	}
+
+	if got.Consonant.HangShift() != 190 {
+		t.Errorf("HangShift() = %v, want 190", got.Consonant.HangShift())
+	}
+}
+
+func TestLoad_errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		svg     string
+		wantErr string
+	}{
+		{
+			"no layout",
+			`<svg viewBox="0 0 1000 1000"><g label="vowels"></g></svg>`,
+			"template has no Layout layer",
+		},
+		{
+			"missing group",
+			`<svg viewBox="0 0 1000 1000"><g label="Layout"><g label="vowel"><path label="inner" d="M 20,20 V 980 H 980 V 20 Z m 80,80 H 900 V 900 H 100 Z"/></g></g></svg>`,
+			"template is missing Layout/consonant/core",
+		},
+		{
+			"single vowel contour",
+			`<svg viewBox="0 0 1000 1000"><g label="Layout"><g label="vowel"><path label="inner" d="M 20,20 V 980 H 980 V 20 Z"/></g></g></svg>`,
+			"Layout/vowel/inner must have an outer and an inner contour but has 1 contours",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root, err := svgparser.Parse(strings.NewReader(tt.svg), true)
+			if err != nil {
+				t.Fatalf("svgparser.Parse() error = %v", err)
+			}
+
+			_, err = Load(root)
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Load() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// metricsEqual checks if two sets of metrics are equal within a small error margin
+func metricsEqual(a, b FontMetrics) bool {
+	da, _ := json.Marshal(a)
+	db, _ := json.Marshal(b)
+
+	var va, vb map[string]any
+	json.Unmarshal(da, &va)
+	json.Unmarshal(db, &vb)
+
+	return valuesEqual(va, vb)
+}
+
+func valuesEqual(a, b any) bool {
+	switch a := a.(type) {
+	case map[string]any:
+		b, ok := b.(map[string]any)
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k := range a {
+			if !valuesEqual(a[k], b[k]) {
+				return false
+			}
+		}
+		return true
+	case float64:
+		b, ok := b.(float64)
+		return ok && math.Abs(a-b) < 0.01
+	default:
+		return a == b
+	}
+}
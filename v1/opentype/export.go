@@ -0,0 +1,38 @@
+package opentype
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/bjatkin/silabex/font"
+	"github.com/bjatkin/silabex/linalg"
+)
+
+// Export compiles every glyph in the font into a TrueType font. Template units are scaled so a
+// full character is one em wide, and the top of the character sits on the ascender
+func Export(f *font.Font, info Info) ([]byte, error) {
+	info = info.withDefaults()
+
+	scale := float64(info.UnitsPerEm) / f.Metrics().EmSize
+	transform := linalg.MatMul(
+		linalg.Translate(0, float64(info.Ascender)),
+		linalg.Scale(scale, -scale),
+	)
+
+	glyphs := []Glyph{}
+	for _, g := range f.Glyphs() {
+		outline, err := g.Outline()
+		if err != nil {
+			return nil, fmt.Errorf("glyph %s: %w", g.Name, err)
+		}
+
+		glyphs = append(glyphs, Glyph{
+			Name:    g.Name,
+			Runes:   []rune{g.CodePoint},
+			Advance: int(math.Round(g.Advance * scale)),
+			Outline: outline.Transform(transform),
+		})
+	}
+
+	return Compile(info, glyphs)
+}
@@ -0,0 +1,725 @@
+package opentype
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bjatkin/silabex/geom"
+)
+
+// Info holds the names and vertical metrics of a compiled font
+type Info struct {
+	FamilyName string
+	StyleName  string
+	Version    string
+	UnitsPerEm int
+	Ascender   int
+	// Descender is the distance below the baseline and should be negative
+	Descender int
+}
+
+func (i Info) withDefaults() Info {
+	if i.FamilyName == "" {
+		i.FamilyName = "Silabex"
+	}
+	if i.StyleName == "" {
+		i.StyleName = "Regular"
+	}
+	if i.Version == "" {
+		i.Version = "1.0"
+	}
+	if i.UnitsPerEm == 0 {
+		i.UnitsPerEm = 1000
+	}
+	if i.Ascender == 0 && i.Descender == 0 {
+		i.Ascender = i.UnitsPerEm * 4 / 5
+		i.Descender = i.Ascender - i.UnitsPerEm
+	}
+
+	return i
+}
+
+// Glyph is a single glyph in the font. The outline is in font units with the y axis pointing up
+// and the origin on the baseline
+type Glyph struct {
+	Name    string
+	Runes   []rune
+	Advance int
+	Outline geom.Path
+}
+
+// quadTolerance is the max distance in font units between a cubic curve and its quadratic approximation
+const quadTolerance = 0.5
+
+// Compile builds a TrueType flavored OpenType font from the glyphs. A .notdef glyph is added as the
+// first glyph so glyph ids are one more than the glyph's index in glyphs
+func Compile(info Info, glyphs []Glyph) ([]byte, error) {
+	info = info.withDefaults()
+
+	if len(glyphs)+1 > math.MaxUint16 {
+		return nil, fmt.Errorf("fonts can only hold %d glyphs but got %d", math.MaxUint16-1, len(glyphs))
+	}
+
+	all := append([]Glyph{notdef(info)}, glyphs...)
+
+	encoded := []*simpleGlyph{}
+	for _, g := range all {
+		s, err := encodeGlyph(g)
+		if err != nil {
+			return nil, fmt.Errorf("glyph %s: %w", g.Name, err)
+		}
+		encoded = append(encoded, s)
+	}
+
+	cmap := map[rune]int{}
+	for id, g := range all {
+		for _, r := range g.Runes {
+			if other, ok := cmap[r]; ok {
+				return nil, fmt.Errorf("code point U+%04X is used by both %s and %s", r, all[other].Name, g.Name)
+			}
+			cmap[r] = id
+		}
+	}
+
+	glyf, loca := buildGlyf(encoded)
+	tables := map[string][]byte{
+		"OS/2": buildOS2(info, all, encoded, cmap),
+		"cmap": buildCmap(cmap),
+		"glyf": glyf,
+		"head": buildHead(info, encoded),
+		"hhea": buildHhea(info, all, encoded),
+		"hmtx": buildHmtx(all, encoded),
+		"loca": loca,
+		"maxp": buildMaxp(encoded),
+		"name": buildName(info),
+		"post": buildPost(),
+	}
+
+	return assemble(tables), nil
+}
+
+// notdef draws the hollow box shown for missing characters
+func notdef(info Info) Glyph {
+	width := info.UnitsPerEm / 2
+	height := info.Ascender
+	stroke := float64(info.UnitsPerEm) / 20
+
+	rect := func(x0, y0, x1, y1 float64, clockwise bool) geom.Contour {
+		points := []geom.Point{{X: x1, Y: y0}, {X: x1, Y: y1}, {X: x0, Y: y1}}
+		if clockwise {
+			points = []geom.Point{{X: x0, Y: y1}, {X: x1, Y: y1}, {X: x1, Y: y0}}
+		}
+
+		contour := geom.Contour{Start: geom.Point{X: x0, Y: y0}, Closed: true}
+		for _, p := range points {
+			contour.Segments = append(contour.Segments, geom.Line(p))
+		}
+		return contour
+	}
+
+	w, h := float64(width), float64(height)
+	return Glyph{
+		Name:    ".notdef",
+		Advance: width,
+		Outline: geom.Path{
+			rect(stroke, 0, w-stroke, h, true),
+			rect(2*stroke, stroke, w-2*stroke, h-stroke, false),
+		},
+	}
+}
+
+type glyphPoint struct {
+	x, y    int
+	onCurve bool
+}
+
+// simpleGlyph is a glyph converted into TrueType contours
+type simpleGlyph struct {
+	contours               [][]glyphPoint
+	xMin, yMin, xMax, yMax int
+	points                 int
+}
+
+func (s *simpleGlyph) empty() bool {
+	return len(s.contours) == 0
+}
+
+func encodeGlyph(g Glyph) (*simpleGlyph, error) {
+	s := &simpleGlyph{
+		xMin: math.MaxInt, yMin: math.MaxInt,
+		xMax: math.MinInt, yMax: math.MinInt,
+	}
+
+	for _, c := range g.Outline.QuadApprox(quadTolerance) {
+		if len(c.Segments) == 0 {
+			continue
+		}
+
+		contour := []glyphPoint{toGlyphPoint(c.Start, true)}
+		for _, seg := range c.Segments {
+			switch seg.Op {
+			case geom.LineTo:
+				contour = append(contour, toGlyphPoint(seg.Points[0], true))
+			case geom.QuadTo:
+				contour = append(contour, toGlyphPoint(seg.Points[0], false), toGlyphPoint(seg.Points[1], true))
+			default:
+				return nil, fmt.Errorf("unexpected cubic segment after quadratic conversion")
+			}
+		}
+
+		// contours are always closed so a final point on top of the start point is redundant
+		if len(contour) > 1 && contour[len(contour)-1] == contour[0] {
+			contour = contour[:len(contour)-1]
+		}
+
+		for _, p := range contour {
+			if p.x < math.MinInt16 || p.x > math.MaxInt16 || p.y < math.MinInt16 || p.y > math.MaxInt16 {
+				return nil, fmt.Errorf("point (%d, %d) is outside the range of font coordinates", p.x, p.y)
+			}
+
+			s.xMin, s.yMin = min(s.xMin, p.x), min(s.yMin, p.y)
+			s.xMax, s.yMax = max(s.xMax, p.x), max(s.yMax, p.y)
+		}
+
+		s.contours = append(s.contours, contour)
+		s.points += len(contour)
+	}
+
+	if s.empty() {
+		s.xMin, s.yMin, s.xMax, s.yMax = 0, 0, 0, 0
+	}
+
+	return s, nil
+}
+
+func toGlyphPoint(p geom.Point, onCurve bool) glyphPoint {
+	return glyphPoint{x: int(math.Round(p.X)), y: int(math.Round(p.Y)), onCurve: onCurve}
+}
+
+const (
+	flagOnCurve  = 0x01
+	flagXShort   = 0x02
+	flagYShort   = 0x04
+	flagRepeat   = 0x08
+	flagXSame    = 0x10
+	flagYSame    = 0x20
+	maxShortSize = 255
+)
+
+// bytes encodes the glyph in the simple glyph format of the glyf table
+func (s *simpleGlyph) bytes() []byte {
+	if s.empty() {
+		return nil
+	}
+
+	w := &writer{}
+	w.i16(len(s.contours))
+	w.i16(s.xMin)
+	w.i16(s.yMin)
+	w.i16(s.xMax)
+	w.i16(s.yMax)
+
+	end := -1
+	for _, c := range s.contours {
+		end += len(c)
+		w.u16(end)
+	}
+
+	// no hinting instructions
+	w.u16(0)
+
+	flags := []byte{}
+	xs, ys := &writer{}, &writer{}
+	lastX, lastY := 0, 0
+	for _, c := range s.contours {
+		for _, p := range c {
+			var flag byte
+			if p.onCurve {
+				flag |= flagOnCurve
+			}
+
+			flag |= encodeDelta(xs, p.x-lastX, flagXShort, flagXSame)
+			flag |= encodeDelta(ys, p.y-lastY, flagYShort, flagYSame)
+			lastX, lastY = p.x, p.y
+
+			flags = append(flags, flag)
+		}
+	}
+
+	w.bytes(flags)
+	w.bytes(xs.buf)
+	w.bytes(ys.buf)
+	w.pad(4)
+
+	return w.buf
+}
+
+// encodeDelta writes a single coordinate delta and returns the flags that describe how it was written
+func encodeDelta(w *writer, delta int, short, same byte) byte {
+	switch {
+	case delta == 0:
+		return same
+	case delta > 0 && delta <= maxShortSize:
+		w.u8(delta)
+		return short | same
+	case delta < 0 && delta >= -maxShortSize:
+		w.u8(-delta)
+		return short
+	default:
+		w.i16(delta)
+		return 0
+	}
+}
+
+func buildGlyf(glyphs []*simpleGlyph) (glyf, loca []byte) {
+	g, l := &writer{}, &writer{}
+	for _, s := range glyphs {
+		l.u32(len(g.buf))
+		g.bytes(s.bytes())
+	}
+	l.u32(len(g.buf))
+
+	return g.buf, l.buf
+}
+
+func buildHead(info Info, glyphs []*simpleGlyph) []byte {
+	xMin, yMin, xMax, yMax := bounds(glyphs)
+
+	w := &writer{}
+	w.u32(0x00010000) // version
+	w.u32(fixed(info.Version))
+	w.u32(0) // checkSumAdjustment, filled in once the font is assembled
+	w.u32(0x5F0F3CF5)
+	w.u16(0x000B) // baseline at y=0, left sidebearing at x=0, integer scaling
+	w.u16(info.UnitsPerEm)
+	w.u64(0) // created
+	w.u64(0) // modified
+	w.i16(xMin)
+	w.i16(yMin)
+	w.i16(xMax)
+	w.i16(yMax)
+	w.u16(0) // macStyle
+	w.u16(8) // lowestRecPPEM
+	w.i16(2) // fontDirectionHint
+	w.i16(1) // indexToLocFormat, long offsets
+	w.i16(0) // glyphDataFormat
+
+	return w.buf
+}
+
+func buildHhea(info Info, all []Glyph, glyphs []*simpleGlyph) []byte {
+	advanceMax, minLSB, minRSB, maxExtent := 0, math.MaxInt16, math.MaxInt16, 0
+	for i, g := range all {
+		advanceMax = max(advanceMax, g.Advance)
+		if glyphs[i].empty() {
+			continue
+		}
+
+		minLSB = min(minLSB, glyphs[i].xMin)
+		minRSB = min(minRSB, g.Advance-glyphs[i].xMax)
+		maxExtent = max(maxExtent, glyphs[i].xMax)
+	}
+
+	w := &writer{}
+	w.u32(0x00010000)
+	w.i16(info.Ascender)
+	w.i16(info.Descender)
+	w.i16(0) // lineGap
+	w.u16(advanceMax)
+	w.i16(minLSB)
+	w.i16(minRSB)
+	w.i16(maxExtent)
+	w.i16(1) // caretSlopeRise
+	w.i16(0) // caretSlopeRun
+	w.i16(0) // caretOffset
+	w.bytes(make([]byte, 8))
+	w.i16(0) // metricDataFormat
+	w.u16(len(all))
+
+	return w.buf
+}
+
+func buildHmtx(all []Glyph, glyphs []*simpleGlyph) []byte {
+	w := &writer{}
+	for i, g := range all {
+		w.u16(g.Advance)
+		w.i16(glyphs[i].xMin)
+	}
+
+	return w.buf
+}
+
+func buildMaxp(glyphs []*simpleGlyph) []byte {
+	maxPoints, maxContours := 0, 0
+	for _, g := range glyphs {
+		maxPoints = max(maxPoints, g.points)
+		maxContours = max(maxContours, len(g.contours))
+	}
+
+	w := &writer{}
+	w.u32(0x00010000)
+	w.u16(len(glyphs))
+	w.u16(maxPoints)
+	w.u16(maxContours)
+	w.u16(0) // maxCompositePoints
+	w.u16(0) // maxCompositeContours
+	w.u16(2) // maxZones
+	w.u16(0) // maxTwilightPoints
+	w.u16(0) // maxStorage
+	w.u16(0) // maxFunctionDefs
+	w.u16(0) // maxInstructionDefs
+	w.u16(0) // maxStackElements
+	w.u16(0) // maxSizeOfInstructions
+	w.u16(0) // maxComponentElements
+	w.u16(0) // maxComponentDepth
+
+	return w.buf
+}
+
+func buildOS2(info Info, all []Glyph, glyphs []*simpleGlyph, cmap map[rune]int) []byte {
+	total, count := 0, 0
+	for _, g := range all {
+		if g.Advance > 0 {
+			total += g.Advance
+			count++
+		}
+	}
+	avg := 0
+	if count > 0 {
+		avg = total / count
+	}
+
+	first, last := rune(0xFFFF), rune(0)
+	var rangeBits [4]uint32
+	for r := range cmap {
+		first, last = min(first, r), max(last, r)
+		switch {
+		case r >= 0xE000 && r <= 0xF8FF:
+			rangeBits[1] |= 1 << (60 - 32) // private use area
+		case r >= 0xF0000:
+			rangeBits[2] |= 1 << (90 - 64) // supplementary private use area
+		}
+	}
+	if len(cmap) == 0 {
+		first = 0
+	}
+
+	_, yMin, _, yMax := bounds(glyphs)
+	upm := info.UnitsPerEm
+
+	w := &writer{}
+	w.u16(4) // version
+	w.i16(avg)
+	w.u16(400) // usWeightClass
+	w.u16(5)   // usWidthClass
+	w.u16(0)   // fsType, installable embedding
+	w.i16(upm * 65 / 100)
+	w.i16(upm * 60 / 100)
+	w.i16(0)
+	w.i16(upm * 7 / 100)
+	w.i16(upm * 65 / 100)
+	w.i16(upm * 60 / 100)
+	w.i16(0)
+	w.i16(upm * 35 / 100)
+	w.i16(upm * 5 / 100)  // yStrikeoutSize
+	w.i16(upm * 25 / 100) // yStrikeoutPosition
+	w.i16(0)              // sFamilyClass
+	w.bytes(make([]byte, 10))
+	for _, bits := range rangeBits {
+		w.u32(int(bits))
+	}
+	w.bytes([]byte("NONE"))
+	w.u16(0x40) // fsSelection, regular
+	w.u16(int(min(first, 0xFFFF)))
+	w.u16(int(min(last, 0xFFFF)))
+	w.i16(info.Ascender)
+	w.i16(info.Descender)
+	w.i16(0) // sTypoLineGap
+	w.u16(max(info.Ascender, yMax))
+	w.u16(max(-info.Descender, -yMin))
+	w.u32(1) // latin 1 code page
+	w.u32(0)
+	w.i16(upm / 2) // sxHeight
+	w.i16(info.Ascender)
+	w.u16(0)  // usDefaultChar
+	w.u16(32) // usBreakChar
+	w.u16(1)  // usMaxContext
+
+	return w.buf
+}
+
+func buildPost() []byte {
+	w := &writer{}
+	w.u32(0x00030000) // version 3, no glyph names
+	w.u32(0)          // italicAngle
+	w.i16(-100)       // underlinePosition
+	w.i16(50)         // underlineThickness
+	w.u32(0)          // isFixedPitch
+	w.u32(0)
+	w.u32(0)
+	w.u32(0)
+	w.u32(0)
+
+	return w.buf
+}
+
+func buildName(info Info) []byte {
+	postscript := strings.ReplaceAll(info.FamilyName+"-"+info.StyleName, " ", "")
+	records := []struct {
+		id    int
+		value string
+	}{
+		{1, info.FamilyName},
+		{2, info.StyleName},
+		{3, postscript + ";" + info.Version},
+		{4, info.FamilyName + " " + info.StyleName},
+		{5, "Version " + info.Version},
+		{6, postscript},
+	}
+
+	strs := &writer{}
+	w := &writer{}
+	w.u16(0) // format
+	w.u16(len(records))
+	w.u16(6 + 12*len(records))
+	for _, r := range records {
+		encoded := utf16BE(r.value)
+		w.u16(3)     // windows platform
+		w.u16(1)     // unicode BMP encoding
+		w.u16(0x409) // english (US)
+		w.u16(r.id)
+		w.u16(len(encoded))
+		w.u16(len(strs.buf))
+		strs.bytes(encoded)
+	}
+	w.bytes(strs.buf)
+
+	return w.buf
+}
+
+func utf16BE(s string) []byte {
+	ret := []byte{}
+	for _, r := range s {
+		if r > 0xFFFF {
+			r -= 0x10000
+			ret = binary.BigEndian.AppendUint16(ret, uint16(0xD800+(r>>10)))
+			ret = binary.BigEndian.AppendUint16(ret, uint16(0xDC00+(r&0x3FF)))
+			continue
+		}
+		ret = binary.BigEndian.AppendUint16(ret, uint16(r))
+	}
+
+	return ret
+}
+
+// buildCmap maps code points to glyphs with a format 4 subtable for the basic multilingual plane
+// and a format 12 subtable that covers every code point
+func buildCmap(cmap map[rune]int) []byte {
+	runes := []rune{}
+	for r := range cmap {
+		runes = append(runes, r)
+	}
+	slices.Sort(runes)
+
+	type group struct {
+		start, end rune
+		glyph      int
+	}
+
+	groups := []group{}
+	for _, r := range runes {
+		if n := len(groups); n > 0 && groups[n-1].end+1 == r && groups[n-1].glyph+int(r-groups[n-1].start) == cmap[r] {
+			groups[n-1].end = r
+			continue
+		}
+		groups = append(groups, group{start: r, end: r, glyph: cmap[r]})
+	}
+
+	// format 4
+	bmp := []group{}
+	for _, g := range groups {
+		if g.start > 0xFFFE {
+			break
+		}
+		g.end = min(g.end, 0xFFFE)
+		bmp = append(bmp, g)
+	}
+	bmp = append(bmp, group{start: 0xFFFF, end: 0xFFFF, glyph: 0})
+
+	segments := len(bmp)
+	searchRange := 2 * (1 << int(math.Log2(float64(segments))))
+
+	f4 := &writer{}
+	f4.u16(4)
+	f4.u16(16 + 8*segments)
+	f4.u16(0) // language
+	f4.u16(segments * 2)
+	f4.u16(searchRange)
+	f4.u16(int(math.Log2(float64(searchRange / 2))))
+	f4.u16(segments*2 - searchRange)
+	for _, g := range bmp {
+		f4.u16(int(g.end))
+	}
+	f4.u16(0) // reservedPad
+	for _, g := range bmp {
+		f4.u16(int(g.start))
+	}
+	for _, g := range bmp {
+		if g.start == 0xFFFF {
+			f4.u16(1)
+			continue
+		}
+		f4.u16((g.glyph - int(g.start)) & 0xFFFF)
+	}
+	for range bmp {
+		f4.u16(0) // idRangeOffset
+	}
+
+	// format 12
+	f12 := &writer{}
+	f12.u16(12)
+	f12.u16(0)
+	f12.u32(16 + 12*len(groups))
+	f12.u32(0) // language
+	f12.u32(len(groups))
+	for _, g := range groups {
+		f12.u32(int(g.start))
+		f12.u32(int(g.end))
+		f12.u32(g.glyph)
+	}
+
+	w := &writer{}
+	w.u16(0) // version
+	w.u16(2)
+	w.u16(3)
+	w.u16(1)
+	w.u32(4 + 2*8)
+	w.u16(3)
+	w.u16(10)
+	w.u32(4 + 2*8 + len(f4.buf))
+	w.bytes(f4.buf)
+	w.bytes(f12.buf)
+
+	return w.buf
+}
+
+func bounds(glyphs []*simpleGlyph) (xMin, yMin, xMax, yMax int) {
+	xMin, yMin, xMax, yMax = math.MaxInt16, math.MaxInt16, math.MinInt16, math.MinInt16
+	found := false
+	for _, g := range glyphs {
+		if g.empty() {
+			continue
+		}
+
+		found = true
+		xMin, yMin = min(xMin, g.xMin), min(yMin, g.yMin)
+		xMax, yMax = max(xMax, g.xMax), max(yMax, g.yMax)
+	}
+
+	if !found {
+		return 0, 0, 0, 0
+	}
+
+	return xMin, yMin, xMax, yMax
+}
+
+// fixed converts a version string like 1.2 into a 16.16 fixed point number
+func fixed(version string) int {
+	f, _ := strconv.ParseFloat(version, 64)
+	return int(math.Round(f * 0x10000))
+}
+
+// assemble writes the table directory followed by every table
+func assemble(tables map[string][]byte) []byte {
+	tags := []string{}
+	for tag := range tables {
+		tags = append(tags, tag)
+	}
+	slices.Sort(tags)
+
+	count := len(tags)
+	entrySelector := int(math.Log2(float64(count)))
+	searchRange := (1 << entrySelector) * 16
+
+	w := &writer{}
+	w.u32(0x00010000)
+	w.u16(count)
+	w.u16(searchRange)
+	w.u16(entrySelector)
+	w.u16(count*16 - searchRange)
+
+	offset := 12 + 16*count
+	headOffset := 0
+	for _, tag := range tags {
+		data := tables[tag]
+		w.bytes([]byte(tag))
+		w.u32(int(checksum(data)))
+		w.u32(offset)
+		w.u32(len(data))
+
+		if tag == "head" {
+			headOffset = offset
+		}
+		offset += (len(data) + 3) &^ 3
+	}
+
+	for _, tag := range tags {
+		w.bytes(tables[tag])
+		w.pad(4)
+	}
+
+	adjustment := 0xB1B0AFBA - checksum(w.buf)
+	binary.BigEndian.PutUint32(w.buf[headOffset+8:], adjustment)
+
+	return w.buf
+}
+
+func checksum(data []byte) uint32 {
+	var sum uint32
+	for i := 0; i < len(data); i += 4 {
+		var word [4]byte
+		copy(word[:], data[i:])
+		sum += binary.BigEndian.Uint32(word[:])
+	}
+
+	return sum
+}
+
+// writer appends big endian values to a buffer
+type writer struct {
+	buf []byte
+}
+
+func (w *writer) u8(v int) {
+	w.buf = append(w.buf, byte(v))
+}
+
+func (w *writer) u16(v int) {
+	w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(v))
+}
+
+func (w *writer) i16(v int) {
+	w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(int16(v)))
+}
+
+func (w *writer) u32(v int) {
+	w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(v))
+}
+
+func (w *writer) u64(v int) {
+	w.buf = binary.BigEndian.AppendUint64(w.buf, uint64(v))
+}
+
+func (w *writer) bytes(b []byte) {
+	w.buf = append(w.buf, b...)
+}
+
+func (w *writer) pad(n int) {
+	for len(w.buf)%n != 0 {
+		w.buf = append(w.buf, 0)
+	}
+}
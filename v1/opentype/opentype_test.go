@@ -0,0 +1,217 @@
+package opentype
+
+import (
+	"testing"
+
+	"github.com/bjatkin/silabex/font"
+	"github.com/bjatkin/silabex/geom"
+	"github.com/bjatkin/silabex/pua"
+	"github.com/bjatkin/silabex/steno"
+)
+
+func square(x0, y0, x1, y1 float64) geom.Contour {
+	return geom.Contour{
+		Start: geom.Point{X: x0, Y: y0},
+		Segments: []geom.Segment{
+			geom.Line(geom.Point{X: x0, Y: y1}),
+			geom.Line(geom.Point{X: x1, Y: y1}),
+			geom.Line(geom.Point{X: x1, Y: y0}),
+		},
+		Closed: true,
+	}
+}
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name       string
+		glyphs     []Glyph
+		wantPoints []int
+	}{
+		{
+			"empty glyph",
+			[]Glyph{{Name: "space", Runes: []rune{' '}, Advance: 500}},
+			[]int{0},
+		},
+		{
+			"line glyph",
+			[]Glyph{{
+				Name:    "box",
+				Runes:   []rune{0xE000},
+				Advance: 1000,
+				Outline: geom.Path{square(100, -200, 900, 800)},
+			}},
+			[]int{4},
+		},
+		{
+			"curve glyph",
+			[]Glyph{{
+				Name:    "curve",
+				Runes:   []rune{0xE001, 0xE002},
+				Advance: 1000,
+				Outline: geom.Path{{
+					Start: geom.Point{X: 0, Y: 0},
+					Segments: []geom.Segment{
+						geom.Cubic(geom.Point{X: 0, Y: 500}, geom.Point{X: 1000, Y: 500}, geom.Point{X: 1000, Y: 0}),
+					},
+					Closed: true,
+				}},
+			}},
+			nil,
+		},
+		{
+			"supplementary plane",
+			[]Glyph{
+				{Name: "a", Runes: []rune{0xF0000}, Advance: 1000, Outline: geom.Path{square(0, 0, 10, 10)}},
+				{Name: "b", Runes: []rune{0x100000}, Advance: 0, Outline: geom.Path{square(0, 0, 10, 10), square(20, 20, 30, 30)}},
+			},
+			[]int{4, 8},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := Compile(Info{FamilyName: "Test"}, tt.glyphs)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+
+			got, err := Parse(data)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if got.Info.FamilyName != "Test" || got.Info.UnitsPerEm != 1000 {
+				t.Errorf("Parse() info = %+v", got.Info)
+			}
+
+			// the first glyph is always .notdef
+			if len(got.Glyphs) != len(tt.glyphs)+1 {
+				t.Fatalf("Parse() got %d glyphs, want %d", len(got.Glyphs), len(tt.glyphs)+1)
+			}
+
+			for i, want := range tt.glyphs {
+				g := got.Glyphs[i+1]
+				if g.Advance != want.Advance {
+					t.Errorf("glyph %s advance = %d, want %d", want.Name, g.Advance, want.Advance)
+				}
+
+				if len(g.Runes) != len(want.Runes) {
+					t.Errorf("glyph %s runes = %U, want %U", want.Name, g.Runes, want.Runes)
+				}
+				for _, r := range want.Runes {
+					found := false
+					for _, gr := range g.Runes {
+						found = found || gr == r
+					}
+					if !found {
+						t.Errorf("glyph %s is missing rune %U", want.Name, r)
+					}
+				}
+
+				if len(g.Outline) != len(want.Outline) {
+					t.Fatalf("glyph %s has %d contours, want %d", want.Name, len(g.Outline), len(want.Outline))
+				}
+
+				if tt.wantPoints == nil {
+					continue
+				}
+
+				points := 0
+				for _, c := range g.Outline {
+					points += len(c.Segments)
+				}
+				if points != tt.wantPoints[i] {
+					t.Errorf("glyph %s has %d points, want %d", want.Name, points, tt.wantPoints[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCompile_duplicateRune(t *testing.T) {
+	_, err := Compile(Info{}, []Glyph{
+		{Name: "a", Runes: []rune{0xE000}},
+		{Name: "b", Runes: []rune{0xE000}},
+	})
+	if err == nil {
+		t.Errorf("Compile() expected an error for a duplicate code point")
+	}
+}
+
+func TestExport(t *testing.T) {
+	f, err := font.NewFont("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("NewFont() error = %v", err)
+	}
+
+	data, err := Export(f, Info{})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	got, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	glyphs := f.Glyphs()
+	if len(got.Glyphs) != len(glyphs)+1 {
+		t.Fatalf("Parse() got %d glyphs, want %d", len(got.Glyphs), len(glyphs)+1)
+	}
+
+	for i, g := range glyphs {
+		parsed := got.Glyphs[i+1]
+		if len(parsed.Runes) != 1 || parsed.Runes[0] != g.CodePoint {
+			t.Errorf("glyph %s runes = %U, want %U", g.Name, parsed.Runes, g.CodePoint)
+		}
+
+		if g.Cluster == font.Vowel && parsed.Advance != 1000 {
+			t.Errorf("vowel %s advance = %d, want 1000", g.Name, parsed.Advance)
+		}
+	}
+}
+
+func TestExport_puaRoundTrip(t *testing.T) {
+	f, err := font.NewFont("../reference/font2.svg")
+	if err != nil {
+		t.Fatalf("NewFont() error = %v", err)
+	}
+
+	data, err := Export(f, Info{})
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	got, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	cmap := map[rune]bool{}
+	for _, g := range got.Glyphs {
+		for _, r := range g.Runes {
+			cmap[r] = true
+		}
+	}
+
+	// every initial with every vowel, and every final with and without an initial
+	syllables := []steno.Keys{}
+	for initial := 0; initial < 1<<8; initial++ {
+		for vowel := 0; vowel < 1<<4; vowel++ {
+			if initial != 0 || vowel != 0 {
+				syllables = append(syllables, pua.FromBanks(initial, vowel, 0))
+			}
+		}
+	}
+	for final := 1; final < 1<<10; final++ {
+		syllables = append(syllables, pua.FromBanks(0, 0, final), pua.FromBanks(0xFF, 0xF, final))
+	}
+
+	text, err := pua.String(syllables)
+	if err != nil {
+		t.Fatalf("String() error = %v", err)
+	}
+	for _, r := range text {
+		if !cmap[r] {
+			t.Fatalf("Export() cmap has no glyph for %U", r)
+		}
+	}
+}
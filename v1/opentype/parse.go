@@ -0,0 +1,372 @@
+package opentype
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/bjatkin/silabex/geom"
+)
+
+// Font is a parsed TrueType font
+type Font struct {
+	Info   Info
+	Glyphs []Glyph
+}
+
+// Parse reads a TrueType font. Only the tables and glyph formats written by Compile are supported,
+// which is enough to check compiled fonts without any outside tools
+func Parse(data []byte) (*Font, error) {
+	r := &reader{buf: data}
+	if r.u32(0) != 0x00010000 {
+		return nil, errors.New("not a TrueType font")
+	}
+
+	tables := map[string][]byte{}
+	count := r.u16(4)
+	for i := 0; i < count; i++ {
+		entry := 12 + 16*i
+		tag := string(r.slice(entry, 4))
+		offset, length := r.u32(entry+8), r.u32(entry+12)
+		if offset+length > len(data) {
+			return nil, fmt.Errorf("table %s extends past the end of the font", tag)
+		}
+
+		table := data[offset : offset+length]
+		sum := checksum(table)
+		if tag == "head" && length >= 12 {
+			sum -= binary.BigEndian.Uint32(table[8:])
+		}
+		if sum != uint32(r.u32(entry+4)) {
+			return nil, fmt.Errorf("table %s has an invalid checksum", tag)
+		}
+
+		tables[tag] = table
+	}
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	if checksum(data) != 0xB1B0AFBA {
+		return nil, errors.New("font has an invalid checksum adjustment")
+	}
+
+	for _, tag := range []string{"cmap", "glyf", "head", "hhea", "hmtx", "loca", "maxp", "name"} {
+		if _, ok := tables[tag]; !ok {
+			return nil, fmt.Errorf("font is missing the required %s table", tag)
+		}
+	}
+
+	head := &reader{buf: tables["head"]}
+	hhea := &reader{buf: tables["hhea"]}
+	maxp := &reader{buf: tables["maxp"]}
+
+	font := &Font{
+		Info: Info{
+			UnitsPerEm: head.u16(18),
+			Ascender:   hhea.i16(4),
+			Descender:  hhea.i16(6),
+		},
+	}
+	font.Info.FamilyName, font.Info.StyleName = parseNames(tables["name"])
+
+	numGlyphs := maxp.u16(4)
+	numMetrics := hhea.u16(34)
+	longLoca := head.i16(50) == 1
+	if head.err != nil || hhea.err != nil || maxp.err != nil {
+		return nil, errors.Join(head.err, hhea.err, maxp.err)
+	}
+
+	cmap, err := parseCmap(tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+
+	hmtx := &reader{buf: tables["hmtx"]}
+	loca := &reader{buf: tables["loca"]}
+	for id := 0; id < numGlyphs; id++ {
+		var start, end int
+		if longLoca {
+			start, end = loca.u32(4*id), loca.u32(4*id+4)
+		} else {
+			start, end = loca.u16(2*id)*2, loca.u16(2*id+2)*2
+		}
+
+		advance := hmtx.u16(4 * min(id, numMetrics-1))
+		if loca.err != nil || hmtx.err != nil {
+			return nil, fmt.Errorf("glyph %d: %w", id, errors.Join(loca.err, hmtx.err))
+		}
+
+		if start > end || end > len(tables["glyf"]) {
+			return nil, fmt.Errorf("glyph %d has an invalid location", id)
+		}
+
+		outline, err := parseGlyph(tables["glyf"][start:end])
+		if err != nil {
+			return nil, fmt.Errorf("glyph %d: %w", id, err)
+		}
+
+		font.Glyphs = append(font.Glyphs, Glyph{
+			Advance: advance,
+			Outline: outline,
+		})
+	}
+
+	for r, id := range cmap {
+		if id >= len(font.Glyphs) {
+			return nil, fmt.Errorf("code point U+%04X maps to missing glyph %d", r, id)
+		}
+		font.Glyphs[id].Runes = append(font.Glyphs[id].Runes, r)
+	}
+
+	return font, nil
+}
+
+// parseNames returns the family and style name from the name table
+func parseNames(data []byte) (family, style string) {
+	r := &reader{buf: data}
+	count, strings := r.u16(2), r.u16(4)
+	for i := 0; i < count; i++ {
+		record := 6 + 12*i
+		if r.u16(record) != 3 {
+			continue
+		}
+
+		raw := r.slice(strings+r.u16(record+10), r.u16(record+8))
+		value := []rune{}
+		for j := 0; j+1 < len(raw); j += 2 {
+			value = append(value, rune(binary.BigEndian.Uint16(raw[j:])))
+		}
+
+		switch r.u16(record + 6) {
+		case 1:
+			family = string(value)
+		case 2:
+			style = string(value)
+		}
+	}
+
+	return family, style
+}
+
+// parseCmap reads the first format 12 subtable, or the first format 4 subtable if there is no format 12 subtable
+func parseCmap(data []byte) (map[rune]int, error) {
+	r := &reader{buf: data}
+	count := r.u16(2)
+
+	f4, f12 := -1, -1
+	for i := 0; i < count; i++ {
+		offset := r.u32(4 + 8*i + 4)
+		switch r.u16(offset) {
+		case 4:
+			if f4 < 0 {
+				f4 = offset
+			}
+		case 12:
+			if f12 < 0 {
+				f12 = offset
+			}
+		}
+	}
+
+	cmap := map[rune]int{}
+	switch {
+	case f12 >= 0:
+		groups := r.u32(f12 + 12)
+		for i := 0; i < groups; i++ {
+			group := f12 + 16 + 12*i
+			start, end, glyph := r.u32(group), r.u32(group+4), r.u32(group+8)
+			for c := start; c <= end; c++ {
+				cmap[rune(c)] = glyph + c - start
+			}
+		}
+	case f4 >= 0:
+		segments := r.u16(f4+6) / 2
+		ends := f4 + 14
+		starts := ends + 2*segments + 2
+		deltas := starts + 2*segments
+		offsets := deltas + 2*segments
+		for i := 0; i < segments; i++ {
+			start, end := r.u16(starts+2*i), r.u16(ends+2*i)
+			delta, rangeOffset := r.u16(deltas+2*i), r.u16(offsets+2*i)
+			for c := start; c <= end && c != 0xFFFF; c++ {
+				glyph := (c + delta) & 0xFFFF
+				if rangeOffset != 0 {
+					glyph = r.u16(offsets + 2*i + rangeOffset + 2*(c-start))
+					if glyph != 0 {
+						glyph = (glyph + delta) & 0xFFFF
+					}
+				}
+				cmap[rune(c)] = glyph
+			}
+		}
+	default:
+		return nil, errors.New("font has no supported cmap subtable")
+	}
+
+	if r.err != nil {
+		return nil, fmt.Errorf("cmap: %w", r.err)
+	}
+
+	return cmap, nil
+}
+
+// parseGlyph reads a simple glyph back into a path of lines and quadratic curves
+func parseGlyph(data []byte) (geom.Path, error) {
+	if len(data) == 0 {
+		return geom.Path{}, nil
+	}
+
+	r := &reader{buf: data}
+	contours := r.i16(0)
+	if contours < 0 {
+		return nil, errors.New("composite glyphs are not supported")
+	}
+
+	ends := []int{}
+	for i := 0; i < contours; i++ {
+		ends = append(ends, r.u16(10+2*i))
+	}
+
+	points := 0
+	if contours > 0 {
+		points = ends[contours-1] + 1
+	}
+
+	pos := 10 + 2*contours
+	pos += 2 + r.u16(pos)
+
+	flags := []byte{}
+	for len(flags) < points {
+		flag := byte(r.u8(pos))
+		pos++
+		flags = append(flags, flag)
+		if flag&flagRepeat != 0 {
+			repeat := r.u8(pos)
+			pos++
+			for i := 0; i < repeat; i++ {
+				flags = append(flags, flag)
+			}
+		}
+	}
+
+	readCoords := func(short, same byte) []int {
+		coords := []int{}
+		value := 0
+		for _, flag := range flags[:points] {
+			switch {
+			case flag&short != 0 && flag&same != 0:
+				value += r.u8(pos)
+				pos++
+			case flag&short != 0:
+				value -= r.u8(pos)
+				pos++
+			case flag&same == 0:
+				value += r.i16(pos)
+				pos += 2
+			}
+			coords = append(coords, value)
+		}
+		return coords
+	}
+
+	xs := readCoords(flagXShort, flagXSame)
+	ys := readCoords(flagYShort, flagYSame)
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	path := geom.Path{}
+	start := 0
+	for _, end := range ends {
+		type point struct {
+			p       geom.Point
+			onCurve bool
+		}
+
+		pts := []point{}
+		for i := start; i <= end; i++ {
+			pts = append(pts, point{
+				p:       geom.Point{X: float64(xs[i]), Y: float64(ys[i])},
+				onCurve: flags[i]&flagOnCurve != 0,
+			})
+		}
+		start = end + 1
+
+		if len(pts) == 0 {
+			continue
+		}
+
+		// rotate the contour so it starts on an on curve point, a contour of only off curve
+		// points starts on the implied point between the first two points
+		first := 0
+		for first < len(pts) && !pts[first].onCurve {
+			first++
+		}
+		if first == len(pts) {
+			mid := point{p: pts[0].p.Lerp(pts[1%len(pts)].p, 0.5), onCurve: true}
+			pts = append([]point{mid}, pts...)
+			first = 0
+		}
+		pts = append(pts[first:], pts[:first]...)
+
+		contour := geom.Contour{Start: pts[0].p, Closed: true}
+		var control *geom.Point
+		for i := 1; i <= len(pts); i++ {
+			pt := pts[i%len(pts)]
+			switch {
+			case pt.onCurve && control == nil:
+				contour.Segments = append(contour.Segments, geom.Line(pt.p))
+			case pt.onCurve:
+				contour.Segments = append(contour.Segments, geom.Quad(*control, pt.p))
+				control = nil
+			case control != nil:
+				mid := control.Lerp(pt.p, 0.5)
+				contour.Segments = append(contour.Segments, geom.Quad(*control, mid))
+				p := pt.p
+				control = &p
+			default:
+				p := pt.p
+				control = &p
+			}
+		}
+
+		path = append(path, contour)
+	}
+
+	return path, nil
+}
+
+// reader reads big endian values out of a buffer, the first out of bounds read is kept in err
+type reader struct {
+	buf []byte
+	err error
+}
+
+func (r *reader) slice(offset, length int) []byte {
+	if offset < 0 || length < 0 || offset+length > len(r.buf) {
+		if r.err == nil {
+			r.err = fmt.Errorf("read of %d bytes at offset %d is out of bounds", length, offset)
+		}
+		return make([]byte, length)
+	}
+
+	return r.buf[offset : offset+length]
+}
+
+func (r *reader) u8(offset int) int {
+	return int(r.slice(offset, 1)[0])
+}
+
+func (r *reader) u16(offset int) int {
+	return int(binary.BigEndian.Uint16(r.slice(offset, 2)))
+}
+
+func (r *reader) i16(offset int) int {
+	return int(int16(binary.BigEndian.Uint16(r.slice(offset, 2))))
+}
+
+func (r *reader) u32(offset int) int {
+	return int(binary.BigEndian.Uint32(r.slice(offset, 4)))
+}
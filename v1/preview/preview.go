@@ -0,0 +1,322 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bjatkin/silabex/derive"
+	"github.com/bjatkin/silabex/dict"
+	"github.com/bjatkin/silabex/font"
+	"github.com/bjatkin/silabex/layout"
+	"github.com/bjatkin/silabex/steno"
+)
+
+// DefaultPoll is how often the template and rules are checked for changes when no poll is set
+const DefaultPoll = 500 * time.Millisecond
+
+// Config is what the preview server draws with
+type Config struct {
+	// Font is the path of the template svg
+	Font string
+	// Rules is the path of the derivation rules, the rules are loaded with LoadRules every time they
+	// change and build the parts the template does not draw, mistakes show up on the page. No rules
+	// are used if either is empty
+	Rules     string
+	LoadRules func(path string) ([]derive.Rule, error)
+	// Dicts are used to look up the words of text previews
+	Dicts   []*dict.Dictionary
+	Options layout.Options
+	// Poll is how often the files are checked for changes
+	Poll time.Duration
+}
+
+// Server serves previews of the font. The template and rules are watched and every open page is
+// reloaded when they change, a template that fails to load keeps the last font that loaded
+type Server struct {
+	cfg   Config
+	store *dict.Store
+
+	// mu guards everything below, the layout's glyph cache is only filled while it is held
+	mu      sync.Mutex
+	font    *font.Font
+	layout  *layout.Layout
+	errs    []string
+	version int
+	clients map[chan int]struct{}
+}
+
+// New creates a preview server and loads the font, an error is returned if the font does not load
+func New(cfg Config) (*Server, error) {
+	if cfg.Poll <= 0 {
+		cfg.Poll = DefaultPoll
+	}
+
+	s := &Server{
+		cfg:     cfg,
+		store:   dict.NewStore(cfg.Dicts...),
+		clients: map[chan int]struct{}{},
+	}
+
+	s.Reload()
+	if s.font == nil {
+		return nil, errors.New(s.errs[0])
+	}
+
+	return s, nil
+}
+
+// Reload loads the template and rules again and tells every open page to reload. Rules that fail to
+// load leave the font with only the parts drawn in the template
+func (s *Server) Reload() {
+	errs := []string{}
+	f, err := font.NewFont(s.cfg.Font)
+	if err != nil {
+		errs = append(errs, fmt.Sprintf("font: %v", err))
+	}
+
+	if s.cfg.Rules != "" && s.cfg.LoadRules != nil {
+		rules, err := s.cfg.LoadRules(s.cfg.Rules)
+		switch {
+		case err != nil:
+			errs = append(errs, fmt.Sprintf("rules: %v", err))
+		case f != nil:
+			f.Derive(rules)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f != nil {
+		s.font = f
+		s.layout = layout.New(f, s.store, s.cfg.Options)
+	}
+	s.errs = errs
+	s.version++
+
+	for c := range s.clients {
+		select {
+		case c <- s.version:
+		default:
+		}
+	}
+}
+
+// Watch polls the template and rules until the context is done and reloads when either changes
+func (s *Server) Watch(ctx context.Context) {
+	paths := []string{s.cfg.Font}
+	if s.cfg.Rules != "" {
+		paths = append(paths, s.cfg.Rules)
+	}
+	w := newWatcher(paths...)
+
+	ticker := time.NewTicker(s.cfg.Poll)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if w.poll() {
+				s.Reload()
+			}
+		}
+	}
+}
+
+// Handler returns the routes of the preview server
+//
+//	/                the index with forms for chords and text
+//	/char/{chord}    a single character
+//	/words           a page of ?chords=KOE/KWOE or ?text=hello
+//	/table           every glyph of the font
+//	/events          a server sent event stream that sends reload when the files change
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.index)
+	mux.HandleFunc("GET /char/{chord}", s.char)
+	mux.HandleFunc("GET /words", s.words)
+	mux.HandleFunc("GET /table", s.table)
+	mux.HandleFunc("GET /events", s.events)
+
+	return mux
+}
+
+// page is the data every page is drawn with
+type page struct {
+	Title  string
+	Errors []string
+	Chords string
+	Text   string
+	// SVG is drawn large, Page is drawn at the size it was set at
+	SVG     template.HTML
+	Page    template.HTML
+	Missing []string
+	Glyphs  []glyph
+}
+
+// glyph is a cell of the glyph table
+type glyph struct {
+	Name      string
+	CodePoint string
+	SVG       template.HTML
+}
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}} - silabex preview</title>
+<style>
+body { font-family: sans-serif; margin: 24px; }
+nav, form { display: flex; gap: 8px; align-items: center; margin-bottom: 12px; }
+.error { color: #b00020; white-space: pre-wrap; }
+.char svg { width: 320px; height: 320px; border: 1px solid #ccc; }
+.table { display: flex; flex-wrap: wrap; gap: 8px; }
+.table figure { margin: 0; width: 96px; text-align: center; font-size: 11px; }
+.table svg { width: 96px; height: 96px; border: 1px solid #eee; }
+</style>
+</head>
+<body>
+<nav><a href="/">silabex preview</a><a href="/table">glyph table</a></nav>
+<form action="/words"><input name="chords" size="40" placeholder="KOE/KWOE TEFT" value="{{.Chords}}"><button>chords</button></form>
+<form action="/words"><input name="text" size="40" placeholder="hello world" value="{{.Text}}"><button>text</button></form>
+{{range .Errors}}<p class="error">{{.}}</p>{{end}}
+{{with .SVG}}<div class="char">{{.}}</div>{{end}}
+{{with .Page}}<div class="page">{{.}}</div>{{end}}
+{{with .Missing}}<p class="error">missing from the dictionary: {{range $i, $w := .}}{{if $i}}, {{end}}{{$w}}{{end}}</p>{{end}}
+{{with .Glyphs}}<div class="table">{{range .}}<figure>{{.SVG}}<figcaption>{{.Name}}<br>{{.CodePoint}}</figcaption></figure>{{end}}</div>{{end}}
+<script>new EventSource("/events").addEventListener("reload", () => location.reload())</script>
+</body>
+</html>
+`))
+
+// render draws the page, the errors from the last reload are added to it. The lock must be held
+func (s *Server) render(w http.ResponseWriter, status int, p page) {
+	p.Errors = append(append([]string{}, s.errs...), p.Errors...)
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	pageTemplate.Execute(w, p)
+}
+
+func (s *Server) index(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.render(w, http.StatusOK, page{Title: "index"})
+}
+
+func (s *Server) char(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	chord := r.PathValue("chord")
+	k, err := steno.ParseKeys(chord)
+	if err != nil {
+		s.render(w, http.StatusBadRequest, page{Title: chord, Errors: []string{err.Error()}})
+		return
+	}
+
+	char, err := s.font.NewCharacter(k.Chord())
+	if err != nil {
+		s.render(w, http.StatusBadRequest, page{Title: chord, Errors: []string{err.Error()}})
+		return
+	}
+
+	s.render(w, http.StatusOK, page{
+		Title:  k.String(),
+		Chords: k.String(),
+		SVG:    template.HTML(char.SVG()),
+	})
+}
+
+func (s *Server) words(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := page{
+		Title:  "words",
+		Chords: r.URL.Query().Get("chords"),
+		Text:   r.URL.Query().Get("text"),
+	}
+
+	var set *layout.Page
+	if p.Chords != "" {
+		strokes, err := steno.ParseKeysList(p.Chords)
+		if err != nil {
+			p.Errors = append(p.Errors, err.Error())
+			s.render(w, http.StatusBadRequest, p)
+			return
+		}
+		set = s.layout.LayoutStrokes(strokes)
+	} else {
+		set = s.layout.Layout(p.Text)
+		p.Missing = set.Missing
+	}
+
+	svg, err := set.SVG()
+	if err != nil {
+		p.Errors = append(p.Errors, err.Error())
+		s.render(w, http.StatusInternalServerError, p)
+		return
+	}
+	p.Page = template.HTML(svg)
+
+	s.render(w, http.StatusOK, p)
+}
+
+func (s *Server) table(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p := page{Title: "glyph table"}
+	for _, g := range s.font.Glyphs() {
+		p.Glyphs = append(p.Glyphs, glyph{
+			Name:      g.Name,
+			CodePoint: fmt.Sprintf("%U", g.CodePoint),
+			SVG:       template.HTML(g.SVG()),
+		})
+	}
+
+	s.render(w, http.StatusOK, p)
+}
+
+// events streams a reload event to the page every time the font is reloaded
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	c := make(chan int, 1)
+	s.mu.Lock()
+	s.clients[c] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, c)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case version := <-c:
+			fmt.Fprintf(w, "event: reload\ndata: %d\n\n", version)
+			flusher.Flush()
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package preview
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bjatkin/silabex/derive"
+	"github.com/bjatkin/silabex/dict"
+	"github.com/bjatkin/silabex/layout"
+)
+
+func testServer(t *testing.T, fontPath string) *Server {
+	d, err := dict.LoadFile("../dict/testdata/main.json")
+	if err != nil {
+		t.Fatalf("LoadFile() error = %v", err)
+	}
+
+	s, err := New(Config{
+		Font:      fontPath,
+		Rules:     "../reference/derive.dat",
+		LoadRules: derive.LoadRules,
+		Dicts:     []*dict.Dictionary{d},
+		Options:   layout.DefaultOptions(),
+		Poll:      10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	return s
+}
+
+func TestServer_Handler(t *testing.T) {
+	s := testServer(t, "../reference/font2.svg")
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		want       string
+	}{
+		{"index", "/", http.StatusOK, "<form action=\"/words\">"},
+		{"live reload", "/", http.StatusOK, "new EventSource(\"/events\")"},
+		{"char", "/char/KOE", http.StatusOK, "<div class=\"char\"><svg width=\"1000\""},
+		{"bad char", "/char/XYZ", http.StatusBadRequest, "class=\"error\""},
+		{"chords", "/words?chords=KOE/KWOE+TEFT", http.StatusOK, "<div class=\"page\"><svg"},
+		{"bad chords", "/words?chords=KOE/XYZ", http.StatusBadRequest, "class=\"error\""},
+		{"text", "/words?text=hello+moon.", http.StatusOK, "<div class=\"page\"><svg"},
+		{"missing text", "/words?text=hello+zebra", http.StatusOK, "missing from the dictionary: zebra"},
+		{"table", "/table", http.StatusOK, "<figcaption>initial_TK<br>"},
+		{"not found", "/nothing", http.StatusNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("GET %s status = %d, want %d", tt.path, rec.Code, tt.wantStatus)
+			}
+			if !strings.Contains(rec.Body.String(), tt.want) {
+				t.Errorf("GET %s = %.300q, want it to contain %q", tt.path, rec.Body.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_Watch(t *testing.T) {
+	raw, err := os.ReadFile("../reference/font2.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fontPath := filepath.Join(t.TempDir(), "font.svg")
+	if err := os.WriteFile(fontPath, raw, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s := testServer(t, fontPath)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Watch(ctx)
+
+	server := httptest.NewServer(s.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/events")
+	if err != nil {
+		t.Fatalf("GET /events error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	// a template that no longer parses keeps the last font and shows the error
+	if err := os.WriteFile(fontPath, []byte("<svg"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	events := bufio.NewReader(resp.Body)
+	line, err := events.ReadString('\n')
+	if err != nil || line != "event: reload\n" {
+		t.Fatalf("GET /events = %q, %v, want a reload event", line, err)
+	}
+
+	for _, path := range []string{"/", "/char/KOE"} {
+		page, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s error = %v", path, err)
+		}
+		body, _ := io.ReadAll(page.Body)
+		page.Body.Close()
+
+		if page.StatusCode != http.StatusOK || !strings.Contains(string(body), "<p class=\"error\">font: ") {
+			t.Errorf("GET %s after a bad save = %d %.300q, want the font error", path, page.StatusCode, body)
+		}
+	}
+}
+
+func TestServer_Reload_rules(t *testing.T) {
+	raw, err := os.ReadFile("../reference/font2.svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := os.ReadFile("../reference/derive.dat")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the template leaves out the OU vowel so only the rules can draw it
+	dir := t.TempDir()
+	fontPath := filepath.Join(dir, "font.svg")
+	ou := `      <path
+         style="fill:#000000;stroke-linecap:square"
+         d="M 20,980 H 980 V 20 H 900 V 900 H 20 Z"
+         id="path203" />
+`
+	if !strings.Contains(string(raw), ou) {
+		t.Fatal("font2.svg has no OU vowel path")
+	}
+	if err := os.WriteFile(fontPath, []byte(strings.Replace(string(raw), ou, "", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rulesPath := filepath.Join(dir, "derive.dat")
+	if err := os.WriteFile(rulesPath, rules, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(Config{Font: fontPath, Rules: rulesPath, LoadRules: derive.LoadRules})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	char := func() string {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/char/OU", nil))
+		return rec.Body.String()
+	}
+
+	if !strings.Contains(char(), "<path") {
+		t.Errorf("GET /char/OU = %.300q, want the vowel built by the rules", char())
+	}
+
+	if err := os.WriteFile(rulesPath, []byte("// no rules\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s.Reload()
+	if strings.Contains(char(), "<path") {
+		t.Errorf("GET /char/OU = %.300q after the rules were removed, want no strokes", char())
+	}
+}
+
+func TestWatcher_poll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "font.svg")
+	if err := os.WriteFile(path, []byte("<svg/>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	w := newWatcher(path)
+
+	steps := []struct {
+		name  string
+		write string
+		want  bool
+	}{
+		{"unchanged", "", false},
+		{"saved", "<svg></svg>", false},
+		{"settled", "", true},
+		{"settled again", "", false},
+		{"removed", "-", false},
+		{"removal settled", "", true},
+	}
+
+	for _, step := range steps {
+		switch step.write {
+		case "":
+		case "-":
+			if err := os.Remove(path); err != nil {
+				t.Fatal(err)
+			}
+		default:
+			if err := os.WriteFile(path, []byte(step.write), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if got := w.poll(); got != step.want {
+			t.Errorf("%s: poll() = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
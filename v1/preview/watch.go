@@ -0,0 +1,71 @@
+package preview
+
+import (
+	"os"
+	"time"
+)
+
+// stamp is what is known about a file without reading it, a file has changed when its stamp changes
+type stamp struct {
+	exists bool
+	size   int64
+	mod    time.Time
+}
+
+func (s stamp) equal(t stamp) bool {
+	return s.exists == t.exists && s.size == t.size && s.mod.Equal(t.mod)
+}
+
+func stat(path string) stamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return stamp{}
+	}
+
+	return stamp{exists: true, size: info.Size(), mod: info.ModTime()}
+}
+
+// watcher polls files for changes. Polling works the same for editors that write the file in place
+// and editors like Inkscape that replace it, and it needs nothing outside the standard library
+type watcher struct {
+	paths  []string
+	stamps map[string]stamp
+	// pending is set when a file changed on the last poll, the change is only reported once the file
+	// stops changing so a file that is still being written is not read
+	pending bool
+}
+
+func newWatcher(paths ...string) *watcher {
+	w := &watcher{
+		paths:  paths,
+		stamps: map[string]stamp{},
+	}
+	for _, path := range paths {
+		w.stamps[path] = stat(path)
+	}
+
+	return w
+}
+
+// poll checks every file and reports true once files that changed have been stable for a poll
+func (w *watcher) poll() bool {
+	changed := false
+	for _, path := range w.paths {
+		s := stat(path)
+		if !s.equal(w.stamps[path]) {
+			w.stamps[path] = s
+			changed = true
+		}
+	}
+
+	switch {
+	case changed:
+		w.pending = true
+		return false
+	case w.pending:
+		w.pending = false
+		return true
+	default:
+		return false
+	}
+}
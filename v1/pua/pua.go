@@ -0,0 +1,275 @@
+package pua
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bjatkin/silabex/steno"
+)
+
+// Component code points live in the private use area of the basic multilingual plane. A syllable
+// is written as a vowel base followed by an initial (or solo) mark and a final mark, each indexed by
+// the keys of its bank
+const (
+	// VowelBase is the base for a syllable with no vowel keys, VowelBase+1 through VowelBase+15 have vowels
+	VowelBase rune = 0xE000
+	// InitialMark+i is the combining mark for initial index i in a syllable with finals
+	InitialMark rune = 0xE010
+	// SoloMark+i is the combining mark for initial index i in a syllable without finals
+	SoloMark rune = 0xE110
+	// FinalMark+i is the combining mark for final index i
+	FinalMark rune = 0xE210
+	// componentEnd is the first code point after the components
+	componentEnd rune = 0xE610
+)
+
+// the keys of each bank in the order of their index bits
+const (
+	initialKeys = "STKPWHR*"
+	vowelKeys   = "AOEU"
+	finalKeys   = "FRPBLGTSDZ"
+)
+
+// Kind is the role a code point plays in a syllable
+type Kind int
+
+const (
+	Unassigned Kind = iota
+	VowelComponent
+	InitialComponent
+	SoloComponent
+	FinalComponent
+)
+
+func (k Kind) String() string {
+	switch k {
+	case VowelComponent:
+		return "vowel"
+	case InitialComponent:
+		return "initial"
+	case SoloComponent:
+		return "solo"
+	case FinalComponent:
+		return "final"
+	default:
+		return "unassigned"
+	}
+}
+
+// Banks splits the keys of a syllable into an index for each bank. Bit i of an index is set when
+// key i of the bank is down, the asterisk is the last initial key
+func Banks(k steno.Keys) (initial, vowel, final int) {
+	c := k.Chord()
+	initial = index(initialKeys, c.Initial)
+	if c.Star {
+		initial |= 1 << 7
+	}
+
+	return initial, index(vowelKeys, c.Vowel), index(finalKeys, c.Final)
+}
+
+// FromBanks joins the bank indexes back into the keys of a syllable
+func FromBanks(initial, vowel, final int) steno.Keys {
+	c := steno.Chord{
+		Initial: keys(initialKeys[:7], initial),
+		Star:    initial&(1<<7) != 0,
+		Vowel:   keys(vowelKeys, vowel),
+		Final:   keys(finalKeys, final),
+	}
+
+	// the chord is built in steno order so it is always valid
+	ret, _ := c.Keys()
+	return ret
+}
+
+// index sets bit i for every key of the bank that is in down
+func index(bank, down string) int {
+	ret := 0
+	for i := range bank {
+		for j := range down {
+			if bank[i] == down[j] {
+				ret |= 1 << i
+			}
+		}
+	}
+
+	return ret
+}
+
+// keys lists the keys of the bank that are set in the index
+func keys(bank string, index int) string {
+	ret := ""
+	for i := range bank {
+		if index&(1<<i) != 0 {
+			ret += string(bank[i])
+		}
+	}
+
+	return ret
+}
+
+// validate checks that the keys are a syllable that can be written with code points
+func validate(k steno.Keys) error {
+	switch {
+	case k.Empty():
+		return errors.New("syllable has no keys")
+	case !k.Valid():
+		return fmt.Errorf("keys %#x are not a steno stroke", uint32(k))
+	case k&steno.NumberBar != 0:
+		return fmt.Errorf("number stroke %s has no code point", k)
+	}
+
+	return nil
+}
+
+// Decompose returns the component code points of a syllable. The vowel base always comes first,
+// the initial bank uses a solo mark when there are no finals and the marks of empty banks are left out
+func Decompose(k steno.Keys) ([]rune, error) {
+	if err := validate(k); err != nil {
+		return nil, err
+	}
+
+	initial, vowel, final := Banks(k)
+	ret := []rune{VowelBase + rune(vowel)}
+	switch {
+	case initial != 0 && final == 0:
+		ret = append(ret, SoloMark+rune(initial))
+	case initial != 0:
+		ret = append(ret, InitialMark+rune(initial))
+	}
+	if final != 0 {
+		ret = append(ret, FinalMark+rune(final))
+	}
+
+	return ret, nil
+}
+
+// Runes returns the code points text is written with, the same code points the exported font maps
+func Runes(k steno.Keys) ([]rune, error) {
+	return Decompose(k)
+}
+
+// Lookup returns the kind of a code point and the keys it stands for. Component keys only have
+// the keys of their own bank
+func Lookup(r rune) (Kind, steno.Keys) {
+	switch {
+	case r >= VowelBase && r < InitialMark:
+		return VowelComponent, FromBanks(0, int(r-VowelBase), 0)
+	case r > InitialMark && r < SoloMark:
+		return InitialComponent, FromBanks(int(r-InitialMark), 0, 0)
+	case r > SoloMark && r < FinalMark:
+		return SoloComponent, FromBanks(int(r-SoloMark), 0, 0)
+	case r > FinalMark && r < componentEnd:
+		return FinalComponent, FromBanks(0, 0, int(r-FinalMark))
+	default:
+		return Unassigned, 0
+	}
+}
+
+// Decode reads the syllable at the start of rs and returns its keys with the number of code points
+// it used. A syllable is a vowel base followed by its marks
+func Decode(rs []rune) (steno.Keys, int, error) {
+	if len(rs) == 0 {
+		return 0, 0, errors.New("no code points to decode")
+	}
+
+	kind, k := Lookup(rs[0])
+	switch kind {
+	case VowelComponent:
+	case Unassigned:
+		return 0, 0, fmt.Errorf("%U is not a silabex code point", rs[0])
+	default:
+		return 0, 0, fmt.Errorf("%s mark %U is not attached to a vowel base", kind, rs[0])
+	}
+
+	n := 1
+	mark := Unassigned
+	if n < len(rs) {
+		if kind, initial := Lookup(rs[n]); kind == InitialComponent || kind == SoloComponent {
+			mark = kind
+			k |= initial
+			n++
+		}
+	}
+
+	hasFinal := false
+	if n < len(rs) {
+		if kind, final := Lookup(rs[n]); kind == FinalComponent {
+			hasFinal = true
+			k |= final
+			n++
+		}
+	}
+
+	switch {
+	case mark == InitialComponent && !hasFinal:
+		return 0, 0, fmt.Errorf("initial mark %U must be followed by a final mark", rs[1])
+	case mark == SoloComponent && hasFinal:
+		return 0, 0, fmt.Errorf("solo mark %U can not be followed by a final mark", rs[1])
+	case k.Empty():
+		return 0, 0, fmt.Errorf("vowel base %U has no keys", rs[0])
+	}
+
+	return k, n, nil
+}
+
+// String returns the code points for a sequence of syllables
+func String(syllables []steno.Keys) (string, error) {
+	ret := []rune{}
+	for i, k := range syllables {
+		rs, err := Runes(k)
+		if err != nil {
+			return "", fmt.Errorf("syllable %d: %w", i, err)
+		}
+		ret = append(ret, rs...)
+	}
+
+	return string(ret), nil
+}
+
+// Parse decodes every syllable in s
+func Parse(s string) ([]steno.Keys, error) {
+	rs := []rune(s)
+	ret := []steno.Keys{}
+	for i := 0; i < len(rs); {
+		k, n, err := Decode(rs[i:])
+		if err != nil {
+			return nil, fmt.Errorf("code point %d: %w", i, err)
+		}
+		ret = append(ret, k)
+		i += n
+	}
+
+	return ret, nil
+}
+
+// Entry is a single row of the mapping table
+type Entry struct {
+	CodePoint rune
+	Kind      Kind
+	Keys      steno.Keys
+}
+
+// Table lists every assigned code point in order
+func Table() []Entry {
+	ret := []Entry{}
+	for r := VowelBase; r < componentEnd; r++ {
+		if kind, k := Lookup(r); kind != Unassigned {
+			ret = append(ret, Entry{CodePoint: r, Kind: kind, Keys: k})
+		}
+	}
+
+	return ret
+}
+
+// WriteTable writes the mapping table as tab seperated code point, kind and steno stroke columns
+func WriteTable(w io.Writer) error {
+	for _, e := range Table() {
+		if _, err := fmt.Fprintf(w, "%U\t%s\t%s\n", e.CodePoint, e.Kind, e.Keys); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package pua
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bjatkin/silabex/steno"
+)
+
+func mustKeys(t *testing.T, stroke string) steno.Keys {
+	t.Helper()
+	k, err := steno.ParseKeys(stroke)
+	if err != nil {
+		t.Fatalf("ParseKeys() error = %v", err)
+	}
+
+	return k
+}
+
+func TestRunes(t *testing.T) {
+	tests := []struct {
+		name    string
+		stroke  string
+		want    []rune
+		wantErr bool
+	}{
+		{"vowel", "A", []rune{VowelBase + 1}, false},
+		{"solo", "KA", []rune{VowelBase + 1, SoloMark + 4}, false},
+		{"star", "*", []rune{VowelBase, SoloMark + 0x80}, false},
+		{"one final", "KAT", []rune{VowelBase + 1, InitialMark + 4, FinalMark + 0x40}, false},
+		{"last final", "-Z", []rune{VowelBase, FinalMark + 0x200}, false},
+		{"decomposed", "KATS", []rune{VowelBase + 1, InitialMark + 4, FinalMark + 0xC0}, false},
+		{"decomposed without vowel", "-TS", []rune{VowelBase, FinalMark + 0xC0}, false},
+		{"decomposed star", "STKPWHRAO*EUFRPBLGTSDZ", []rune{VowelBase + 0xF, InitialMark + 0xFF, FinalMark + 0x3FF}, false},
+		{"number", "1-9", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Runes(mustKeys(t, tt.stroke))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Runes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Runes() = %U, want %U", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecompose(t *testing.T) {
+	tests := []struct {
+		name   string
+		stroke string
+		want   []rune
+	}{
+		{"vowel", "A", []rune{VowelBase + 1}},
+		{"solo", "KA", []rune{VowelBase + 1, SoloMark + 4}},
+		{"initial", "KAT", []rune{VowelBase + 1, InitialMark + 4, FinalMark + 0x40}},
+		{"final", "-T", []rune{VowelBase, FinalMark + 0x40}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decompose(mustKeys(t, tt.stroke))
+			if err != nil {
+				t.Fatalf("Decompose() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decompose() = %U, want %U", got, tt.want)
+			}
+
+			k, n, err := Decode(got)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if k != mustKeys(t, tt.stroke) || n != len(got) {
+				t.Errorf("Decode() = %v, %v, want %v, %v", k, n, tt.stroke, len(got))
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		rs      []rune
+		want    string
+		wantN   int
+		wantErr bool
+	}{
+		{"base followed by a syllable", []rune{VowelBase + 1, VowelBase + 2}, "A", 1, false},
+		{"empty", nil, "", 0, true},
+		{"not silabex", []rune("a"), "", 0, true},
+		{"mark without base", []rune{FinalMark + 1}, "", 0, true},
+		{"empty base", []rune{VowelBase}, "", 0, true},
+		{"initial without final", []rune{VowelBase + 1, InitialMark + 4}, "", 0, true},
+		{"solo with final", []rune{VowelBase + 1, SoloMark + 4, FinalMark + 0x40}, "", 0, true},
+		{"unassigned mark", []rune{VowelBase + 1, InitialMark}, "A", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n, err := Decode(tt.rs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got.String() != tt.want || n != tt.wantN {
+				t.Errorf("Decode() = %v, %v, want %v, %v", got, n, tt.want, tt.wantN)
+			}
+		})
+	}
+}
+
+func TestString_roundTrip(t *testing.T) {
+	chords, err := steno.ParseStrokes("HEL/HROE/WORLD/TAOEU/TPH*EU/-T/STKPWHRAO*EUFRPBLGTSDZ/S")
+	if err != nil {
+		t.Fatalf("ParseStrokes() error = %v", err)
+	}
+
+	syllables := []steno.Keys{}
+	for _, c := range chords {
+		k, _ := c.Keys()
+		syllables = append(syllables, k)
+	}
+
+	s, err := String(syllables)
+	if err != nil {
+		t.Fatalf("String() error = %v", err)
+	}
+
+	got, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, syllables) {
+		t.Errorf("Parse() = %v, want %v", got, syllables)
+	}
+}
+
+func TestTable(t *testing.T) {
+	table := Table()
+
+	// 16 vowel bases, 255 initial and solo marks and 1023 final marks
+	want := 16 + 255 + 255 + 1023
+	if len(table) != want {
+		t.Fatalf("Table() has %d entries, want %d", len(table), want)
+	}
+
+	seen := map[rune]bool{}
+	for _, e := range table {
+		if seen[e.CodePoint] {
+			t.Fatalf("Table() assigns %U twice", e.CodePoint)
+		}
+		seen[e.CodePoint] = true
+	}
+
+	buf := bytes.Buffer{}
+	if err := WriteTable(&buf); err != nil {
+		t.Fatalf("WriteTable() error = %v", err)
+	}
+	if !strings.Contains(buf.String(), "U+E001\tvowel\tA\n") {
+		t.Errorf("WriteTable() is missing the A row")
+	}
+}
@@ -0,0 +1,104 @@
+package raster
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"slices"
+
+	"github.com/bjatkin/silabex/geom"
+)
+
+// Tolerance is the max distance between a curve and the lines it is drawn with, in pixels
+const Tolerance = 0.1
+
+// Samples is the number of scanlines sampled in each row of pixels, coverage along the scanline is exact
+const Samples = 4
+
+// edge is a line of a flattened contour that goes up or down
+type edge struct {
+	a, b geom.Point
+	// dir is 1 for edges that go down and -1 for edges that go up
+	dir int
+}
+
+// crossing is the point where a scanline crosses an edge
+type crossing struct {
+	x   float64
+	dir int
+}
+
+// Fill draws the filled path in black on a white image. The path is in pixels and filled with the
+// nonzero rule, the edges of the shape are anti aliased
+func Fill(path geom.Path, width, height int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+
+	edges := []edge{}
+	for _, c := range path {
+		points := c.Flatten(Tolerance)
+		for i := range points {
+			a, b := points[i], points[(i+1)%len(points)]
+			switch {
+			case a.Y < b.Y:
+				edges = append(edges, edge{a: a, b: b, dir: 1})
+			case a.Y > b.Y:
+				edges = append(edges, edge{a: b, b: a, dir: -1})
+			}
+		}
+	}
+
+	coverage := make([]float64, width)
+	crossings := []crossing{}
+	for y := 0; y < height; y++ {
+		clear(coverage)
+		for s := 0; s < Samples; s++ {
+			sy := float64(y) + (float64(s)+0.5)/Samples
+
+			crossings = crossings[:0]
+			for _, e := range edges {
+				if sy < e.a.Y || sy >= e.b.Y {
+					continue
+				}
+				t := (sy - e.a.Y) / (e.b.Y - e.a.Y)
+				crossings = append(crossings, crossing{x: e.a.X + t*(e.b.X-e.a.X), dir: e.dir})
+			}
+			slices.SortFunc(crossings, func(a, b crossing) int {
+				switch {
+				case a.x < b.x:
+					return -1
+				case a.x > b.x:
+					return 1
+				default:
+					return 0
+				}
+			})
+
+			winding := 0
+			for i, c := range crossings {
+				winding += c.dir
+				if winding != 0 && i+1 < len(crossings) {
+					span(coverage, c.x, crossings[i+1].x)
+				}
+			}
+		}
+
+		for x, c := range coverage {
+			ink := min(c/Samples, 1)
+			img.SetGray(x, y, color.Gray{Y: uint8(math.Round(0xff * (1 - ink)))})
+		}
+	}
+
+	return img
+}
+
+// span adds the part of each pixel between x0 and x1 to the coverage of the row
+func span(coverage []float64, x0, x1 float64) {
+	x0 = max(x0, 0)
+	x1 = min(x1, float64(len(coverage)))
+	for px := int(x0); px < len(coverage) && float64(px) < x1; px++ {
+		coverage[px] += min(x1, float64(px+1)) - max(x0, float64(px))
+	}
+}
@@ -0,0 +1,47 @@
+package raster
+
+import (
+	"testing"
+
+	"github.com/bjatkin/silabex/geom"
+)
+
+// square returns a closed square contour, reversed squares wind the other way
+func square(x, y, size float64, reversed bool) geom.Contour {
+	pts := []geom.Point{{X: x + size, Y: y}, {X: x + size, Y: y + size}, {X: x, Y: y + size}}
+	if reversed {
+		pts = []geom.Point{{X: x, Y: y + size}, {X: x + size, Y: y + size}, {X: x + size, Y: y}}
+	}
+
+	segments := []geom.Segment{}
+	for _, p := range pts {
+		segments = append(segments, geom.Line(p))
+	}
+
+	return geom.Contour{Start: geom.Point{X: x, Y: y}, Segments: segments, Closed: true}
+}
+
+func TestFill(t *testing.T) {
+	tests := []struct {
+		name string
+		path geom.Path
+		x, y int
+		want uint8
+	}{
+		{"inside", geom.Path{square(2, 2, 4, false)}, 3, 3, 0x00},
+		{"outside", geom.Path{square(2, 2, 4, false)}, 7, 7, 0xff},
+		{"half covered edge", geom.Path{square(2.5, 2, 4, false)}, 2, 3, 0x80},
+		{"hole", geom.Path{square(0, 0, 8, false), square(2, 2, 4, true)}, 3, 3, 0xff},
+		{"nonzero overlap", geom.Path{square(0, 0, 8, false), square(2, 2, 4, false)}, 3, 3, 0x00},
+		{"clipped", geom.Path{square(-4, -4, 6, false)}, 0, 0, 0x00},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := Fill(tt.path, 8, 8)
+			if got := img.GrayAt(tt.x, tt.y).Y; got != tt.want {
+				t.Errorf("Fill() at %d,%d = %#x, want %#x", tt.x, tt.y, got, tt.want)
+			}
+		})
+	}
+}
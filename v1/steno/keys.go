@@ -0,0 +1,163 @@
+package steno
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Keys is a steno stroke packed into the low 23 bits of a uint32, one bit per key. Bit i is the
+// key at position i in Order, so comparing two Keys compares their strokes
+type Keys uint32
+
+// the bit for every key on the steno keyboard
+const (
+	NumberBar Keys = 1 << iota
+	LeftS
+	LeftT
+	LeftK
+	LeftP
+	LeftW
+	LeftH
+	LeftR
+	VowelA
+	VowelO
+	Star
+	VowelE
+	VowelU
+	RightF
+	RightR
+	RightP
+	RightB
+	RightL
+	RightG
+	RightT
+	RightS
+	RightD
+	RightZ
+)
+
+// AllKeys has every key on the steno keyboard down
+const AllKeys Keys = 1<<len(Order) - 1
+
+// ParseKeys parses a steno stroke into its packed keys
+func ParseKeys(stroke string) (Keys, error) {
+	c, err := Parse(stroke)
+	if err != nil {
+		return 0, err
+	}
+
+	return c.Keys()
+}
+
+// ParseKeysList parses steno strokes seperated by white space or slashes (e.g. "KOE/KWOE TEFT")
+func ParseKeysList(text string) ([]Keys, error) {
+	ret := []Keys{}
+	for _, field := range strings.Fields(text) {
+		for _, stroke := range strings.Split(field, "/") {
+			k, err := ParseKeys(stroke)
+			if err != nil {
+				return nil, err
+			}
+			ret = append(ret, k)
+		}
+	}
+
+	return ret, nil
+}
+
+// Union returns the keys that are down in either k or o
+func (k Keys) Union(o Keys) Keys {
+	return k | o
+}
+
+// Intersect returns the keys that are down in both k and o
+func (k Keys) Intersect(o Keys) Keys {
+	return k & o
+}
+
+// Difference returns the keys that are down in k but not in o
+func (k Keys) Difference(o Keys) Keys {
+	return k &^ o
+}
+
+// SubsetOf reports whether every key in k is also down in o
+func (k Keys) SubsetOf(o Keys) bool {
+	return k&^o == 0
+}
+
+// Len returns the number of keys that are down
+func (k Keys) Len() int {
+	return bits.OnesCount32(uint32(k))
+}
+
+// Empty reports whether no keys are down
+func (k Keys) Empty() bool {
+	return k == 0
+}
+
+// Valid reports whether only bits for keys on the steno keyboard are set
+func (k Keys) Valid() bool {
+	return k.SubsetOf(AllKeys)
+}
+
+// has reports whether the key at position i in Order is down
+func (k Keys) has(i int) bool {
+	return k&(1<<i) != 0
+}
+
+// Chord splits the keys into their banks, bits above the steno keyboard are ignored
+func (k Keys) Chord() Chord {
+	c := Chord{
+		Number: k&NumberBar != 0,
+		Star:   k&Star != 0,
+	}
+
+	for i := range Order {
+		if !k.has(i) {
+			continue
+		}
+
+		key := string(Order[i])
+		switch {
+		case Keys(1<<i) == NumberBar, Keys(1<<i) == Star:
+		case i >= rightStart:
+			c.Final += key
+		case i >= vowelStart:
+			c.Vowel += key
+		default:
+			c.Initial += key
+		}
+	}
+
+	return c
+}
+
+// String formats the keys as a canonical steno stroke
+func (k Keys) String() string {
+	if !k.Valid() {
+		return fmt.Sprintf("Keys(%#x)", uint32(k))
+	}
+
+	return k.Chord().String()
+}
+
+// MarshalText writes the keys as a canonical steno stroke
+func (k Keys) MarshalText() ([]byte, error) {
+	if !k.Valid() {
+		return nil, fmt.Errorf("keys %#x are not on the steno keyboard", uint32(k))
+	}
+
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText parses a steno stroke into the keys
+func (k *Keys) UnmarshalText(text []byte) error {
+	keys, err := ParseKeys(string(text))
+	if err != nil {
+		return err
+	}
+
+	*k = keys
+	return nil
+}
@@ -0,0 +1,157 @@
+package steno
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		stroke  string
+		want    Keys
+		wantErr bool
+	}{
+		{"word", "KAT", LeftK | VowelA | RightT, false},
+		{"left bank", "T-", LeftT, false},
+		{"right bank", "-T", RightT, false},
+		{"star", "*S", Star | RightS, false},
+		{"digits", "1-9", NumberBar | LeftS | RightT, false},
+		{"every key", "#STKPWHRAO*EUFRPBLGTSDZ", AllKeys, false},
+		{"invalid", "TAK-T", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKeys(tt.stroke)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseKeys() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("ParseKeys() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseKeysList(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []Keys
+		wantErr bool
+	}{
+		{"empty", "  ", []Keys{}, false},
+		{"slashes", "KAT/-T", []Keys{LeftK | VowelA | RightT, RightT}, false},
+		{"spaces and slashes", "KAT\n-T/T-", []Keys{LeftK | VowelA | RightT, RightT, LeftT}, false},
+		{"invalid", "KAT/TAK-T", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKeysList(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseKeysList() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseKeysList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeys_String(t *testing.T) {
+	tests := []struct {
+		name string
+		k    Keys
+		want string
+	}{
+		{"empty", 0, ""},
+		{"word", LeftK | VowelA | RightT, "KAT"},
+		{"banks", LeftT | RightS, "T-S"},
+		{"star", Star | RightT, "*T"},
+		{"digits", NumberBar | LeftT | VowelO, "20"},
+		{"number bar", NumberBar | LeftK, "#K"},
+		{"every key", AllKeys, "12K3W4R50*EU6R7B8G9SDZ"},
+		{"outside the keyboard", 1 << 23, "Keys(0x800000)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.k.String(); got != tt.want {
+				t.Errorf("Keys.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeys_Set(t *testing.T) {
+	kat := LeftK | VowelA | RightT
+	cat := LeftK | VowelA | RightT | RightS
+
+	if got := kat.Union(RightS); got != cat {
+		t.Errorf("Keys.Union() = %v, want %v", got, cat)
+	}
+	if got := cat.Intersect(LeftK | RightS | LeftS); got != LeftK|RightS {
+		t.Errorf("Keys.Intersect() = %v, want %v", got, LeftK|RightS)
+	}
+	if got := cat.Difference(kat); got != RightS {
+		t.Errorf("Keys.Difference() = %v, want %v", got, RightS)
+	}
+	if !kat.SubsetOf(cat) {
+		t.Errorf("Keys.SubsetOf() = false, want true")
+	}
+	if cat.SubsetOf(kat) {
+		t.Errorf("Keys.SubsetOf() = true, want false")
+	}
+	if got := cat.Len(); got != 4 {
+		t.Errorf("Keys.Len() = %v, want 4", got)
+	}
+	if got := AllKeys.Len(); got != len(Order) {
+		t.Errorf("Keys.Len() = %v, want %v", got, len(Order))
+	}
+}
+
+func TestKeys_Chord(t *testing.T) {
+	strokes := []string{"KAT", "T-S", "*T", "TAO*EU", "1-9", "#K", "STKPWHRAO*EUFRPBLGTSDZ", "-Z"}
+	for _, stroke := range strokes {
+		t.Run(stroke, func(t *testing.T) {
+			c, err := Parse(stroke)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			k, err := c.Keys()
+			if err != nil {
+				t.Fatalf("Chord.Keys() error = %v", err)
+			}
+
+			if got := k.Chord(); got != c {
+				t.Errorf("Keys.Chord() = %#v, want %#v", got, c)
+			}
+		})
+	}
+}
+
+func TestKeys_MarshalText(t *testing.T) {
+	want := map[Keys]string{LeftK | VowelA | RightT: "-T"}
+	data, err := json.Marshal(want)
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	if string(data) != `{"KAT":"-T"}` {
+		t.Errorf("json.Marshal() = %s, want %s", data, `{"KAT":"-T"}`)
+	}
+
+	got := map[Keys]string{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if len(got) != 1 || got[LeftK|VowelA|RightT] != "-T" {
+		t.Errorf("json.Unmarshal() = %v, want %v", got, want)
+	}
+
+	if _, err := Keys(1 << 30).MarshalText(); err == nil {
+		t.Errorf("Keys.MarshalText() error = nil, want an error")
+	}
+}
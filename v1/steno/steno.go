@@ -0,0 +1,211 @@
+package steno
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Order is the Plover steno order. The number bar comes first, then the left bank, the vowels
+// with the asterisk between them and the right bank
+const Order = "#STKPWHRAO*EUFRPBLGTSDZ"
+
+// positions of the banks in Order
+const (
+	numberKey  = 0
+	leftStart  = 1
+	vowelStart = 8
+	rightStart = 13
+)
+
+// digits maps the number keys to the position of the key they are written with when the number bar is down
+var digits = map[rune]int{
+	'1': 1,  // S-
+	'2': 2,  // T-
+	'3': 4,  // P-
+	'4': 6,  // H-
+	'5': 8,  // A
+	'0': 9,  // O
+	'6': 13, // -F
+	'7': 15, // -P
+	'8': 17, // -L
+	'9': 19, // -T
+}
+
+// Chord is a single steno stroke split into its banks. Each bank holds its keys in steno order
+type Chord struct {
+	Number  bool
+	Initial string
+	Star    bool
+	Vowel   string
+	Final   string
+}
+
+// Parse parses a single steno stroke (e.g. "KAT", "-T", "T-", "#S-T" or "1-9"). Keys must be written
+// in steno order, a hyphen seperates the left bank from the right bank when there are no vowels or
+// asterisk to do it, and digits stand for their keys with the number bar pressed
+func Parse(stroke string) (Chord, error) {
+	if stroke == "" {
+		return Chord{}, errors.New("empty steno stroke")
+	}
+
+	keys := Keys(0)
+	next := 0
+	hyphen := false
+	for i, r := range stroke {
+		switch {
+		case r == '-':
+			if hyphen {
+				return Chord{}, fmt.Errorf("stroke '%s' has more than one hyphen", stroke)
+			}
+			if next > rightStart {
+				return Chord{}, fmt.Errorf("hyphen at offset %d in stroke '%s' comes after right bank keys", i, stroke)
+			}
+			hyphen = true
+			next = rightStart
+		case digits[r] != 0:
+			pos := digits[r]
+			if pos < next {
+				return Chord{}, fmt.Errorf("number '%s' at offset %d in stroke '%s' is out of steno order", string(r), i, stroke)
+			}
+			keys |= NumberBar | 1<<pos
+			next = pos + 1
+		default:
+			if !strings.ContainsRune(Order, r) {
+				return Chord{}, fmt.Errorf("unknown steno key '%s' in stroke '%s'", string(r), stroke)
+			}
+
+			pos := strings.IndexRune(Order[next:], r)
+			if pos < 0 {
+				return Chord{}, fmt.Errorf("key '%s' at offset %d in stroke '%s' is out of steno order", string(r), i, stroke)
+			}
+			pos += next
+
+			if hyphen && pos < rightStart {
+				return Chord{}, fmt.Errorf("key '%s' at offset %d in stroke '%s' can not come after the hyphen", string(r), i, stroke)
+			}
+			keys |= 1 << pos
+			next = pos + 1
+		}
+	}
+
+	c := keys.Chord()
+	if c.Empty() {
+		return Chord{}, fmt.Errorf("stroke '%s' has no keys", stroke)
+	}
+
+	return c, nil
+}
+
+// ParseStrokes parses a multi stroke outline where the strokes are seperated by slashes (e.g. "HEL/HROE")
+func ParseStrokes(outline string) ([]Chord, error) {
+	ret := []Chord{}
+	for _, stroke := range strings.Split(outline, "/") {
+		c, err := Parse(stroke)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, c)
+	}
+
+	return ret, nil
+}
+
+// FormatStrokes formats the chords as a multi stroke outline
+func FormatStrokes(chords []Chord) string {
+	strokes := []string{}
+	for _, c := range chords {
+		strokes = append(strokes, c.String())
+	}
+
+	return strings.Join(strokes, "/")
+}
+
+// Keys returns the keys that are down in the chord, an error is returned if any bank of the
+// chord has keys that are not in that bank or are out of steno order
+func (c Chord) Keys() (Keys, error) {
+	keys := Keys(0)
+	if c.Number {
+		keys |= NumberBar
+	}
+	if c.Star {
+		keys |= Star
+	}
+
+	banks := []struct {
+		name       string
+		keys       string
+		start, end int
+	}{
+		{"initial", c.Initial, leftStart, vowelStart},
+		{"vowel", c.Vowel, vowelStart, rightStart},
+		{"final", c.Final, rightStart, len(Order)},
+	}
+
+	for _, b := range banks {
+		next := b.start
+		for _, r := range b.keys {
+			pos := strings.IndexRune(Order[next:b.end], r)
+			if r == '*' || pos < 0 {
+				return 0, fmt.Errorf("%s keys '%s' are not valid %s keys in steno order", b.name, b.keys, b.name)
+			}
+			keys |= 1 << (next + pos)
+			next += pos + 1
+		}
+	}
+
+	return keys, nil
+}
+
+// Validate checks that every bank of the chord only has its own keys in steno order
+func (c Chord) Validate() error {
+	_, err := c.Keys()
+	return err
+}
+
+// Empty reports whether no keys are down
+func (c Chord) Empty() bool {
+	return c == Chord{}
+}
+
+// String formats the chord the way Plover does. Keys with a number are written as digits when the
+// number bar is down, the number bar is only written when none of the keys have digits, and
+// a hyphen is added before the right bank when there are no vowels or asterisk
+func (c Chord) String() string {
+	keys, err := c.Keys()
+	if err != nil {
+		return "?"
+	}
+
+	written := map[int]string{}
+	for i := range Order {
+		if keys.has(i) {
+			written[i] = string(Order[i])
+		}
+	}
+
+	if c.Number {
+		replaced := false
+		for digit, pos := range digits {
+			if keys.has(pos) {
+				written[pos] = string(digit)
+				replaced = true
+			}
+		}
+		if replaced {
+			delete(written, numberKey)
+		}
+	}
+
+	middle := keys&(VowelA|VowelO|Star|VowelE|VowelU) != 0
+
+	b := strings.Builder{}
+	for i := range Order {
+		if i == rightStart && !middle && c.Final != "" {
+			b.WriteByte('-')
+		}
+		b.WriteString(written[i])
+	}
+
+	return b.String()
+}
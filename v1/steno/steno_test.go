@@ -0,0 +1,133 @@
+package steno
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		stroke  string
+		want    Chord
+		wantErr bool
+	}{
+		{"word", "KAT", Chord{Initial: "K", Vowel: "A", Final: "T"}, false},
+		{"left bank", "T-", Chord{Initial: "T"}, false},
+		{"left bank no hyphen", "T", Chord{Initial: "T"}, false},
+		{"right bank", "-T", Chord{Final: "T"}, false},
+		{"both banks", "T-T", Chord{Initial: "T", Final: "T"}, false},
+		{"order splits the banks", "TS", Chord{Initial: "T", Final: "S"}, false},
+		{"redundant hyphen", "TA-T", Chord{Initial: "T", Vowel: "A", Final: "T"}, false},
+		{"star", "*T", Chord{Star: true, Final: "T"}, false},
+		{"star between vowels", "AO*EU", Chord{Vowel: "AOEU", Star: true}, false},
+		{"number bar", "#S-T", Chord{Number: true, Initial: "S", Final: "T"}, false},
+		{"digits", "1-9", Chord{Number: true, Initial: "S", Final: "T"}, false},
+		{"digits with vowels", "1250", Chord{Number: true, Initial: "ST", Vowel: "AO"}, false},
+		{"full chord", "#STKPWHRAO*EUFRPBLGTSDZ", Chord{
+			Number: true, Initial: "STKPWHR", Star: true, Vowel: "AOEU", Final: "FRPBLGTSDZ",
+		}, false},
+		{"empty", "", Chord{}, true},
+		{"only a hyphen", "-", Chord{}, true},
+		{"unknown key", "KAX", Chord{}, true},
+		{"out of order", "TAK-T", Chord{}, true},
+		{"vowel after hyphen", "T-A", Chord{}, true},
+		{"hyphen after final", "TF-", Chord{}, true},
+		{"two hyphens", "T--T", Chord{}, true},
+		{"digit out of order", "91", Chord{}, true},
+		{"final out of order", "-RF", Chord{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.stroke)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChord_String(t *testing.T) {
+	tests := []struct {
+		name  string
+		chord Chord
+		want  string
+	}{
+		{"word", Chord{Initial: "K", Vowel: "A", Final: "T"}, "KAT"},
+		{"left bank", Chord{Initial: "T"}, "T"},
+		{"right bank", Chord{Final: "T"}, "-T"},
+		{"both banks", Chord{Initial: "T", Final: "S"}, "T-S"},
+		{"star", Chord{Star: true, Final: "T"}, "*T"},
+		{"star between vowels", Chord{Initial: "T", Vowel: "AOEU", Star: true}, "TAO*EU"},
+		{"digits", Chord{Number: true, Initial: "S", Final: "T"}, "1-9"},
+		{"digit vowel", Chord{Number: true, Vowel: "A", Final: "T"}, "59"},
+		{"number bar", Chord{Number: true, Initial: "K"}, "#K"},
+		{"number bar star", Chord{Number: true, Star: true}, "#*"},
+		{"invalid", Chord{Initial: "X"}, "?"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.chord.String(); got != tt.want {
+				t.Errorf("Chord.String() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChord_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		chord   Chord
+		wantErr bool
+	}{
+		{"valid", Chord{Initial: "STK", Vowel: "AE", Final: "FPLT"}, false},
+		{"empty", Chord{}, false},
+		{"final key in initial bank", Chord{Initial: "F"}, true},
+		{"initial out of order", Chord{Initial: "KT"}, true},
+		{"duplicate key", Chord{Vowel: "AA"}, true},
+		{"star in vowel bank", Chord{Vowel: "A*E"}, true},
+		{"final out of order", Chord{Final: "TF"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.chord.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Chord.Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseStrokes(t *testing.T) {
+	tests := []struct {
+		name    string
+		outline string
+		want    string
+		wantErr bool
+	}{
+		{"single stroke", "PHAOPB", "PHAOPB", false},
+		{"two strokes", "HEL/HROE", "HEL/HROE", false},
+		{"canonical form", "TAOEU/TPH*EU/TS", "TAOEU/TPH*EU/T-S", false},
+		{"numbers", "#S-T/#TAO", "1-9/250", false},
+		{"empty stroke", "HEL//HROE", "", true},
+		{"bad stroke", "HEL/XYZ", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chords, err := ParseStrokes(tt.outline)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseStrokes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := FormatStrokes(chords); got != tt.want {
+				t.Errorf("FormatStrokes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
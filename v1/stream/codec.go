@@ -0,0 +1,147 @@
+package stream
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Encoder turns the text form of a stream into encoded bytes. Text written to the encoder can be split
+// anywhere, the last stroke is held back until it ends so Close must be called to write it
+type Encoder struct {
+	w      io.Writer
+	parser textParser
+	buf    []byte
+	// offset is the number of text bytes that have been written
+	offset int64
+}
+
+// NewEncoder creates an encoder that writes encoded bytes to w
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Write encodes the text in p
+func (e *Encoder) Write(p []byte) (int, error) {
+	for i, c := range p {
+		if err := e.parser.next(c); err != nil {
+			return i, fmt.Errorf("offset %d: %w", e.offset+int64(i), err)
+		}
+	}
+	e.offset += int64(len(p))
+
+	if err := e.emit(); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Encode writes the tokens to the stream
+func (e *Encoder) Encode(tokens ...Token) error {
+	if err := e.Close(); err != nil {
+		return err
+	}
+
+	e.parser.tokens = append(e.parser.tokens, tokens...)
+	return e.emit()
+}
+
+// Close encodes the last stroke of the text, it does not close the underlying writer
+func (e *Encoder) Close() error {
+	if err := e.parser.flush(); err != nil {
+		return fmt.Errorf("offset %d: %w", e.offset, err)
+	}
+
+	return e.emit()
+}
+
+// emit encodes the tokens that have been parsed and writes them out
+func (e *Encoder) emit() error {
+	if len(e.parser.tokens) == 0 {
+		return nil
+	}
+
+	e.buf = e.buf[:0]
+	for _, t := range e.parser.tokens {
+		var err error
+		e.buf, err = AppendToken(e.buf, t)
+		if err != nil {
+			return err
+		}
+	}
+	e.parser.tokens = e.parser.tokens[:0]
+
+	_, err := e.w.Write(e.buf)
+	return err
+}
+
+// Decoder reads encoded bytes and turns them back into text or tokens
+type Decoder struct {
+	r *bufio.Reader
+	// offset is the number of encoded bytes that have been read
+	offset int64
+	// prev is the kind of the last token returned, it decides if a syllable needs a stroke seperator
+	prev Kind
+	text []byte
+	err  error
+}
+
+// NewDecoder creates a decoder that reads encoded bytes from r
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r), prev: WordBreak}
+}
+
+// Next reads the next token in the stream, io.EOF is returned at the end of the stream
+func (d *Decoder) Next() (Token, error) {
+	lead, err := d.r.ReadByte()
+	if err != nil {
+		return Token{}, err
+	}
+
+	n := tokenLen(lead)
+	buf := make([]byte, max(n, 1))
+	buf[0] = lead
+	if _, err := io.ReadFull(d.r, buf[1:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+			err = ErrIncomplete
+		}
+		return Token{}, fmt.Errorf("byte %d: %w", d.offset, err)
+	}
+
+	t, _, err := DecodeToken(buf)
+	if err != nil {
+		return Token{}, fmt.Errorf("byte %d: %w", d.offset, err)
+	}
+	d.offset += int64(len(buf))
+	d.prev = t.Kind
+
+	return t, nil
+}
+
+// Read decodes the stream into its text form
+func (d *Decoder) Read(p []byte) (int, error) {
+	for len(d.text) < len(p) && d.err == nil {
+		prev := d.prev
+		t, err := d.Next()
+		if err != nil {
+			// the text decoded before the error is returned first, the error comes back on the next read
+			d.err = err
+			break
+		}
+
+		if t.Kind == Syllable && prev == Syllable {
+			d.text = append(d.text, '/')
+		}
+		d.text = append(d.text, t.String()...)
+	}
+
+	if len(d.text) == 0 {
+		return 0, d.err
+	}
+
+	n := copy(p, d.text)
+	d.text = d.text[n:]
+	return n, nil
+}
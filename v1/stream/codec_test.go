@@ -0,0 +1,135 @@
+package stream
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+)
+
+func TestEncoder_Write(t *testing.T) {
+	tests := []struct {
+		name    string
+		writes  []string
+		want    string
+		wantErr bool
+	}{
+		{"one write", []string{"HEL/HROE WORLD."}, "HEL/HROE WORLD.", false},
+		{"split strokes", []string{"HE", "L/HR", "OE WOR", "LD", "."}, "HEL/HROE WORLD.", false},
+		{"last stroke", []string{"KAT ", "KAT"}, "KAT KAT", false},
+		{"bad stroke", []string{"KAT TAK-T"}, "", true},
+		{"trailing slash", []string{"KAT/"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			enc := NewEncoder(&buf)
+
+			var err error
+			for _, w := range tt.writes {
+				if _, err = enc.Write([]byte(w)); err != nil {
+					break
+				}
+			}
+			if err == nil {
+				err = enc.Close()
+			}
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Encoder.Write() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			want, _ := ParseText(tt.want)
+			wantData, _ := Encode(want)
+			if !bytes.Equal(buf.Bytes(), wantData) {
+				t.Errorf("Encoder.Write() = %#v, want %#v", buf.Bytes(), wantData)
+			}
+		})
+	}
+}
+
+func TestEncoder_Encode(t *testing.T) {
+	buf := bytes.Buffer{}
+	enc := NewEncoder(&buf)
+	if _, err := enc.Write([]byte("KAT")); err != nil {
+		t.Fatalf("Encoder.Write() error = %v", err)
+	}
+	if err := enc.Encode(Token{Kind: Punct, Punct: '!'}); err != nil {
+		t.Fatalf("Encoder.Encode() error = %v", err)
+	}
+
+	tokens, err := Decode(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got := FormatText(tokens); got != "KAT!" {
+		t.Errorf("Encoder.Encode() wrote %v, want KAT!", got)
+	}
+
+	if err := enc.Encode(Token{Kind: Punct, Punct: 'x'}); err == nil {
+		t.Errorf("Encoder.Encode() error = nil, want an error")
+	}
+}
+
+func TestDecoder_Read(t *testing.T) {
+	text := "HEL/HROE WORLD. \"TAOEU/TPH*EU\" 1-9!"
+	tokens, err := ParseText(text)
+	if err != nil {
+		t.Fatalf("ParseText() error = %v", err)
+	}
+	data, err := Encode(tokens)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	if err := iotest.TestReader(NewDecoder(bytes.NewReader(data)), []byte(text)); err != nil {
+		t.Errorf("Decoder.Read() %v", err)
+	}
+
+	got, err := io.ReadAll(NewDecoder(iotest.OneByteReader(bytes.NewReader(data))))
+	if err != nil {
+		t.Fatalf("Decoder.Read() error = %v", err)
+	}
+	if string(got) != text {
+		t.Errorf("Decoder.Read() = %v, want %v", string(got), text)
+	}
+}
+
+func TestDecoder_Next(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    string
+		wantEOF bool
+	}{
+		{"complete", []byte{0xC0, 0x82, ' ', 0xC0, 0x82}, "S S", true},
+		{"truncated", []byte{0xC0, 0x82, ' ', 0xE0, 0xA0}, "S ", false},
+		{"stray continuation", []byte{0xC0, 0x82, 0x80}, "S", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec := NewDecoder(bytes.NewReader(tt.data))
+			tokens := []Token{}
+			var err error
+			for {
+				var tok Token
+				tok, err = dec.Next()
+				if err != nil {
+					break
+				}
+				tokens = append(tokens, tok)
+			}
+
+			if got := FormatText(tokens); got != tt.want {
+				t.Errorf("Decoder.Next() = %v, want %v", got, tt.want)
+			}
+			if (err == io.EOF) != tt.wantEOF {
+				t.Errorf("Decoder.Next() error = %v, wantEOF %v", err, tt.wantEOF)
+			}
+		})
+	}
+}
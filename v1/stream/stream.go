@@ -0,0 +1,333 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/bjatkin/silabex/steno"
+)
+
+// Kind is the type of a token in a silabex stream
+type Kind int
+
+const (
+	Syllable Kind = iota
+	WordBreak
+	Punct
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Syllable:
+		return "syllable"
+	case WordBreak:
+		return "word break"
+	case Punct:
+		return "punctuation"
+	default:
+		return "?"
+	}
+}
+
+// Punctuation is every punctuation mark that can be written in a stream. The marks that are part of
+// steno strokes (the hyphen, asterisk, number bar and stroke separator) are not punctuation
+const Punctuation = ".,;:!?'\"()"
+
+// ErrIncomplete is returned when a stream ends in the middle of a syllable
+var ErrIncomplete = errors.New("incomplete syllable")
+
+// Token is a single syllable, word break or punctuation mark
+type Token struct {
+	Kind Kind
+	// Keys are the keys of a syllable
+	Keys steno.Keys
+	// Punct is the mark of a punctuation token
+	Punct byte
+}
+
+// String returns the token in its text form
+func (t Token) String() string {
+	switch t.Kind {
+	case Syllable:
+		return t.Keys.String()
+	case WordBreak:
+		return " "
+	case Punct:
+		return string(t.Punct)
+	default:
+		return "?"
+	}
+}
+
+// Validate checks that the token can be encoded
+func (t Token) Validate() error {
+	switch t.Kind {
+	case Syllable:
+		if t.Keys.Empty() || !t.Keys.Valid() {
+			return fmt.Errorf("syllable keys %#x are not a steno stroke", uint32(t.Keys))
+		}
+	case WordBreak:
+	case Punct:
+		if t.Punct == 0 || !strings.ContainsRune(Punctuation, rune(t.Punct)) {
+			return fmt.Errorf("'%c' is not a punctuation mark", t.Punct)
+		}
+	default:
+		return fmt.Errorf("unknown token kind %d", t.Kind)
+	}
+
+	return nil
+}
+
+// the encoding works like utf-8. Word breaks and punctuation are single ascii bytes, syllables are a
+// lead byte that gives the length followed by continuation bytes that each carry 6 bits of the keys.
+// The lead byte of a syllable with n bytes has n high bits set, so the longest form carries 26 bits
+const (
+	wordBreak = ' '
+	contMask  = 0x3F
+	contTag   = 0x80
+)
+
+// forms lists the syllable encodings from shortest to longest, max is the largest value it can hold
+var forms = []struct {
+	tag, mask byte
+	max       uint32
+}{
+	{0xC0, 0x1F, 1<<11 - 1},
+	{0xE0, 0x0F, 1<<16 - 1},
+	{0xF0, 0x07, 1<<21 - 1},
+	{0xF8, 0x03, 1<<26 - 1},
+}
+
+// tokenLen returns the number of bytes in the token that starts with the lead byte, 0 is
+// returned for bytes that can not start a token
+func tokenLen(lead byte) int {
+	if lead < 0x80 {
+		return 1
+	}
+
+	for i, f := range forms {
+		if lead&^f.mask == f.tag {
+			return i + 2
+		}
+	}
+
+	return 0
+}
+
+// AppendToken appends the encoding of the token to b. Syllables always use the shortest form that holds their keys
+func AppendToken(b []byte, t Token) ([]byte, error) {
+	if err := t.Validate(); err != nil {
+		return b, err
+	}
+
+	switch t.Kind {
+	case WordBreak:
+		return append(b, wordBreak), nil
+	case Punct:
+		return append(b, t.Punct), nil
+	}
+
+	v := uint32(t.Keys)
+	for i, f := range forms {
+		if v > f.max {
+			continue
+		}
+
+		n := i + 1
+		b = append(b, f.tag|byte(v>>(6*n)))
+		for j := n - 1; j >= 0; j-- {
+			b = append(b, contTag|byte(v>>(6*j))&contMask)
+		}
+		return b, nil
+	}
+
+	return b, fmt.Errorf("syllable keys %#x are too large to encode", v)
+}
+
+// DecodeToken decodes the first token in p and returns it with the number of bytes it used
+func DecodeToken(p []byte) (Token, int, error) {
+	if len(p) == 0 {
+		return Token{}, 0, ErrIncomplete
+	}
+
+	lead := p[0]
+	n := tokenLen(lead)
+	switch {
+	case n == 0 && lead&^contMask == contTag:
+		return Token{}, 0, fmt.Errorf("unexpected continuation byte %#02x", lead)
+	case n == 0:
+		return Token{}, 0, fmt.Errorf("invalid lead byte %#02x", lead)
+	case n == 1 && lead == wordBreak:
+		return Token{Kind: WordBreak}, 1, nil
+	case n == 1:
+		t := Token{Kind: Punct, Punct: lead}
+		if err := t.Validate(); err != nil {
+			return Token{}, 0, err
+		}
+		return t, 1, nil
+	}
+
+	if len(p) < n {
+		return Token{}, 0, ErrIncomplete
+	}
+
+	f := forms[n-2]
+	v := uint32(lead & f.mask)
+	for _, c := range p[1:n] {
+		if c&^contMask != contTag {
+			return Token{}, 0, fmt.Errorf("expected a continuation byte but got %#02x", c)
+		}
+		v = v<<6 | uint32(c&contMask)
+	}
+
+	if n > 2 && v <= forms[n-3].max {
+		return Token{}, 0, fmt.Errorf("syllable %#x uses an overlong %d byte form", v, n)
+	}
+
+	t := Token{Kind: Syllable, Keys: steno.Keys(v)}
+	if err := t.Validate(); err != nil {
+		return Token{}, 0, err
+	}
+
+	return t, n, nil
+}
+
+// Validate checks that data is a complete sequence of valid tokens, the error gives the offset of the first bad token
+func Validate(data []byte) error {
+	for i := 0; i < len(data); {
+		_, n, err := DecodeToken(data[i:])
+		if err != nil {
+			return fmt.Errorf("byte %d: %w", i, err)
+		}
+		i += n
+	}
+
+	return nil
+}
+
+// Encode encodes every token
+func Encode(tokens []Token) ([]byte, error) {
+	ret := []byte{}
+	for i, t := range tokens {
+		var err error
+		ret, err = AppendToken(ret, t)
+		if err != nil {
+			return nil, fmt.Errorf("token %d: %w", i, err)
+		}
+	}
+
+	return ret, nil
+}
+
+// Decode decodes every token in data
+func Decode(data []byte) ([]Token, error) {
+	ret := []Token{}
+	for i := 0; i < len(data); {
+		t, n, err := DecodeToken(data[i:])
+		if err != nil {
+			return nil, fmt.Errorf("byte %d: %w", i, err)
+		}
+		ret = append(ret, t)
+		i += n
+	}
+
+	return ret, nil
+}
+
+// ParseText parses the text form of a stream. Syllables in the same word are steno strokes seperated by
+// slashes, words are seperated by spaces and punctuation marks are written after or between words
+// (e.g. "HEL/HROE WORLD.")
+func ParseText(text string) ([]Token, error) {
+	p := textParser{}
+	for i := 0; i < len(text); i++ {
+		if err := p.next(text[i]); err != nil {
+			return nil, fmt.Errorf("offset %d: %w", i, err)
+		}
+	}
+
+	if err := p.flush(); err != nil {
+		return nil, fmt.Errorf("offset %d: %w", len(text), err)
+	}
+
+	return p.tokens, nil
+}
+
+// FormatText writes the tokens in their text form
+func FormatText(tokens []Token) string {
+	b := strings.Builder{}
+	prev := WordBreak
+	for _, t := range tokens {
+		if t.Kind == Syllable && prev == Syllable {
+			b.WriteByte('/')
+		}
+		b.WriteString(t.String())
+		prev = t.Kind
+	}
+
+	return b.String()
+}
+
+// textParser splits the text form into tokens one byte at a time so it can be fed by io.Writer
+type textParser struct {
+	stroke []byte
+	// slash is true when a stroke seperator has been read and the next stroke is still expected
+	slash  bool
+	tokens []Token
+}
+
+// next reads the next byte of the text
+func (p *textParser) next(c byte) error {
+	switch {
+	case c == '/':
+		if len(p.stroke) == 0 {
+			return errors.New("stroke seperator must come after a stroke")
+		}
+		if err := p.endStroke(); err != nil {
+			return err
+		}
+		p.slash = true
+	case c == wordBreak:
+		if err := p.flush(); err != nil {
+			return err
+		}
+		p.tokens = append(p.tokens, Token{Kind: WordBreak})
+	case strings.IndexByte(Punctuation, c) >= 0:
+		if err := p.flush(); err != nil {
+			return err
+		}
+		p.tokens = append(p.tokens, Token{Kind: Punct, Punct: c})
+	case c < 0x80 && (strings.IndexByte(steno.Order, c) >= 0 || c == '-' || (c >= '0' && c <= '9')):
+		p.stroke = append(p.stroke, c)
+	default:
+		return fmt.Errorf("unexpected character %q", c)
+	}
+
+	return nil
+}
+
+// endStroke turns the pending stroke into a syllable
+func (p *textParser) endStroke() error {
+	if len(p.stroke) == 0 {
+		return nil
+	}
+
+	keys, err := steno.ParseKeys(string(p.stroke))
+	if err != nil {
+		return err
+	}
+
+	p.tokens = append(p.tokens, Token{Kind: Syllable, Keys: keys})
+	p.stroke = p.stroke[:0]
+	p.slash = false
+	return nil
+}
+
+// flush ends the pending stroke at the end of a word
+func (p *textParser) flush() error {
+	if p.slash && len(p.stroke) == 0 {
+		return errors.New("stroke seperator must come before a stroke")
+	}
+
+	return p.endStroke()
+}
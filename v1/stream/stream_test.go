@@ -0,0 +1,176 @@
+package stream
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/bjatkin/silabex/steno"
+)
+
+func syllable(stroke string) Token {
+	keys, err := steno.ParseKeys(stroke)
+	if err != nil {
+		panic(err)
+	}
+
+	return Token{Kind: Syllable, Keys: keys}
+}
+
+func TestAppendToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   Token
+		want    []byte
+		wantErr bool
+	}{
+		{"word break", Token{Kind: WordBreak}, []byte{' '}, false},
+		{"punctuation", Token{Kind: Punct, Punct: '.'}, []byte{'.'}, false},
+		{"two bytes", Token{Kind: Syllable, Keys: steno.LeftS}, []byte{0xC0, 0x82}, false},
+		{"largest two bytes", Token{Kind: Syllable, Keys: 1<<11 - 1}, []byte{0xDF, 0xBF}, false},
+		{"three bytes", Token{Kind: Syllable, Keys: 1 << 11}, []byte{0xE0, 0xA0, 0x80}, false},
+		{"four bytes", Token{Kind: Syllable, Keys: 1 << 16}, []byte{0xF0, 0x90, 0x80, 0x80}, false},
+		{"five bytes", Token{Kind: Syllable, Keys: steno.RightZ}, []byte{0xF8, 0x90, 0x80, 0x80, 0x80}, false},
+		{"empty syllable", Token{Kind: Syllable}, nil, true},
+		{"keys off the keyboard", Token{Kind: Syllable, Keys: 1 << 23}, nil, true},
+		{"not punctuation", Token{Kind: Punct, Punct: 'a'}, nil, true},
+		{"unknown kind", Token{Kind: 7}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := AppendToken(nil, tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("AppendToken() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("AppendToken() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		p       []byte
+		want    Token
+		wantN   int
+		wantErr error
+	}{
+		{"word break", []byte(" KAT"), Token{Kind: WordBreak}, 1, nil},
+		{"punctuation", []byte("?"), Token{Kind: Punct, Punct: '?'}, 1, nil},
+		{"syllable", []byte{0xC0, 0x82, ' '}, Token{Kind: Syllable, Keys: steno.LeftS}, 2, nil},
+		{"five bytes", []byte{0xF8, 0x90, 0x80, 0x80, 0x80}, Token{Kind: Syllable, Keys: steno.RightZ}, 5, nil},
+		{"empty", []byte{}, Token{}, 0, ErrIncomplete},
+		{"incomplete", []byte{0xE0, 0xA0}, Token{}, 0, ErrIncomplete},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, n, err := DecodeToken(tt.p)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("DecodeToken() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want || n != tt.wantN {
+				t.Errorf("DecodeToken() = %v, %v, want %v, %v", got, n, tt.want, tt.wantN)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{"empty", []byte{}, false},
+		{"text", []byte{0xC0, 0x82, ' ', 0xE0, 0xA0, 0x80, '.'}, false},
+		{"stray continuation", []byte{0x80}, true},
+		{"letter", []byte("a"), true},
+		{"bad lead", []byte{0xFC, 0x80, 0x80, 0x80, 0x80, 0x80}, true},
+		{"missing continuation", []byte{0xE0, 0xA0, ' '}, true},
+		{"truncated", []byte{' ', 0xE0, 0xA0}, true},
+		{"overlong", []byte{0xE0, 0x80, 0x82}, true},
+		{"empty syllable", []byte{0xC0, 0x80}, true},
+		{"keys off the keyboard", []byte{0xF8, 0xA0, 0x80, 0x80, 0x80}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Validate(tt.data); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseText(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []Token
+		wantErr bool
+	}{
+		{"words", "HEL/HROE WORLD.", []Token{
+			syllable("HEL"), syllable("HROE"), {Kind: WordBreak}, syllable("WORLD"), {Kind: Punct, Punct: '.'},
+		}, false},
+		{"quoted", "\"KAT\"", []Token{{Kind: Punct, Punct: '"'}, syllable("KAT"), {Kind: Punct, Punct: '"'}}, false},
+		{"numbers", "1-9 #K", []Token{syllable("1-9"), {Kind: WordBreak}, syllable("#K")}, false},
+		{"empty", "", nil, false},
+		{"leading slash", "/KAT", nil, true},
+		{"trailing slash", "KAT/", nil, true},
+		{"slash before space", "KAT/ KAT", nil, true},
+		{"double slash", "KAT//KAT", nil, true},
+		{"bad stroke", "TAK-T", nil, true},
+		{"unknown character", "KAT\n", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseText(tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseText() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseText() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode_roundTrip(t *testing.T) {
+	texts := []string{
+		"HEL/HROE WORLD.",
+		"TAOEU/TPH*EU, T-S!",
+		"\"STKPWHRAO*EUFRPBLGTSDZ\" -Z/*T 1-9",
+	}
+	for _, text := range texts {
+		t.Run(text, func(t *testing.T) {
+			tokens, err := ParseText(text)
+			if err != nil {
+				t.Fatalf("ParseText() error = %v", err)
+			}
+
+			data, err := Encode(tokens)
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if err := Validate(data); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			got, err := Decode(data)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tokens) {
+				t.Errorf("Decode() = %v, want %v", got, tokens)
+			}
+			if FormatText(got) != text {
+				t.Errorf("FormatText() = %v, want %v", FormatText(got), text)
+			}
+		})
+	}
+}
@@ -1,7 +1,14 @@
 package stroke
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/JoshVarga/svgparser"
+	"github.com/bjatkin/silabex/geom"
+	"github.com/bjatkin/silabex/linalg"
+	"github.com/bjatkin/silabex/metrics"
+	"github.com/bjatkin/silabex/svg"
 )
 
 type Cluster int
@@ -30,88 +37,105 @@ type element struct {
 
 	elements []svgparser.Element
 
-	dx, dy float64
-	sx, sy float64
+	// transform places the elements in the character, it is applied on top of
+	// any transforms the elements already have in the template
+	transform linalg.Mat3x
+}
+
+// apply applies m after the existing transform of the element
+func (e *element) apply(m linalg.Mat3x) {
+	e.transform = linalg.MatMul(m, e.transform)
 }
 
-func (e *element) up(cluster Cluster) {
+func (e *element) up(m metrics.FontMetrics, cluster Cluster) {
 	if cluster == Vowel {
-		e.dx -= 880
+		e.apply(linalg.Translate(0, -m.VowelShift()))
 	}
 
-	if e.segment == Stand {
-		e.dx -= 140
+	slot := slot(m, cluster)
+	switch e.segment {
+	case Stand:
+		e.apply(linalg.Translate(0, -slot.HangShift()))
 		e.segment = Hang
-	}
-
-	if e.segment == Foot {
-		e.dx -= 620
+	case Foot:
+		e.apply(linalg.Translate(0, -slot.FootShift()))
 		e.segment = Head
 	}
 }
 
-func (e *element) down(cluster Cluster) {
+func (e *element) down(m metrics.FontMetrics, cluster Cluster) {
 	if cluster == Vowel {
-		e.dx += 880
+		e.apply(linalg.Translate(0, m.VowelShift()))
 	}
 
-	if e.segment == Hang {
-		e.dx += 140
+	slot := slot(m, cluster)
+	switch e.segment {
+	case Hang:
+		e.apply(linalg.Translate(0, slot.HangShift()))
 		e.segment = Stand
-	}
-
-	if e.segment == Head {
-		e.dx += 620
+	case Head:
+		e.apply(linalg.Translate(0, slot.FootShift()))
 		e.segment = Foot
 	}
 }
 
-func (e *element) left(cluster Cluster) {
-	if cluster == Vowel {
-		e.dy -= 880
-	}
-
-	if cluster == Final {
-		e.dy -= 390
+func (e *element) left(m metrics.FontMetrics, cluster Cluster) {
+	switch cluster {
+	case Vowel:
+		e.apply(linalg.Translate(-m.VowelShift(), 0))
+	case Final:
+		e.apply(linalg.Translate(-m.FinalShift(), 0))
 	}
 }
 
-func (e *element) right(cluster Cluster) {
-	if cluster == Vowel {
-		e.dy += 880
-	}
-
-	if cluster == Initial {
-		e.dy += 390
-		cluster = Final
+func (e *element) right(m metrics.FontMetrics, cluster Cluster) {
+	switch cluster {
+	case Vowel:
+		e.apply(linalg.Translate(m.VowelShift(), 0))
+	case Initial:
+		e.apply(linalg.Translate(m.FinalShift(), 0))
 	}
 }
 
-func (e *element) flipX() {
-	e.sx *= -1
-}
+// slot returns the consonant slot strokes in the cluster are drawn in
+func slot(m metrics.FontMetrics, cluster Cluster) metrics.Slot {
+	if cluster == Solo {
+		return m.Solo
+	}
 
-func (e *element) FlipY() {
-	e.sy *= -1
+	return m.Consonant
 }
 
 func (e *element) copy() element {
 	return element{
-		segment:  e.segment,
-		elements: append([]svgparser.Element{}, e.elements...),
-		dx:       e.dx,
-		dy:       e.dy,
-		sx:       e.sx,
-		sy:       e.sy,
+		segment:   e.segment,
+		elements:  append([]svgparser.Element{}, e.elements...),
+		transform: e.transform,
 	}
 }
 
 type Stroke struct {
 	name     string
 	cluster  Cluster
+	segment  Segment
+	metrics  *metrics.FontMetrics
 	elements []element
 }
 
+// New creates a new stroke from a set of svg elements. All the elements are placed in the given segment
+func New(name string, cluster Cluster, segment Segment, elements ...svgparser.Element) *Stroke {
+	return &Stroke{
+		name:    name,
+		cluster: cluster,
+		segment: segment,
+		elements: []element{{
+			segment:   segment,
+			elements:  append([]svgparser.Element{}, elements...),
+			transform: linalg.Identity(),
+		}},
+	}
+}
+
 func (s *Stroke) copy() *Stroke {
 	elements := []element{}
 	for _, e := range s.elements {
@@ -120,10 +144,18 @@ func (s *Stroke) copy() *Stroke {
 
 	return &Stroke{
 		name:     s.name,
+		cluster:  s.cluster,
+		segment:  s.segment,
+		metrics:  s.metrics,
 		elements: elements,
 	}
 }
 
+// Copy returns a deep copy of the stroke so it can be transformed without modifying the original
+func (s *Stroke) Copy() *Stroke {
+	return s.copy()
+}
+
 func (s *Stroke) Name() string {
 	return s.name
 }
@@ -132,6 +164,30 @@ func (s *Stroke) Cluster() Cluster {
 	return s.cluster
 }
 
+// SetName renames the stroke
+func (s *Stroke) SetName(name string) *Stroke {
+	s.name = name
+	return s
+}
+
+// SetCluster moves the stroke into a different cluster without transforming it
+func (s *Stroke) SetCluster(cluster Cluster) *Stroke {
+	s.cluster = cluster
+	return s
+}
+
+// Segment returns the segment the stroke as a whole occupies in the character
+func (s *Stroke) Segment() Segment {
+	return s.segment
+}
+
+// SetSegment changes the segment the stroke as a whole occupies, the segments of the underlying
+// elements are left unchanged
+func (s *Stroke) SetSegment(segment Segment) *Stroke {
+	s.segment = segment
+	return s
+}
+
 func (s *Stroke) Segments() []Segment {
 	segments := map[Segment]struct{}{}
 
@@ -148,24 +204,38 @@ func (s *Stroke) Segments() []Segment {
 }
 
 func (s *Stroke) Up() *Stroke {
-	for _, e := range s.elements {
-		e.up(s.cluster)
+	switch s.segment {
+	case Stand:
+		s.segment = Hang
+	case Foot:
+		s.segment = Head
+	}
+
+	for i := range s.elements {
+		s.elements[i].up(s.Metrics(), s.cluster)
 	}
 
 	return s
 }
 
 func (s *Stroke) Down() *Stroke {
-	for _, e := range s.elements {
-		e.down(s.cluster)
+	switch s.segment {
+	case Hang:
+		s.segment = Stand
+	case Head:
+		s.segment = Foot
+	}
+
+	for i := range s.elements {
+		s.elements[i].down(s.Metrics(), s.cluster)
 	}
 
 	return s
 }
 
 func (s *Stroke) Left() *Stroke {
-	for _, e := range s.elements {
-		e.left(s.cluster)
+	for i := range s.elements {
+		s.elements[i].left(s.Metrics(), s.cluster)
 	}
 
 	if s.cluster == Final {
@@ -176,8 +246,8 @@ func (s *Stroke) Left() *Stroke {
 }
 
 func (s *Stroke) Right() *Stroke {
-	for _, e := range s.elements {
-		e.right(s.cluster)
+	for i := range s.elements {
+		s.elements[i].right(s.Metrics(), s.cluster)
 	}
 
 	if s.cluster == Initial {
@@ -187,22 +257,131 @@ func (s *Stroke) Right() *Stroke {
 	return s
 }
 
+// FlipX mirrors the stroke left to right across the center of its slot
 func (s *Stroke) FlipX() *Stroke {
-	for _, e := range s.elements {
-		e.flipX()
+	x, _ := s.center()
+	return s.Transform(linalg.ScaleAt(-1, 1, x, 0))
+}
+
+// FlipY mirrors the stroke top to bottom across the center of its slot
+func (s *Stroke) FlipY() *Stroke {
+	_, y := s.center()
+	return s.Transform(linalg.ScaleAt(1, -1, 0, y))
+}
+
+// Transform applies m to the stroke after all the transforms that have already been applied.
+// Up, Down, Left, Right, FlipX and FlipY are all applied this way, so a series of transforms
+// is applied in the order it is called. Use linalg.ScaleAt or linalg.RotateAt to mirror or
+// rotate the stroke around an arbitrary origin
+func (s *Stroke) Transform(m linalg.Mat3x) *Stroke {
+	for i := range s.elements {
+		s.elements[i].apply(m)
 	}
 
 	return s
 }
 
-func (s *Stroke) FlipY() *Stroke {
-	for _, e := range s.elements {
-		e.FlipY()
+// center returns the center of the slot the stroke's cluster is drawn in
+func (s *Stroke) center() (float64, float64) {
+	m := s.Metrics()
+
+	var p geom.Point
+	switch s.cluster {
+	case Vowel:
+		p = m.VowelCenter()
+	case Final:
+		p = m.FinalCenter()
+	default:
+		p = slot(m, s.cluster).Center()
 	}
 
+	return p.X, p.Y
+}
+
+// Metrics returns the metrics the stroke is moved with
+func (s *Stroke) Metrics() metrics.FontMetrics {
+	if s.metrics == nil {
+		return metrics.Default()
+	}
+
+	return *s.metrics
+}
+
+// SetMetrics changes the metrics the stroke is moved with, strokes use metrics.Default until
+// their metrics are set
+func (s *Stroke) SetMetrics(m metrics.FontMetrics) *Stroke {
+	s.metrics = &m
 	return s
 }
 
+// Outline returns the shape drawn by the stroke with every transform applied
+func (s *Stroke) Outline() (geom.Path, error) {
+	ret := geom.Path{}
+	for _, e := range s.elements {
+		for i := range e.elements {
+			shape, err := svg.ElementOutline(&e.elements[i])
+			if err != nil {
+				return nil, fmt.Errorf("stroke %s: %w", s.name, err)
+			}
+			ret = append(ret, shape.Transform(e.transform)...)
+		}
+	}
+
+	return ret, nil
+}
+
+// Elements returns copies of the svg elements of the stroke with the moves the stroke was given written
+// into their transform attributes, so the stroke can be drawn like a group from the template
+func (s *Stroke) Elements() []svgparser.Element {
+	ret := []svgparser.Element{}
+	for _, e := range s.elements {
+		transform := svg.FormatTransform(e.transform)
+		for _, elem := range e.elements {
+			attrs := map[string]string{}
+			for k, v := range elem.Attributes {
+				attrs[k] = v
+			}
+			if transform != "" {
+				attrs["transform"] = strings.TrimSpace(transform + " " + attrs["transform"])
+			}
+
+			elem.Attributes = attrs
+			ret = append(ret, elem)
+		}
+	}
+
+	return ret
+}
+
+// collisionTolerance is the max distance between a curve and the polyline used to check it for collisions
+const collisionTolerance = 0.5
+
+// Bounds returns the bounding box of the stroke, a stroke that draws nothing has an empty box
+func (s *Stroke) Bounds() (geom.Rect, error) {
+	outline, err := s.Outline()
+	if err != nil {
+		return geom.Rect{}, err
+	}
+
+	r, _ := outline.Bounds()
+	return r, nil
+}
+
+// Intersects reports whether the shapes drawn by the strokes overlap
+func (s *Stroke) Intersects(o *Stroke) (bool, error) {
+	a, err := s.Outline()
+	if err != nil {
+		return false, err
+	}
+
+	b, err := o.Outline()
+	if err != nil {
+		return false, err
+	}
+
+	return a.Intersects(b, collisionTolerance), nil
+}
+
 func Match(a, b *Stroke) bool {
 	return a.cluster == b.cluster
 }
@@ -210,6 +389,9 @@ func Match(a, b *Stroke) bool {
 func Join(name string, a, b *Stroke) *Stroke {
 	return &Stroke{
 		name:     name,
+		cluster:  a.cluster,
+		segment:  a.segment,
+		metrics:  a.metrics,
 		elements: append(a.copy().elements, b.copy().elements...),
 	}
 }
@@ -222,7 +404,7 @@ func NewSlice(strokes ...*Stroke) StrokeSlice {
 
 func (s StrokeSlice) SetName(name string) StrokeSlice {
 	for _, stroke := range s {
-		stroke.name = name
+		stroke.SetName(name)
 	}
 
 	return s
@@ -275,3 +457,19 @@ func (s StrokeSlice) FlipY() StrokeSlice {
 
 	return s
 }
+
+func (s StrokeSlice) Transform(m linalg.Mat3x) StrokeSlice {
+	for _, stroke := range s {
+		stroke.Transform(m)
+	}
+
+	return s
+}
+
+func (s StrokeSlice) SetMetrics(m metrics.FontMetrics) StrokeSlice {
+	for _, stroke := range s {
+		stroke.SetMetrics(m)
+	}
+
+	return s
+}
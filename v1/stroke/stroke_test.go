@@ -0,0 +1,197 @@
+package stroke
+
+import (
+	"math"
+	"testing"
+
+	"github.com/JoshVarga/svgparser"
+	"github.com/bjatkin/silabex/geom"
+	"github.com/bjatkin/silabex/linalg"
+	"github.com/bjatkin/silabex/metrics"
+)
+
+func TestStroke_Transform(t *testing.T) {
+	tests := []struct {
+		name    string
+		stroke  func() *Stroke
+		point   linalg.Vec3
+		want    linalg.Vec3
+		segment Segment
+	}{
+		{
+			"up moves a stand into the hang",
+			func() *Stroke { return New("2", Initial, Stand).Up() },
+			linalg.NewPoint2(140, 290),
+			linalg.NewPoint2(140, 150),
+			Hang,
+		},
+		{
+			"down moves a head into the foot",
+			func() *Stroke { return New("0", Initial, Head).Down() },
+			linalg.NewPoint2(140, 150),
+			linalg.NewPoint2(140, 770),
+			Foot,
+		},
+		{
+			"right moves an initial into the final",
+			func() *Stroke { return New("2", Initial, Tall).Right() },
+			linalg.NewPoint2(140, 290),
+			linalg.NewPoint2(530, 290),
+			Tall,
+		},
+		{
+			"vowels move across the vowel box",
+			func() *Stroke { return New("0", Vowel, Border).Up().Left() },
+			linalg.NewPoint2(900, 900),
+			linalg.NewPoint2(20, 20),
+			Border,
+		},
+		{
+			"flip x mirrors across the slot",
+			func() *Stroke { return New("3", Initial, Tall).FlipX() },
+			linalg.NewPoint2(140, 290),
+			linalg.NewPoint2(470, 290),
+			Tall,
+		},
+		{
+			"flip x of a final mirrors across the final slot",
+			func() *Stroke { return New("3", Initial, Tall).Right().FlipX() },
+			linalg.NewPoint2(140, 290),
+			linalg.NewPoint2(860, 290),
+			Tall,
+		},
+		{
+			"transforms apply in order",
+			func() *Stroke { return New("3", Initial, Tall).FlipY().Right() },
+			linalg.NewPoint2(140, 290),
+			linalg.NewPoint2(530, 710),
+			Tall,
+		},
+		{
+			"metrics from a redesigned template",
+			func() *Stroke {
+				m := metrics.Default()
+				m.Consonant.Core.Min.Y = 400
+				return New("2", Initial, Stand).SetMetrics(m).Up()
+			},
+			linalg.NewPoint2(140, 400),
+			linalg.NewPoint2(140, 150),
+			Hang,
+		},
+		{
+			"rotate around an origin",
+			func() *Stroke { return New("3", Initial, Tall).Transform(linalg.RotateAt(math.Pi, 305, 500)) },
+			linalg.NewPoint2(140, 290),
+			linalg.NewPoint2(470, 710),
+			Tall,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.stroke()
+			for _, e := range s.elements {
+				got := linalg.VecMul(e.transform, tt.point)
+				if math.Abs(got.X-tt.want.X) > 0.01 || math.Abs(got.Y-tt.want.Y) > 0.01 {
+					t.Errorf("transform moved %v to %v, want %v", tt.point, got, tt.want)
+				}
+			}
+
+			if s.Segment() != tt.segment {
+				t.Errorf("Segment() = %v, want %v", s.Segment(), tt.segment)
+			}
+		})
+	}
+}
+
+func TestStroke_Copy(t *testing.T) {
+	a := New("2", Initial, Stand)
+	b := a.Copy().Up()
+
+	if a.elements[0].transform != linalg.Identity() {
+		t.Errorf("Copy() shares its transform with the original")
+	}
+
+	if b.Segment() != Hang || a.Segment() != Stand {
+		t.Errorf("Copy() shares its segment with the original")
+	}
+}
+
+func TestStroke_Intersects(t *testing.T) {
+	// box is a stroke with a single 100 by 200 unit box at the top left of the initial core
+	box := func() *Stroke {
+		return New("2", Initial, Stand, svgparser.Element{
+			Name:       "path",
+			Attributes: map[string]string{"d": "M 140,290 h 100 v 200 h -100 Z"},
+		})
+	}
+
+	tests := []struct {
+		name string
+		a    *Stroke
+		b    *Stroke
+		want bool
+	}{
+		{"same place", box(), box(), true},
+		{"moved up into the hang", box(), box().Up(), true},
+		{"moved into the final", box(), box().Right(), false},
+		{"rotated onto itself", box(), box().Transform(linalg.RotateAt(math.Pi, 190, 390)), true},
+		{"rotated away", box(), box().Transform(linalg.RotateAt(math.Pi, 300, 390)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.a.Intersects(tt.b)
+			if err != nil {
+				t.Fatalf("Intersects() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Intersects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStroke_Bounds(t *testing.T) {
+	s := New("2", Initial, Stand, svgparser.Element{
+		Name:       "path",
+		Attributes: map[string]string{"d": "M 140,290 h 100 v 100 h -100 Z"},
+	}).Right()
+
+	got, err := s.Bounds()
+	if err != nil {
+		t.Fatalf("Bounds() error = %v", err)
+	}
+	if want := geom.NewRect(530, 290, 100, 100); got != want {
+		t.Errorf("Bounds() = %v, want %v", got, want)
+	}
+}
+
+func TestStroke_Elements(t *testing.T) {
+	s := New("2", Initial, Stand, svgparser.Element{
+		Name:       "path",
+		Attributes: map[string]string{"d": "M 140,290 h 100 v 100 h -100 Z", "transform": "scale(2)"},
+	})
+	moved := s.Copy().Right()
+
+	if got := s.Elements()[0].Attributes["transform"]; got != "scale(2)" {
+		t.Errorf("Elements() transform = %q, want the template transform", got)
+	}
+
+	elems := moved.Elements()
+	if got, want := elems[0].Attributes["transform"], "matrix(1 0 0 1 390 0) scale(2)"; got != want {
+		t.Errorf("Elements() transform = %q, want %q", got, want)
+	}
+
+	// the elements draw the same shape as the stroke
+	from := New("2", Initial, Stand, elems...)
+	got, err := from.Bounds()
+	if err != nil {
+		t.Fatalf("Bounds() error = %v", err)
+	}
+	want, err := moved.Bounds()
+	if err != nil {
+		t.Fatalf("Bounds() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("Bounds() = %v, want %v", got, want)
+	}
+}
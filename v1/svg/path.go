@@ -0,0 +1,560 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/bjatkin/silabex/geom"
+	"github.com/bjatkin/silabex/linalg"
+)
+
+// pathScanner reads the numbers and commands out of svg path data
+type pathScanner struct {
+	data string
+	pos  int
+}
+
+func (s *pathScanner) skipSeparators() {
+	for s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case ' ', '\t', '\n', '\r', ',':
+			s.pos++
+		default:
+			return
+		}
+	}
+}
+
+func (s *pathScanner) done() bool {
+	s.skipSeparators()
+	return s.pos >= len(s.data)
+}
+
+// command returns the next command letter, false is returned if the next token is not a command
+func (s *pathScanner) command() (byte, bool) {
+	s.skipSeparators()
+	if s.pos >= len(s.data) {
+		return 0, false
+	}
+
+	c := s.data[s.pos]
+	if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+		s.pos++
+		return c, true
+	}
+
+	return 0, false
+}
+
+// hasNumber reports whether the next token is a number
+func (s *pathScanner) hasNumber() bool {
+	s.skipSeparators()
+	if s.pos >= len(s.data) {
+		return false
+	}
+
+	c := s.data[s.pos]
+	return c == '-' || c == '+' || c == '.' || (c >= '0' && c <= '9')
+}
+
+func (s *pathScanner) number() (float64, error) {
+	s.skipSeparators()
+	start := s.pos
+
+	if s.pos < len(s.data) && (s.data[s.pos] == '-' || s.data[s.pos] == '+') {
+		s.pos++
+	}
+
+	seenDot, seenExp := false, false
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		switch {
+		case c >= '0' && c <= '9':
+		case c == '.' && !seenDot && !seenExp:
+			seenDot = true
+		case (c == 'e' || c == 'E') && !seenExp:
+			seenExp = true
+			if s.pos+1 < len(s.data) && (s.data[s.pos+1] == '-' || s.data[s.pos+1] == '+') {
+				s.pos++
+			}
+		default:
+			return s.parse(start)
+		}
+		s.pos++
+	}
+
+	return s.parse(start)
+}
+
+func (s *pathScanner) parse(start int) (float64, error) {
+	f, err := strconv.ParseFloat(s.data[start:s.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number '%s' at offset %d in path data", s.data[start:s.pos], start)
+	}
+
+	return f, nil
+}
+
+// flag reads a single arc flag, flags do not need to be seperated from the next number
+func (s *pathScanner) flag() (bool, error) {
+	s.skipSeparators()
+	if s.pos < len(s.data) {
+		switch s.data[s.pos] {
+		case '0':
+			s.pos++
+			return false, nil
+		case '1':
+			s.pos++
+			return true, nil
+		}
+	}
+
+	return false, fmt.Errorf("invalid arc flag at offset %d in path data", s.pos)
+}
+
+func (s *pathScanner) numbers(n int) ([]float64, error) {
+	ret := make([]float64, n)
+	for i := range ret {
+		f, err := s.number()
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = f
+	}
+
+	return ret, nil
+}
+
+// Op is an svg path command, its value is the upper case letter of the command
+type Op byte
+
+const (
+	MoveTo        Op = 'M'
+	LineTo        Op = 'L'
+	HLineTo       Op = 'H'
+	VLineTo       Op = 'V'
+	CubicTo       Op = 'C'
+	SmoothCubicTo Op = 'S'
+	QuadTo        Op = 'Q'
+	SmoothQuadTo  Op = 'T'
+	ArcTo         Op = 'A'
+	ClosePath     Op = 'Z'
+)
+
+// argCount is the number of arguments each command takes
+var argCount = map[Op]int{
+	MoveTo:        2,
+	LineTo:        2,
+	HLineTo:       1,
+	VLineTo:       1,
+	CubicTo:       6,
+	SmoothCubicTo: 4,
+	QuadTo:        4,
+	SmoothQuadTo:  2,
+	ArcTo:         7,
+	ClosePath:     0,
+}
+
+// Command is a single command from svg path data. The arguments are in the order they are written
+// in the path data, the large arc and sweep flags of an arc are stored as 0 or 1
+type Command struct {
+	Op       Op
+	Relative bool
+	Args     []float64
+}
+
+// letter returns the command letter as it is written in path data
+func (c Command) letter() byte {
+	if c.Relative {
+		return byte(c.Op) + 'a' - 'A'
+	}
+
+	return byte(c.Op)
+}
+
+// PathData is the list of commands in the d attribute of a path element
+type PathData []Command
+
+// ParsePathData parses svg path data (the d attribute of a path element). Repeated arguments
+// are split into separate commands so every command has exactly the arguments it takes
+func ParsePathData(d string) (PathData, error) {
+	s := &pathScanner{data: d}
+
+	ret := PathData{}
+	for !s.done() {
+		cmd := Command{}
+		letter, ok := s.command()
+		switch {
+		case ok:
+			cmd.Op = Op(letter)
+			if letter >= 'a' && letter <= 'z' {
+				cmd.Op = Op(letter - 'a' + 'A')
+				cmd.Relative = true
+			}
+			if _, ok := argCount[cmd.Op]; !ok {
+				return nil, fmt.Errorf("unknown path command '%s'", string(letter))
+			}
+		case len(ret) == 0:
+			return nil, fmt.Errorf("path data must start with a command")
+		case ret[len(ret)-1].Op == ClosePath:
+			return nil, fmt.Errorf("expected a command after '%s' at offset %d in path data", string(ret[len(ret)-1].letter()), s.pos)
+		default:
+			// repeated arguments reuse the last command, except a moveto which becomes a lineto
+			cmd.Op, cmd.Relative = ret[len(ret)-1].Op, ret[len(ret)-1].Relative
+			if cmd.Op == MoveTo {
+				cmd.Op = LineTo
+			}
+		}
+
+		if cmd.Op == ArcTo {
+			args, err := s.arc()
+			if err != nil {
+				return nil, err
+			}
+			cmd.Args = args
+		} else {
+			args, err := s.numbers(argCount[cmd.Op])
+			if err != nil {
+				return nil, err
+			}
+			cmd.Args = args
+		}
+
+		ret = append(ret, cmd)
+	}
+
+	return ret, nil
+}
+
+// arc reads the arguments of an arc command
+func (s *pathScanner) arc() ([]float64, error) {
+	args, err := s.numbers(3)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < 2; i++ {
+		flag, err := s.flag()
+		if err != nil {
+			return nil, err
+		}
+
+		if flag {
+			args = append(args, 1)
+		} else {
+			args = append(args, 0)
+		}
+	}
+
+	end, err := s.numbers(2)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(args, end...), nil
+}
+
+// String returns the shortest path data for the commands without rounding any arguments
+func (p PathData) String() string {
+	return p.Format(-1)
+}
+
+// Format returns the path data with every argument rounded to the given number of decimal places,
+// a negative precision keeps full precision. Command letters are left out when a command repeats
+// the last one and separators are left out wherever the numbers stay unambiguous
+func (p PathData) Format(precision int) string {
+	b := strings.Builder{}
+	last := ""
+	for i, c := range p {
+		implicit := false
+		if i > 0 {
+			prev := p[i-1]
+			switch {
+			case prev.Op == ClosePath:
+			case prev.Op == MoveTo:
+				implicit = c.Op == LineTo && c.Relative == prev.Relative
+			default:
+				implicit = c.Op == prev.Op && c.Relative == prev.Relative
+			}
+		}
+
+		if !implicit {
+			b.WriteByte(c.letter())
+			last = ""
+		}
+
+		for _, arg := range c.Args {
+			num := formatNumber(arg, precision)
+			if last != "" && num[0] != '-' && !(num[0] == '.' && strings.Contains(last, ".")) {
+				b.WriteByte(' ')
+			}
+			b.WriteString(num)
+			last = num
+		}
+	}
+
+	return b.String()
+}
+
+// formatNumber formats the number as short as possible, leading zeros are dropped
+func formatNumber(f float64, precision int) string {
+	num := strconv.FormatFloat(f, 'f', precision, 64)
+	if strings.Contains(num, ".") {
+		num = strings.TrimRight(strings.TrimRight(num, "0"), ".")
+	}
+
+	switch {
+	case num == "-0":
+		return "0"
+	case strings.HasPrefix(num, "0."):
+		return num[1:]
+	case strings.HasPrefix(num, "-0."):
+		return "-" + num[2:]
+	}
+
+	return num
+}
+
+// ParseOutline parses svg path data (the d attribute of a path element) into a geom.Path.
+// Elliptical arcs are converted to cubic beziers
+func ParseOutline(d string) (geom.Path, error) {
+	data, err := ParsePathData(d)
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Outline(), nil
+}
+
+// Outline converts the commands into a geom.Path with absolute coordinates.
+// Elliptical arcs are converted to cubic beziers
+func (p PathData) Outline() geom.Path {
+	path := geom.Path{}
+	var contour *geom.Contour
+	var current, lastControl geom.Point
+	var last Op
+
+	closeContour := func() {
+		if contour != nil {
+			path = append(path, *contour)
+			contour = nil
+		}
+	}
+
+	add := func(s ...geom.Segment) {
+		if contour == nil {
+			contour = &geom.Contour{Start: current}
+		}
+		contour.Segments = append(contour.Segments, s...)
+	}
+
+	for _, cmd := range p {
+		offset := func(i int) geom.Point {
+			if cmd.Relative {
+				return geom.Point{X: current.X + cmd.Args[i], Y: current.Y + cmd.Args[i+1]}
+			}
+			return geom.Point{X: cmd.Args[i], Y: cmd.Args[i+1]}
+		}
+
+		control := current
+		switch cmd.Op {
+		case MoveTo:
+			closeContour()
+			current = offset(0)
+			contour = &geom.Contour{Start: current}
+		case LineTo:
+			end := offset(0)
+			add(geom.Line(end))
+			current = end
+		case HLineTo:
+			end := geom.Point{X: cmd.Args[0], Y: current.Y}
+			if cmd.Relative {
+				end.X += current.X
+			}
+			add(geom.Line(end))
+			current = end
+		case VLineTo:
+			end := geom.Point{X: current.X, Y: cmd.Args[0]}
+			if cmd.Relative {
+				end.Y += current.Y
+			}
+			add(geom.Line(end))
+			current = end
+		case CubicTo, SmoothCubicTo:
+			c1, rest := current, 0
+			switch {
+			case cmd.Op == CubicTo:
+				c1, rest = offset(0), 2
+			case last == CubicTo || last == SmoothCubicTo:
+				// the first control point is the reflection of the last one if the last command was a cubic
+				c1 = current.Mul(2).Sub(lastControl)
+			}
+
+			c2, end := offset(rest), offset(rest+2)
+			add(geom.Cubic(c1, c2, end))
+			control, current = c2, end
+		case QuadTo, SmoothQuadTo:
+			c, rest := current, 0
+			switch {
+			case cmd.Op == QuadTo:
+				c, rest = offset(0), 2
+			case last == QuadTo || last == SmoothQuadTo:
+				c = current.Mul(2).Sub(lastControl)
+			}
+
+			end := offset(rest)
+			add(geom.Quad(c, end))
+			control, current = c, end
+		case ArcTo:
+			end := offset(5)
+			add(arcToCubics(current, cmd.Args[0], cmd.Args[1], cmd.Args[2], cmd.Args[3] != 0, cmd.Args[4] != 0, end)...)
+			current = end
+		case ClosePath:
+			if contour != nil {
+				contour.Closed = true
+				current = contour.Start
+			}
+			closeContour()
+		}
+
+		lastControl = control
+		last = cmd.Op
+	}
+
+	closeContour()
+	return path
+}
+
+// arcToCubics converts an svg elliptical arc into cubic beziers using the endpoint to center
+// conversion described in the svg spec (appendix B.2.4)
+func arcToCubics(from geom.Point, rx, ry, rotation float64, large, sweep bool, to geom.Point) []geom.Segment {
+	if from == to {
+		return nil
+	}
+
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		return []geom.Segment{geom.Line(to)}
+	}
+
+	phi := rotation * math.Pi / 180
+	cos, sin := math.Cos(phi), math.Sin(phi)
+
+	dx, dy := (from.X-to.X)/2, (from.Y-to.Y)/2
+	x1 := cos*dx + sin*dy
+	y1 := -sin*dx + cos*dy
+
+	// scale up the radii if they are too small to reach the end point
+	lambda := (x1*x1)/(rx*rx) + (y1*y1)/(ry*ry)
+	if lambda > 1 {
+		rx *= math.Sqrt(lambda)
+		ry *= math.Sqrt(lambda)
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1*y1 - ry*ry*x1*x1
+	den := rx*rx*y1*y1 + ry*ry*x1*x1
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+
+	cx1 := coef * rx * y1 / ry
+	cy1 := -coef * ry * x1 / rx
+
+	cx := cos*cx1 - sin*cy1 + (from.X+to.X)/2
+	cy := sin*cx1 + cos*cy1 + (from.Y+to.Y)/2
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+
+	start := angle(1, 0, (x1-cx1)/rx, (y1-cy1)/ry)
+	delta := angle((x1-cx1)/rx, (y1-cy1)/ry, (-x1-cx1)/rx, (-y1-cy1)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	}
+	if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	// split the arc into pieces of at most 90 degrees so each one is close to a cubic
+	pieces := int(math.Ceil(math.Abs(delta) / (math.Pi / 2)))
+	step := delta / float64(pieces)
+	k := 4.0 / 3.0 * math.Tan(step/4)
+
+	point := func(theta float64) geom.Point {
+		x, y := rx*math.Cos(theta), ry*math.Sin(theta)
+		return geom.Point{X: cos*x - sin*y + cx, Y: sin*x + cos*y + cy}
+	}
+
+	derivative := func(theta float64) geom.Point {
+		x, y := -rx*math.Sin(theta), ry*math.Cos(theta)
+		return geom.Point{X: cos*x - sin*y, Y: sin*x + cos*y}
+	}
+
+	segments := []geom.Segment{}
+	theta := start
+	for i := 0; i < pieces; i++ {
+		next := theta + step
+		p0, p3 := point(theta), point(next)
+		c1 := p0.Add(derivative(theta).Mul(k))
+		c2 := p3.Sub(derivative(next).Mul(k))
+		if i == pieces-1 {
+			p3 = to
+		}
+
+		segments = append(segments, geom.Cubic(c1, c2, p3))
+		theta = next
+	}
+
+	return segments
+}
+
+// axisTolerance is how far a line can be from horizontal or vertical and still be written as an H or V command
+const axisTolerance = 1e-9
+
+// FromOutline converts a geom.Path into path data with absolute coordinates. Axis aligned lines are
+// written as horizontal and vertical lines, and closed contours end with a close path command
+func FromOutline(path geom.Path) PathData {
+	ret := PathData{}
+	for _, c := range path {
+		ret = append(ret, Command{Op: MoveTo, Args: []float64{c.Start.X, c.Start.Y}})
+
+		current := c.Start
+		for i, s := range c.Segments {
+			end := s.End()
+			switch s.Op {
+			case geom.QuadTo:
+				ret = append(ret, Command{Op: QuadTo, Args: []float64{s.Points[0].X, s.Points[0].Y, end.X, end.Y}})
+			case geom.CubicTo:
+				ret = append(ret, Command{Op: CubicTo, Args: []float64{s.Points[0].X, s.Points[0].Y, s.Points[1].X, s.Points[1].Y, end.X, end.Y}})
+			default:
+				switch {
+				case c.Closed && i == len(c.Segments)-1 && end == c.Start:
+					// the close path command draws this line
+				case math.Abs(end.Y-current.Y) < axisTolerance:
+					ret = append(ret, Command{Op: HLineTo, Args: []float64{end.X}})
+				case math.Abs(end.X-current.X) < axisTolerance:
+					ret = append(ret, Command{Op: VLineTo, Args: []float64{end.Y}})
+				default:
+					ret = append(ret, Command{Op: LineTo, Args: []float64{end.X, end.Y}})
+				}
+			}
+			current = end
+		}
+
+		if c.Closed {
+			ret = append(ret, Command{Op: ClosePath})
+		}
+	}
+
+	return ret
+}
+
+// Transform returns path data with the affine transform m applied to every point. Arcs are
+// converted to cubic beziers since an affine transform of an arc can not always be written as an arc
+func (p PathData) Transform(m linalg.Mat3x) PathData {
+	return FromOutline(p.Outline().Transform(m))
+}
@@ -0,0 +1,271 @@
+package svg
+
+import (
+	"math"
+	"testing"
+
+	"github.com/bjatkin/silabex/geom"
+	"github.com/bjatkin/silabex/linalg"
+)
+
+func TestParsePathData(t *testing.T) {
+	tests := []struct {
+		name    string
+		d       string
+		want    string
+		wantErr bool
+	}{
+		{
+			"absolute commands",
+			"M 140,460 H 860 V 540 H 140 Z",
+			"M140 460H860V540H140Z",
+			false,
+		},
+		{
+			"implicit lineto",
+			"m 440,130 80,-10 c 0,40 20,100 40,130 l -80,10 C 460,230 440,170 440,130",
+			"m440 130 80-10c0 40 20 100 40 130l-80 10C460 230 440 170 440 130",
+			false,
+		},
+		{
+			"decimals",
+			"M 0.5,0.5 L -0.25 0.75",
+			"M.5.5-.25.75",
+			false,
+		},
+		{
+			"smooth curves",
+			"M0 0 S 10 10 20 0 T 40 0 q 5 5 10 0 t 10 0 10 0",
+			"M0 0S10 10 20 0T40 0q5 5 10 0t10 0 10 0",
+			false,
+		},
+		{
+			"arc flags without separators",
+			"M10 10a5 5 0 1020 0A5 5 30 0 1 10 10z",
+			"M10 10a5 5 0 1 0 20 0A5 5 30 0 1 10 10z",
+			false,
+		},
+		{
+			"exponent",
+			"M1e2-1E-1",
+			"M100-.1",
+			false,
+		},
+		{
+			"missing command",
+			"10 10",
+			"",
+			true,
+		},
+		{
+			"unknown command",
+			"M 0 0 X 10",
+			"",
+			true,
+		},
+		{
+			"arguments after close path",
+			"M 0 0 L 10 10 Z 5 5",
+			"",
+			true,
+		},
+		{
+			"too few arguments",
+			"M 0 0 C 10 10 20",
+			"",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePathData(tt.d)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePathData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if got.String() != tt.want {
+				t.Errorf("ParsePathData().String() = %s, want %s", got.String(), tt.want)
+			}
+
+			again, err := ParsePathData(got.String())
+			if err != nil {
+				t.Fatalf("ParsePathData() of the serialized data error = %v", err)
+			}
+
+			if !pathEqual(again.Outline(), got.Outline(), 1e-9) {
+				t.Errorf("serialized data %s does not match %s", got.String(), tt.d)
+			}
+		})
+	}
+}
+
+func TestPathData_Format(t *testing.T) {
+	data, err := ParsePathData("M 0.123456,-0.0001 L 10.5 20.25")
+	if err != nil {
+		t.Fatalf("ParsePathData() error = %v", err)
+	}
+
+	tests := []struct {
+		precision int
+		want      string
+	}{
+		{-1, "M.123456-.0001 10.5 20.25"},
+		{3, "M.123 0 10.5 20.25"},
+		{0, "M0 0 10 20"},
+	}
+	for _, tt := range tests {
+		if got := data.Format(tt.precision); got != tt.want {
+			t.Errorf("Format(%d) = %s, want %s", tt.precision, got, tt.want)
+		}
+	}
+}
+
+func TestPathData_Outline(t *testing.T) {
+	tests := []struct {
+		name string
+		d    string
+		want geom.Path
+	}{
+		{
+			"relative lines",
+			"m 10 10 h 10 v 10 l -10 0 z",
+			geom.Path{{
+				Start: geom.Point{X: 10, Y: 10},
+				Segments: []geom.Segment{
+					geom.Line(geom.Point{X: 20, Y: 10}),
+					geom.Line(geom.Point{X: 20, Y: 20}),
+					geom.Line(geom.Point{X: 10, Y: 20}),
+				},
+				Closed: true,
+			}},
+		},
+		{
+			"smooth cubic",
+			"M 0 0 C 0 10 10 10 10 0 s 10 -10 10 0",
+			geom.Path{{
+				Start: geom.Point{X: 0, Y: 0},
+				Segments: []geom.Segment{
+					geom.Cubic(geom.Point{X: 0, Y: 10}, geom.Point{X: 10, Y: 10}, geom.Point{X: 10, Y: 0}),
+					geom.Cubic(geom.Point{X: 10, Y: -10}, geom.Point{X: 20, Y: -10}, geom.Point{X: 20, Y: 0}),
+				},
+			}},
+		},
+		{
+			"two contours",
+			"M 0 0 L 10 0 Z L 0 10",
+			geom.Path{
+				{Start: geom.Point{}, Segments: []geom.Segment{geom.Line(geom.Point{X: 10})}, Closed: true},
+				{Start: geom.Point{}, Segments: []geom.Segment{geom.Line(geom.Point{Y: 10})}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOutline(tt.d)
+			if err != nil {
+				t.Fatalf("ParseOutline() error = %v", err)
+			}
+
+			if !pathEqual(got, tt.want, 1e-9) {
+				t.Errorf("ParseOutline() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPathData_Outline_arc(t *testing.T) {
+	got, err := ParseOutline("M 0 0 A 10 10 0 0 1 20 0")
+	if err != nil {
+		t.Fatalf("ParseOutline() error = %v", err)
+	}
+
+	// a half circle is split into two quarter circles that pass through the top of the circle
+	if len(got) != 1 || len(got[0].Segments) != 2 {
+		t.Fatalf("ParseOutline() = %v, want one contour with two segments", got)
+	}
+
+	for _, s := range got[0].Segments {
+		end := s.End()
+		if r := math.Hypot(end.X-10, end.Y); math.Abs(r-10) > 1e-9 {
+			t.Errorf("arc segment ends %v from the center, want 10", r)
+		}
+	}
+
+	if mid := got[0].Segments[0].End(); math.Abs(mid.X-10) > 1e-9 || math.Abs(mid.Y+10) > 1e-9 {
+		t.Errorf("arc passes through %v, want (10, -10)", mid)
+	}
+}
+
+// pathEqual checks if two paths are equal within a given error margin e
+func pathEqual(a, b geom.Path, e float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	near := func(p, q geom.Point) bool {
+		return math.Abs(p.X-q.X) <= e && math.Abs(p.Y-q.Y) <= e
+	}
+
+	for i := range a {
+		if len(a[i].Segments) != len(b[i].Segments) || a[i].Closed != b[i].Closed || !near(a[i].Start, b[i].Start) {
+			return false
+		}
+
+		for j := range a[i].Segments {
+			s, t := a[i].Segments[j], b[i].Segments[j]
+			if s.Op != t.Op {
+				return false
+			}
+			for k := range s.Points {
+				if !near(s.Points[k], t.Points[k]) {
+					return false
+				}
+			}
+		}
+	}
+
+	return true
+}
+
+func TestPathData_Transform(t *testing.T) {
+	tests := []struct {
+		name string
+		d    string
+		m    linalg.Mat3x
+		want string
+	}{
+		{
+			"translate",
+			"m 140,460 h 720 v 80 H 140 Z",
+			linalg.Translate(10, -20),
+			"M150 440H870V520H150Z",
+		},
+		{
+			"mirror",
+			"M 0 0 C 0 10 10 10 10 0",
+			linalg.Transform(linalg.Translate(610, 0), linalg.Scale(-1, 1)),
+			"M610 0C610 10 600 10 600 0",
+		},
+		{
+			"rotate",
+			"M 0 0 H 10",
+			linalg.Rotate(math.Pi / 2),
+			"M0 0V10",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := ParsePathData(tt.d)
+			if err != nil {
+				t.Fatalf("ParsePathData() error = %v", err)
+			}
+
+			if got := data.Transform(tt.m).Format(3); got != tt.want {
+				t.Errorf("Transform() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package svg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/JoshVarga/svgparser"
+	"github.com/bjatkin/silabex/geom"
+)
+
+// strokeTolerance is the max distance between a stroked curve and the polyline used to outline it
+const strokeTolerance = 0.1
+
+// style returns the presentation properties of the element. Properties in the style attribute
+// override presentation attributes, the same way css does
+func style(elem *svgparser.Element) map[string]string {
+	ret := map[string]string{}
+	for _, name := range []string{"display", "fill", "stroke", "stroke-width", "stroke-linejoin", "stroke-linecap", "stroke-miterlimit"} {
+		if value, ok := elem.Attributes[name]; ok {
+			ret[name] = strings.TrimSpace(value)
+		}
+	}
+
+	for _, decl := range strings.Split(elem.Attributes["style"], ";") {
+		name, value, ok := strings.Cut(decl, ":")
+		if ok {
+			ret[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+
+	return ret
+}
+
+// strokeStyle returns the stroke the element is drawn with, false is returned if the element has no stroke
+func strokeStyle(props map[string]string) (geom.StrokeStyle, bool, error) {
+	if props["stroke"] == "" || props["stroke"] == "none" {
+		return geom.StrokeStyle{}, false, nil
+	}
+
+	ret := geom.StrokeStyle{Width: 1}
+	if width, ok := props["stroke-width"]; ok {
+		w, err := strconv.ParseFloat(strings.TrimSuffix(width, "px"), 64)
+		if err != nil {
+			return geom.StrokeStyle{}, false, fmt.Errorf("invalid stroke-width '%s'", width)
+		}
+		ret.Width = w
+	}
+
+	if limit, ok := props["stroke-miterlimit"]; ok {
+		l, err := strconv.ParseFloat(limit, 64)
+		if err != nil {
+			return geom.StrokeStyle{}, false, fmt.Errorf("invalid stroke-miterlimit '%s'", limit)
+		}
+		ret.MiterLimit = l
+	}
+
+	switch props["stroke-linejoin"] {
+	case "", "miter", "miter-clip", "arcs":
+		ret.Join = geom.MiterJoin
+	case "round":
+		ret.Join = geom.RoundJoin
+	case "bevel":
+		ret.Join = geom.BevelJoin
+	default:
+		return geom.StrokeStyle{}, false, fmt.Errorf("invalid stroke-linejoin '%s'", props["stroke-linejoin"])
+	}
+
+	switch props["stroke-linecap"] {
+	case "", "butt":
+		ret.Cap = geom.ButtCap
+	case "round":
+		ret.Cap = geom.RoundCap
+	case "square":
+		ret.Cap = geom.SquareCap
+	default:
+		return geom.StrokeStyle{}, false, fmt.Errorf("invalid stroke-linecap '%s'", props["stroke-linecap"])
+	}
+
+	return ret, true, nil
+}
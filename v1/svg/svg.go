@@ -2,9 +2,12 @@ package svg
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/JoshVarga/svgparser"
+	"github.com/bjatkin/silabex/geom"
+	"github.com/bjatkin/silabex/linalg"
 )
 
 // Group represents a group of strokes that make up a silbex character
@@ -30,11 +33,15 @@ func (g Group) SVG() string {
 	}
 
 	for _, elem := range g.elements {
+		attrs := ""
 		if elem.Attributes["transform"] != "" {
-			ret = append(ret, fmt.Sprintf("<path transform=\"%s\" d=\"%s\" />", elem.Attributes["transform"], elem.Attributes["d"]))
-		} else {
-			ret = append(ret, fmt.Sprintf("<path d=\"%s\" />", elem.Attributes["d"]))
+			attrs += fmt.Sprintf(" transform=\"%s\"", elem.Attributes["transform"])
 		}
+		// the style is kept so stroked centerlines are not drawn as filled shapes
+		if elem.Attributes["style"] != "" {
+			attrs += fmt.Sprintf(" style=\"%s\"", elem.Attributes["style"])
+		}
+		ret = append(ret, fmt.Sprintf("<path%s d=\"%s\" />", attrs, elem.Attributes["d"]))
 	}
 
 	ret = append(ret, "</g>")
@@ -44,20 +51,35 @@ func (g Group) SVG() string {
 
 // NewGroup creates a new Group from an svgparser.Element
 func NewGroup(root *svgparser.Element, dx, dy float64) *Group {
+	if root == nil {
+		return &Group{}
+	}
+
 	copyElement := func(elem *svgparser.Element) *svgparser.Element {
 		attrs := map[string]string{}
 		for k, v := range elem.Attributes {
 			attrs[k] = v
 		}
 
-		if dy == 0 {
-			attrs["transform"] = fmt.Sprintf("translate(0 %.2f)", dy)
+		// the transform of the labeled group is applied outside of the element's own transform
+		transforms := []string{}
+		if dx != 0 || dy != 0 {
+			transforms = append(transforms, fmt.Sprintf("translate(%.2f %.2f)", dx, dy))
+		}
+		if root.Attributes["transform"] != "" {
+			transforms = append(transforms, root.Attributes["transform"])
+		}
+		if elem.Attributes["transform"] != "" {
+			transforms = append(transforms, elem.Attributes["transform"])
+		}
+		if len(transforms) > 0 {
+			attrs["transform"] = strings.Join(transforms, " ")
 		}
 
 		return &svgparser.Element{
-			Name:       root.Name,
+			Name:       elem.Name,
 			Attributes: attrs,
-			Content:    root.Content,
+			Content:    elem.Content,
 		}
 	}
 
@@ -71,6 +93,172 @@ func NewGroup(root *svgparser.Element, dx, dy float64) *Group {
 	}
 }
 
+// Outline returns the shape drawn by all the paths in the group with their transforms applied.
+// Stroked paths are expanded into the outline of the stroke so the result can always be filled
+func (g Group) Outline() (geom.Path, error) {
+	ret := geom.Path{}
+	for _, elem := range g.elements {
+		shape, err := ElementOutline(elem)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, shape.Transform(linalg.Translate(g.dx, 0))...)
+	}
+
+	return ret, nil
+}
+
+// ElementOutline returns the shape drawn by a single element with its own transform applied. Hidden
+// elements and elements that draw nothing return an empty path
+func ElementOutline(elem *svgparser.Element) (geom.Path, error) {
+	props := style(elem)
+
+	// hidden paths are left over construction lines in the template
+	if (elem.Name != "path" && elem.Name != "rect") || props["display"] == "none" {
+		return geom.Path{}, nil
+	}
+
+	transform, err := ParseTransform(elem.Attributes["transform"])
+	if err != nil {
+		return nil, fmt.Errorf("element %s: %w", elem.Attributes["id"], err)
+	}
+
+	path, err := Shape(elem)
+	if err != nil {
+		return nil, err
+	}
+
+	stroke, stroked, err := strokeStyle(props)
+	if err != nil {
+		return nil, fmt.Errorf("element %s: %w", elem.Attributes["id"], err)
+	}
+
+	// the stroke is outlined before the transform so a scaled element gets a scaled stroke
+	shape := geom.Path{}
+	if props["fill"] != "none" {
+		shape = append(shape, path...)
+	}
+	if stroked {
+		shape = append(shape, path.Stroke(stroke, strokeTolerance)...)
+	}
+
+	return shape.Transform(transform), nil
+}
+
+// collisionTolerance is the max distance between a curve and the polyline used to check it for collisions
+const collisionTolerance = 0.5
+
+// Bounds returns the bounding box of the group's outline, an empty group has an empty box
+func (g Group) Bounds() (geom.Rect, error) {
+	outline, err := g.Outline()
+	if err != nil {
+		return geom.Rect{}, err
+	}
+
+	r, _ := outline.Bounds()
+	return r, nil
+}
+
+// Intersects reports whether the shapes drawn by the groups overlap. The bounding boxes are checked
+// before the curves so groups that are far apart are cheap to check
+func (g Group) Intersects(h Group) (bool, error) {
+	a, err := g.Outline()
+	if err != nil {
+		return false, err
+	}
+
+	b, err := h.Outline()
+	if err != nil {
+		return false, err
+	}
+
+	return a.Intersects(b, collisionTolerance), nil
+}
+
+// Shape returns the geometry of a path or rect element without its transform applied
+func Shape(elem *svgparser.Element) (geom.Path, error) {
+	switch elem.Name {
+	case "path":
+		path, err := ParseOutline(elem.Attributes["d"])
+		if err != nil {
+			return nil, fmt.Errorf("element %s: %w", elem.Attributes["id"], err)
+		}
+		return path, nil
+	case "rect":
+		values := map[string]float64{}
+		for _, attr := range []string{"x", "y", "width", "height"} {
+			if elem.Attributes[attr] == "" {
+				continue
+			}
+
+			f, err := strconv.ParseFloat(strings.TrimSuffix(elem.Attributes[attr], "px"), 64)
+			if err != nil {
+				return nil, fmt.Errorf("element %s: invalid %s '%s'", elem.Attributes["id"], attr, elem.Attributes[attr])
+			}
+			values[attr] = f
+		}
+
+		r := geom.NewRect(values["x"], values["y"], values["width"], values["height"])
+		return geom.Path{{
+			Start: r.Min,
+			Segments: []geom.Segment{
+				geom.Line(geom.Point{X: r.Max.X, Y: r.Min.Y}),
+				geom.Line(r.Max),
+				geom.Line(geom.Point{X: r.Min.X, Y: r.Max.Y}),
+			},
+			Closed: true,
+		}}, nil
+	default:
+		return nil, fmt.Errorf("element %s: %s elements have no shape", elem.Attributes["id"], elem.Name)
+	}
+}
+
+// flattenPrecision is the number of decimal places kept when transforms are baked into path data
+const flattenPrecision = 3
+
+// Flatten returns a copy of the group with every transform applied to the path data, so the group
+// can be drawn by renderers that ignore transform attributes. Paths with path data that can not be
+// parsed are copied as they are and keep their transforms. Stroke widths are not scaled
+func (g Group) Flatten() *Group {
+	elements := []*svgparser.Element{}
+	for _, elem := range g.elements {
+		attrs := map[string]string{}
+		for k, v := range elem.Attributes {
+			attrs[k] = v
+		}
+
+		transforms := []string{}
+		if g.dx != 0 {
+			transforms = append(transforms, fmt.Sprintf("translate(%.2f 0)", g.dx))
+		}
+		if attrs["transform"] != "" {
+			transforms = append(transforms, attrs["transform"])
+		}
+		attrs["transform"] = strings.Join(transforms, " ")
+
+		transform, err := ParseTransform(attrs["transform"])
+		if err == nil && elem.Name == "path" {
+			var data PathData
+			data, err = ParsePathData(attrs["d"])
+			if err == nil {
+				attrs["d"] = data.Transform(transform).Format(flattenPrecision)
+				delete(attrs, "transform")
+			}
+		}
+
+		elements = append(elements, &svgparser.Element{
+			Name:       elem.Name,
+			Attributes: attrs,
+			Content:    elem.Content,
+		})
+	}
+
+	return &Group{
+		elements: elements,
+	}
+}
+
 // Merge merges multiple groups together
 func Merge(groups ...*Group) *Group {
 	elements := []*svgparser.Element{}
@@ -0,0 +1,178 @@
+package svg
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/JoshVarga/svgparser"
+	"github.com/bjatkin/silabex/geom"
+)
+
+func TestGroup_Flatten(t *testing.T) {
+	tests := []struct {
+		name     string
+		root     *svgparser.Element
+		dx, dy   float64
+		shift    float64
+		wantD    []string
+		wantKeep bool
+	}{
+		{
+			"group transform",
+			&svgparser.Element{
+				Name:       "g",
+				Attributes: map[string]string{"transform": "matrix(-1,0,0,1,610,0)"},
+				Children: []*svgparser.Element{
+					{Name: "path", Attributes: map[string]string{"d": "m 10,10 h 20 v 20 z"}},
+				},
+			},
+			0, 0, 0,
+			[]string{"M600 10H580V30Z"},
+			false,
+		},
+		{
+			"offset and shift",
+			&svgparser.Element{
+				Name: "g",
+				Children: []*svgparser.Element{
+					{Name: "path", Attributes: map[string]string{"d": "M 0,0 H 10", "transform": "scale(2)"}},
+				},
+			},
+			0, -140, 390,
+			[]string{"M390-140H410"},
+			false,
+		},
+		{
+			"invalid path data",
+			&svgparser.Element{
+				Name: "g",
+				Children: []*svgparser.Element{
+					{Name: "path", Attributes: map[string]string{"d": "10 10"}},
+				},
+			},
+			0, 0, 390,
+			[]string{"10 10"},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGroup(tt.root, tt.dx, tt.dy)
+			g.Transform(tt.shift)
+
+			got := g.Flatten()
+			if got.dx != 0 {
+				t.Errorf("Flatten() dx = %v, want 0", got.dx)
+			}
+
+			for i, elem := range got.elements {
+				if elem.Attributes["d"] != tt.wantD[i] {
+					t.Errorf("Flatten() d = %s, want %s", elem.Attributes["d"], tt.wantD[i])
+				}
+
+				_, keep := elem.Attributes["transform"]
+				if keep != tt.wantKeep {
+					t.Errorf("Flatten() kept transform = %v, want %v", keep, tt.wantKeep)
+				}
+			}
+
+			if !tt.wantKeep && strings.Contains(got.SVG(), "transform") {
+				t.Errorf("Flatten().SVG() = %s, want no transforms", got.SVG())
+			}
+		})
+	}
+}
+
+// boxGroup is a group with a single square path
+func TestNewGroup(t *testing.T) {
+	root := &svgparser.Element{
+		Name:       "g",
+		Attributes: map[string]string{"transform": "translate(100 0)"},
+		Children: []*svgparser.Element{
+			{Name: "path", Attributes: map[string]string{"d": "M 0,0 h 10 v 10 h -10 Z"}},
+		},
+	}
+
+	// the offset used to only be written when dy was 0, so hang strokes were drawn in their stand position
+	// and every other element got a translate(0 0.00) that did nothing
+	tests := []struct {
+		name    string
+		dx, dy  float64
+		wantSVG string
+	}{
+		{"no offset", 0, 0, `<path transform="translate(100 0)" d="M 0,0 h 10 v 10 h -10 Z" />`},
+		{"hang", 0, -140, `<path transform="translate(0.00 -140.00) translate(100 0)" d="M 0,0 h 10 v 10 h -10 Z" />`},
+		{"shifted", 390, 0, `<path transform="translate(390.00 0.00) translate(100 0)" d="M 0,0 h 10 v 10 h -10 Z" />`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewGroup(root, tt.dx, tt.dy)
+			if !strings.Contains(g.SVG(), tt.wantSVG) {
+				t.Errorf("SVG() = %s, want it to contain %s", g.SVG(), tt.wantSVG)
+			}
+			for _, elem := range g.elements {
+				if elem.Name != "path" {
+					t.Errorf("NewGroup() element name = %s, want path", elem.Name)
+				}
+			}
+		})
+	}
+
+	if g := NewGroup(nil, 0, 0); g.SVG() != "" {
+		t.Errorf("NewGroup(nil).SVG() = %s, want an empty group", g.SVG())
+	}
+}
+
+func boxGroup(x, y, size float64, dx float64) Group {
+	g := NewGroup(&svgparser.Element{
+		Name: "g",
+		Children: []*svgparser.Element{
+			{Name: "path", Attributes: map[string]string{"d": fmt.Sprintf("M %g,%g h %g v %g h %g Z", x, y, size, size, -size)}},
+		},
+	}, 0, 0)
+	g.Transform(dx)
+
+	return *g
+}
+
+func TestGroup_Bounds(t *testing.T) {
+	got, err := boxGroup(10, 20, 30, 100).Bounds()
+	if err != nil {
+		t.Fatalf("Bounds() error = %v", err)
+	}
+	if want := geom.NewRect(110, 20, 30, 30); got != want {
+		t.Errorf("Bounds() = %v, want %v", got, want)
+	}
+
+	got, err = Group{}.Bounds()
+	if err != nil || !got.Empty() {
+		t.Errorf("Bounds() = %v, %v, want an empty box", got, err)
+	}
+}
+
+func TestGroup_Intersects(t *testing.T) {
+	tests := []struct {
+		name string
+		g    Group
+		h    Group
+		want bool
+	}{
+		{"overlap", boxGroup(0, 0, 10, 0), boxGroup(5, 5, 10, 0), true},
+		{"apart", boxGroup(0, 0, 10, 0), boxGroup(20, 0, 10, 0), false},
+		{"shifted into", boxGroup(0, 0, 10, 0), boxGroup(20, 0, 10, -15), true},
+		{"touching", boxGroup(0, 0, 10, 0), boxGroup(10, 0, 10, 0), false},
+		{"empty", Group{}, boxGroup(0, 0, 10, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.g.Intersects(tt.h)
+			if err != nil {
+				t.Fatalf("Intersects() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Intersects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package svg
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/bjatkin/silabex/linalg"
+)
+
+// ParseTransform parses an svg transform attribute into a single matrix. The transforms in the list
+// are applied right to left, the same way nested transforms are applied
+func ParseTransform(transform string) (linalg.Mat3x, error) {
+	ret := linalg.Identity()
+
+	rest := strings.TrimSpace(transform)
+	for rest != "" {
+		open := strings.IndexByte(rest, '(')
+		close := strings.IndexByte(rest, ')')
+		if open < 0 || close < open {
+			return linalg.Mat3x{}, fmt.Errorf("invalid transform '%s'", transform)
+		}
+
+		name := strings.TrimSpace(rest[:open])
+		s := &pathScanner{data: rest[open+1 : close]}
+		args := []float64{}
+		for !s.done() {
+			f, err := s.number()
+			if err != nil {
+				return linalg.Mat3x{}, fmt.Errorf("invalid %s transform: %w", name, err)
+			}
+			args = append(args, f)
+		}
+
+		m, err := transformMatrix(name, args)
+		if err != nil {
+			return linalg.Mat3x{}, err
+		}
+
+		ret = linalg.MatMul(ret, m)
+		rest = strings.TrimLeft(rest[close+1:], " \t\n\r,")
+	}
+
+	return ret, nil
+}
+
+// FormatTransform writes the matrix as an svg matrix transform, the identity matrix is written as
+// an empty string
+func FormatTransform(m linalg.Mat3x) string {
+	if m == linalg.Identity() {
+		return ""
+	}
+
+	return fmt.Sprintf("matrix(%g %g %g %g %g %g)", m.Data[0][0], m.Data[1][0], m.Data[0][1], m.Data[1][1], m.Data[0][2], m.Data[1][2])
+}
+
+func transformMatrix(name string, args []float64) (linalg.Mat3x, error) {
+	arg := func(i int, fallback float64) float64 {
+		if i < len(args) {
+			return args[i]
+		}
+		return fallback
+	}
+
+	counts := map[string][]int{
+		"matrix":    {6},
+		"translate": {1, 2},
+		"scale":     {1, 2},
+		"rotate":    {1, 3},
+		"skewX":     {1},
+		"skewY":     {1},
+	}
+
+	valid, ok := counts[name]
+	if !ok {
+		return linalg.Mat3x{}, fmt.Errorf("unknown transform '%s'", name)
+	}
+
+	if !slices.Contains(valid, len(args)) {
+		return linalg.Mat3x{}, fmt.Errorf("%s transform takes %v arguments but got %d", name, valid, len(args))
+	}
+
+	switch name {
+	case "matrix":
+		return linalg.Mat3x{
+			Data: [3][3]float64{
+				{args[0], args[2], args[4]},
+				{args[1], args[3], args[5]},
+				{0, 0, 1},
+			},
+		}, nil
+	case "translate":
+		return linalg.Translate(args[0], arg(1, 0)), nil
+	case "scale":
+		return linalg.Scale(args[0], arg(1, args[0])), nil
+	case "rotate":
+		cx, cy := arg(1, 0), arg(2, 0)
+		return linalg.Transform(
+			linalg.Translate(cx, cy),
+			linalg.Rotate(args[0]*math.Pi/180),
+			linalg.Translate(-cx, -cy),
+		), nil
+	case "skewX":
+		return linalg.Mat3x{
+			Data: [3][3]float64{
+				{1, math.Tan(args[0] * math.Pi / 180), 0},
+				{0, 1, 0},
+				{0, 0, 1},
+			},
+		}, nil
+	default:
+		return linalg.Mat3x{
+			Data: [3][3]float64{
+				{1, 0, 0},
+				{math.Tan(args[0] * math.Pi / 180), 1, 0},
+				{0, 0, 1},
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,36 @@
+package svg
+
+import (
+	"testing"
+
+	"github.com/bjatkin/silabex/linalg"
+)
+
+func TestFormatTransform(t *testing.T) {
+	tests := []struct {
+		name string
+		m    linalg.Mat3x
+		want string
+	}{
+		{"identity", linalg.Identity(), ""},
+		{"translate", linalg.Translate(10, -5), "matrix(1 0 0 1 10 -5)"},
+		{"flip", linalg.ScaleAt(-1, 1, 300, 0), "matrix(-1 0 0 1 600 0)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FormatTransform(tt.m)
+			if got != tt.want {
+				t.Fatalf("FormatTransform() = %q, want %q", got, tt.want)
+			}
+
+			parsed, err := ParseTransform(got)
+			if err != nil {
+				t.Fatalf("ParseTransform() error = %v", err)
+			}
+			if parsed != tt.m {
+				t.Errorf("ParseTransform() = %v, want %v", parsed, tt.m)
+			}
+		})
+	}
+}